@@ -0,0 +1,212 @@
+// Import uploads a catalogue manifest with its torrents to the admin
+// import endpoint and prints the report.
+//
+//	import -token $TOKEN -manifest movies.csv -torrents ./torrents
+//
+// Torrents are a directory or a .zip archive, the files of a directory
+// are checked before the upload.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+)
+
+const (
+	importPath = "/admin/movies/import"
+	timeout    = 10 * time.Minute
+)
+
+func main() {
+	var (
+		api      = flag.String("api", "http://localhost/api/v1", "url of the api")
+		token    = flag.String("token", os.Getenv("ADMIN_TOKEN"), "access token of an admin, ADMIN_TOKEN by default")
+		manifest = flag.String("manifest", "", "manifest of the movies, a .json or .csv file")
+		torrents = flag.String("torrents", "", "directory or .zip archive with the torrents of the manifest")
+	)
+
+	flag.Parse()
+
+	if *token == "" || *manifest == "" || *torrents == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	report, err := upload(*api+importPath, *token, *manifest, *torrents)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Can`t import. Error:", err)
+		os.Exit(1)
+	}
+
+	for _, row := range report.Rows {
+		if row.Error != "" {
+			fmt.Printf("%d\t%s\tfailed: %s\n", row.Row, row.Name, row.Error)
+		} else {
+			fmt.Printf("%d\t%s\tcreated movie %d with %d sources\n", row.Row, row.Name, row.MovieId, row.Sources)
+		}
+	}
+
+	fmt.Printf("Created: %d, failed: %d.\n", report.Created, report.Failed)
+
+	if report.Failed != 0 {
+		os.Exit(1)
+	}
+}
+
+func upload(url string, token string, manifest string, torrents string) (*dto.ImportDto, error) {
+	manifestData, err := os.ReadFile(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := readTorrents(torrents)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+
+	form := multipart.NewWriter(&body)
+
+	if err := addFile(form, "manifest", filepath.Base(manifest), manifestData); err != nil {
+		return nil, err
+	}
+
+	if err := addFile(form, "archive", "torrents.zip", archive); err != nil {
+		return nil, err
+	}
+
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: timeout}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var resErr struct {
+			Error string `json:"error"`
+		}
+
+		if json.Unmarshal(data, &resErr) == nil && resErr.Error != "" {
+			return nil, errors.New(resErr.Error)
+		}
+
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+
+	var report dto.ImportDto
+
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// readTorrents returns the archive as it is or packs the .torrent files of
+// the directory into one, every file is opened with decode.Open first.
+func readTorrents(torrents string) ([]byte, error) {
+	info, err := os.Stat(torrents)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return os.ReadFile(torrents)
+	}
+
+	var (
+		buff    bytes.Buffer
+		invalid []string
+	)
+
+	archive := zip.NewWriter(&buff)
+
+	err = filepath.WalkDir(torrents, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".torrent" {
+			return err
+		}
+
+		if _, openErr := decode.Open(path); openErr != nil {
+			invalid = append(invalid, path+": "+openErr.Error())
+			return nil
+		}
+
+		name, err := filepath.Rel(torrents, path)
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		file, err := archive.Create(filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+
+		_, err = file.Write(data)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(invalid) != 0 {
+		return nil, errors.New("invalid torrents:\n" + strings.Join(invalid, "\n"))
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
+
+func addFile(form *multipart.Writer, field string, name string, data []byte) error {
+	file, err := form.CreateFormFile(field, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+
+	return err
+}
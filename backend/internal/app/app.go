@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -14,6 +15,9 @@ import (
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/state"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/migrations"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/cache"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/p2p"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/blob"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/postgresql"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/redis"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/logging"
@@ -36,8 +40,11 @@ func New(configPath string) *App {
 
 	logger := logging.NewLogger(cfg.Logger)
 
-	if err := os.MkdirAll("files", 0777); err != nil {
-		logger.Error("Can`t init files directory. Error:", err)
+	// The pieces, the torrents and the images all live in the blob store,
+	// the app can`t work without it.
+	blobs, err := blob.New(cfg.Blob)
+	if err != nil {
+		panic("Can`t init blob store. Error: " + err.Error())
 	}
 
 	gin.SetMode(gin.ReleaseMode)
@@ -46,33 +53,40 @@ func New(configPath string) *App {
 	
 	postgres, err := postgresql.ConnectToDB(ctx, cfg.Postgres)
 	if err != nil {
-		logger.Error("Can`t connect to db. Error:", err)
+		logger.Error("Can`t connect to db. Error:", logging.ErrAttr(err))
 	} else {
 		logger.Info("Db is connected.")
 	}
 
 	if err := migrations.Migrate(postgres); err != nil {
-		logger.Error("Can`t migrate db scheme. Error:", err)
+		logger.Error("Can`t migrate db scheme. Error:", logging.ErrAttr(err))
 	}
 
 	redis, err := redis.ConnectToRedis(ctx, cfg.Redis)
 	if err != nil {
-		slog.Error("Can`t conntect redis. Error:", err)
+		slog.Error("Can`t conntect redis. Error:", logging.ErrAttr(err))
 	} else {
 		slog.Info("Redis is connected.")
 	}
 
 	jwt := auth.NewJwt(cfg.Jwt)
 
+	p2p.SetBandwidth(*cfg.Bandwidth)
+
 	state := state.New()
 
+	cache, err := cache.New(blobs, cfg.Cache)
+	if err != nil {
+		logger.Error("Can`t index cached pieces. Error:", logging.ErrAttr(err))
+	}
+
 	app := &App{}
 
-	app.storage = storage.New(postgres, redis)
+	app.storage = storage.New(postgres, redis, blobs)
 	
-	app.usecase = usecase.New(app.storage, state, jwt)
+	app.usecase = usecase.New(app.storage, state, cache, jwt, cfg.Health)
 
-	app.controller = controller.New(app.usecase)
+	app.controller = controller.New(app.usecase, cfg.Http)
 
 	handler := app.controller.InitRoutes()
 
@@ -82,8 +96,10 @@ func New(configPath string) *App {
 }
 
 func (a *App) Run() error {
+	a.usecase.Health.Start()
+
 	if err := a.server.Start(a.logger); err != nil {
-		slog.Error("Can`t run application. Error:", err)
+		slog.Error("Can`t run application. Error:", logging.ErrAttr(err))
 	}
 
 	a.logger.Info("Application is running.")
@@ -91,7 +107,7 @@ func (a *App) Run() error {
 	err := ShutdownApp(a)
 
 	if err != nil {
-		a.logger.Error("Application shutdown error. Error:", err)
+		a.logger.Error("Application shutdown error. Error:", logging.ErrAttr(err))
 	}
 
 	a.logger.Info("Application Shutting down.")
@@ -107,6 +123,12 @@ func ShutdownApp(a *App) error {
 	if err := a.server.Shutdown(context.Background()); err != nil {
 		return err
 	}
+
+	a.usecase.Health.Stop()
+
+	if err := a.usecase.Resume.Save(context.Background()); err != nil {
+		return errors.New(err.Message)
+	}
 	
 	return nil
 }
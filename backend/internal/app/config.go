@@ -3,7 +3,12 @@ package app
 import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
+	controller "github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/auth"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/health"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/cache"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/p2p"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/blob"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/postgresql"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/redis"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/logging"
@@ -11,11 +16,16 @@ import (
 )
 
 type AppConfig struct {
-	Logger   *logging.Config    `yaml:"logger"`
-	Postgres *postgresql.Config `yaml:"postgres"`
-	Redis    *redis.Config		`yaml:"redis"`
-	Server   *server.Config		`yaml:"server"`
-	Jwt      *auth.JwtOptions   `yaml:"jwt"`
+	Logger    *logging.Config      `yaml:"logger"`
+	Postgres  *postgresql.Config   `yaml:"postgres"`
+	Redis     *redis.Config        `yaml:"redis"`
+	Server    *server.Config       `yaml:"server"`
+	Jwt       *auth.JwtOptions     `yaml:"jwt"`
+	Health    *health.Config       `yaml:"health"`
+	Bandwidth *p2p.BandwidthConfig `yaml:"bandwidth"`
+	Blob      *blob.Config         `yaml:"blob"`
+	Cache     *cache.Config        `yaml:"cache"`
+	Http      *controller.Config   `yaml:"http"`
 }
 
 func GetAppConfig(path string) (*AppConfig, error) {
@@ -6,7 +6,9 @@ import (
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/pkg/account"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/pkg/admin"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/pkg/auth"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/pkg/catalogue"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/pkg/comment"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/pkg/history"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/pkg/movie"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/pkg/playlist"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase"
@@ -16,20 +18,30 @@ type Controller struct {
 	account   *account.Account
 	admin     *admin.Admin
 	auth      *auth.Auth
+	catalogue *catalogue.Catalogue
 	comment   *comment.Comment
+	history   *history.History
 	movie     *movie.Movies
 	playlist  *playlist.Playlist
 	middleware *middleware.Middleware
 }
 
-func New(uc *usecase.UseCase) *Controller {
+// Config is for the browser clients of the api.
+type Config struct {
+	FrontendUrl string `yaml:"frontendUrl"`
+}
+
+func New(uc *usecase.UseCase, cfg *Config) *Controller {
 	return &Controller{
 		account:  account.New(uc.Accounts),
 		admin:    admin.New(uc.Admin),
 		auth:     auth.New(uc.Auth),
+		catalogue: catalogue.New(uc.Catalogue),
 		comment:  comment.New(uc.Comment),
-		movie:    movie.New(uc.Movies),
+		history:  history.New(uc.History),
+		movie:    movie.New(uc.Movies, cfg.FrontendUrl),
 		playlist: playlist.New(uc.Playlist),
+		middleware: middleware.New(uc.Jwt),
 	}
 }
 
@@ -38,16 +50,20 @@ func (c *Controller) InitRoutes() *gin.Engine {
 	
 	api := router.Group("/api/v1")
 	{
-		movieGroup := movie.InitRoutes(api, c.movie)
+		movieGroup := movie.InitRoutes(api, c.movie, c.middleware)
 
 		comment.InitRoutes(movieGroup, c.comment, c.middleware)
 
+		catalogue.InitRoutes(api, c.catalogue)
+
 		accountGroup := account.InitRoutes(api, c.account, c.middleware)
 
 		auth.InitRoutes(accountGroup, c.auth, c.middleware)
 
 		playlist.InitRoutes(accountGroup, c.playlist, c.middleware)
 
+		history.InitRoutes(accountGroup, c.history, c.middleware)
+
 		admin.InitRoutes(api, c.admin, c.middleware)
 	}
 
@@ -1,6 +1,10 @@
 package dto
 
-import "github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+import (
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+)
 
 const (
 	adminRole      = "ADMIN"
@@ -21,6 +25,61 @@ type RemoveAdminDto struct {
 	Username string `json:"username"`
 }
 
+type HealthDto struct {
+	Path      string     `json:"path"`
+	Alive     bool       `json:"alive"`
+	Seeders   int        `json:"seeders"`
+	Leechers  int        `json:"leechers"`
+	Completed int        `json:"completed"`
+	Peers     int        `json:"peers"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checkedAt"`
+}
+
+type LimitsDto struct {
+	Global  int `json:"global"`
+	Torrent int `json:"torrent"`
+	Peer    int `json:"peer"`
+}
+
+type BandwidthDto struct {
+	Download LimitsDto `json:"download"`
+	Upload   LimitsDto `json:"upload"`
+}
+
+func BandwidthToDto(bandwidth *entity.Bandwidth) *BandwidthDto {
+	return &BandwidthDto{
+		Download: LimitsDto(bandwidth.Download),
+		Upload:   LimitsDto(bandwidth.Upload),
+	}
+}
+
+func (b *BandwidthDto) ToEntity() *entity.Bandwidth {
+	return &entity.Bandwidth{
+		Download: entity.Limits(b.Download),
+		Upload:   entity.Limits(b.Upload),
+	}
+}
+
+func HealthToDto(health *entity.Health) *HealthDto {
+	var checkedAt *time.Time
+
+	if !health.CheckedAt.IsZero() {
+		checkedAt = &health.CheckedAt
+	}
+
+	return &HealthDto{
+		Path:      health.Path,
+		Alive:     health.IsAlive(),
+		Seeders:   health.Seeders,
+		Leechers:  health.Leechers,
+		Completed: health.Completed,
+		Peers:     health.Peers,
+		Error:     health.Error,
+		CheckedAt: checkedAt,
+	}
+}
+
 func AdminToDto(admin *entity.User) *AdminDto {
 	var isSuper bool
 	
@@ -34,4 +93,22 @@ func AdminToDto(admin *entity.User) *AdminDto {
 		Username: admin.Username,
 		IsSuper:  isSuper,
 	}
-}
\ No newline at end of file
+}
+
+type SessionDto struct {
+	Id          string    `json:"id"`
+	FileVersion int       `json:"version"`
+	Playhead    int       `json:"playhead"`
+	StartedAt   time.Time `json:"startedAt"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+func SessionToDto(session *entity.Session) *SessionDto {
+	return &SessionDto{
+		Id:          session.Id,
+		FileVersion: session.FileVersion,
+		Playhead:    session.Playhead,
+		StartedAt:   session.StartedAt,
+		LastSeen:    session.LastSeen,
+	}
+}
@@ -0,0 +1,88 @@
+package dto
+
+import "github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+
+type GenreDto struct {
+	Id    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type TagDto struct {
+	Id    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type MoviePageDto struct {
+	Movies []MovieDto `json:"movies"`
+	Total  int        `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+type CollectionDto struct {
+	Id          uint64 `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type CollectionDetailsDto struct {
+	CollectionDto
+	Movies MoviePageDto `json:"movies"`
+}
+
+// CollectionFormDto is used to create and edit collections. Movies replaces
+// the whole ordered list, it is left as is when the field isn`t sent.
+type CollectionFormDto struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Movies      []uint64 `json:"movies"`
+}
+
+func GenreToDto(genre *entity.Genre) GenreDto {
+	return GenreDto{
+		Id:    genre.Id,
+		Name:  genre.Name,
+		Count: genre.Count,
+	}
+}
+
+func TagToDto(tag *entity.Tag) TagDto {
+	return TagDto{
+		Id:    tag.Id,
+		Name:  tag.Name,
+		Count: tag.Count,
+	}
+}
+
+func MoviePageToDto(page *entity.MoviePage, limit int, offset int) MoviePageDto {
+	result := MoviePageDto{
+		Movies: make([]MovieDto, 0, len(page.Movies)),
+		Total:  page.Total,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	for _, movie := range page.Movies {
+		result.Movies = append(result.Movies, MovieToDto(movie))
+	}
+
+	return result
+}
+
+func CollectionToDto(collection *entity.Collection) CollectionDto {
+	return CollectionDto{
+		Id:          collection.Id,
+		Title:       collection.Title,
+		Description: collection.Description,
+	}
+}
+
+func (c *CollectionFormDto) ToEntity() *entity.Collection {
+	return &entity.Collection{
+		Title:       c.Title,
+		Description: c.Description,
+		MoviesIds:   c.Movies,
+	}
+}
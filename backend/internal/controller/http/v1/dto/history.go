@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+)
+
+type HistoryDto struct {
+	Movie       MovieDto  `json:"movie"`
+	Position    int       `json:"position"`
+	FileVersion int       `json:"version"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func HistoryToDto(history *entity.History) HistoryDto {
+	return HistoryDto{
+		Movie:       MovieToDto(history.Movie),
+		Position:    history.Position,
+		FileVersion: history.FileVersion,
+		UpdatedAt:   history.UpdatedAt,
+	}
+}
@@ -0,0 +1,43 @@
+package dto
+
+import "github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+
+type ImportRowDto struct {
+	Row     int    `json:"row"`
+	Name    string `json:"name"`
+	MovieId uint64 `json:"movieId,omitempty"`
+	Sources int    `json:"sources"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ImportDto struct {
+	Created int            `json:"created"`
+	Failed  int            `json:"failed"`
+	Rows    []ImportRowDto `json:"rows"`
+}
+
+func ImportToDto(imports []*entity.Import) *ImportDto {
+	result := &ImportDto{
+		Rows: make([]ImportRowDto, 0, len(imports)),
+	}
+
+	for _, item := range imports {
+		row := ImportRowDto{
+			Row:   item.Row,
+			Name:  item.Movie.Name,
+			Error: item.Error,
+		}
+
+		if item.IsFailed() {
+			result.Failed++
+		} else {
+			row.MovieId = item.Movie.Id
+			row.Sources = len(item.Sources)
+			result.Created++
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result
+}
@@ -0,0 +1,54 @@
+package dto
+
+import "github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+
+type InspectedFileDto struct {
+	Path    string `json:"path"`
+	Length  int    `json:"length"`
+	IsVideo bool   `json:"isVideo"`
+}
+
+type ProblemDto struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type InspectionDto struct {
+	InfoHash    string             `json:"infoHash"`
+	Name        string             `json:"name"`
+	Length      int                `json:"length"`
+	PieceLength int                `json:"pieceLength"`
+	PieceCount  int                `json:"pieceCount"`
+	Files       []InspectedFileDto `json:"files"`
+	Trackers    []string           `json:"trackers"`
+	WebSeeds    []string           `json:"webSeeds"`
+	Private     bool               `json:"private"`
+	IsMagnet    bool               `json:"isMagnet"`
+	Problems    []ProblemDto       `json:"problems"`
+}
+
+func InspectionToDto(inspection *entity.Inspection) *InspectionDto {
+	result := &InspectionDto{
+		InfoHash:    inspection.InfoHash,
+		Name:        inspection.Name,
+		Length:      inspection.Length,
+		PieceLength: inspection.PieceLength,
+		PieceCount:  inspection.PieceCount,
+		Files:       make([]InspectedFileDto, 0, len(inspection.Files)),
+		Trackers:    inspection.Trackers,
+		WebSeeds:    inspection.WebSeeds,
+		Private:     inspection.Private,
+		IsMagnet:    inspection.IsMagnet,
+		Problems:    make([]ProblemDto, 0, len(inspection.Problems)),
+	}
+
+	for _, file := range inspection.Files {
+		result.Files = append(result.Files, InspectedFileDto(file))
+	}
+
+	for _, problem := range inspection.Problems {
+		result.Problems = append(result.Problems, ProblemDto(problem))
+	}
+
+	return result
+}
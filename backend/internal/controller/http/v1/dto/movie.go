@@ -6,24 +6,249 @@ type ChunkDto struct {
 	Buffer     []byte   `json:"buffer"`
 	NextIndex   int  `json:"next"`
 	FileVersion int  `json:"version"`
+	SessionId   string     `json:"session,omitempty"`
+	Required    []RangeDto `json:"required,omitempty"`
+}
+
+type RangeDto struct {
+	Offset     int64 `json:"offset"`
+	Length     int64 `json:"length"`
+	FirstPiece int   `json:"firstPiece"`
+	LastPiece  int   `json:"lastPiece"`
 }
 
 type MovieDto struct {
 	Id   uint64 `json:"id"`
 	Name string `json:"name"`
+	Year int    `json:"year,omitempty"`
+}
+
+type CreditDto struct {
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Character string `json:"character,omitempty"`
+}
+
+type MovieDetailsDto struct {
+	Id            uint64      `json:"id"`
+	Name          string      `json:"name"`
+	OriginalTitle string      `json:"originalTitle,omitempty"`
+	Description   string      `json:"description"`
+	Year          int         `json:"year,omitempty"`
+	Runtime       int         `json:"runtime,omitempty"`
+	Genres        []string    `json:"genres"`
+	Tags          []string    `json:"tags"`
+	Countries     []string    `json:"countries"`
+	AgeRating     string      `json:"ageRating,omitempty"`
+	Credits       []CreditDto `json:"credits"`
 }
 
 func MovieToDto(movie *entity.Movie) MovieDto {
 	return MovieDto{
 		Id: movie.Id,
 		Name: movie.Name,
+		Year: movie.ReleaseYear,
 	}
 }
 
+func MovieToDetailsDto(movie *entity.Movie) MovieDetailsDto {
+	result := MovieDetailsDto{
+		Id:            movie.Id,
+		Name:          movie.Name,
+		OriginalTitle: movie.OriginalTitle,
+		Description:   movie.Description,
+		Year:          movie.ReleaseYear,
+		Runtime:       movie.Runtime,
+		Genres:        make([]string, 0, len(movie.Genres)),
+		Tags:          make([]string, 0, len(movie.Tags)),
+		Countries:     make([]string, 0, len(movie.Countries)),
+		AgeRating:     movie.AgeRating,
+		Credits:       make([]CreditDto, 0, len(movie.Credits)),
+	}
+
+	result.Genres = append(result.Genres, movie.Genres...)
+	result.Tags = append(result.Tags, movie.Tags...)
+	result.Countries = append(result.Countries, movie.Countries...)
+
+	for _, credit := range movie.Credits {
+		result.Credits = append(result.Credits, CreditDto(credit))
+	}
+
+	return result
+}
+
+func CreditsToEntity(credits []CreditDto) []entity.Credit {
+	result := make([]entity.Credit, 0, len(credits))
+
+	for _, credit := range credits {
+		result = append(result, entity.Credit(credit))
+	}
+
+	return result
+}
+
 func ChunkToDto(chunk *entity.Chunk) ChunkDto {
 	return ChunkDto{
 		Buffer: chunk.Buffer,
 		NextIndex: chunk.NextIndex,
 		FileVersion: chunk.FileVersion,
+		SessionId: chunk.SessionId,
+		Required: RangesToDto(chunk.Required),
+	}
+}
+
+func RangesToDto(ranges []entity.ByteRange) []RangeDto {
+	result := make([]RangeDto, 0, len(ranges))
+
+	for _, r := range ranges {
+		result = append(result, RangeDto{
+			Offset:     r.Offset,
+			Length:     r.Length,
+			FirstPiece: r.FirstPiece,
+			LastPiece:  r.LastPiece,
+		})
+	}
+
+	return result
+}
+
+type TrackDto struct {
+	Number   int    `json:"number"`
+	Type     string `json:"type"`
+	Codec    string `json:"codec"`
+	Language string `json:"language"`
+	Name     string `json:"name,omitempty"`
+}
+
+type KeyframeDto struct {
+	Time     float64 `json:"time"`
+	Position int64   `json:"position"`
+}
+
+type MediaDto struct {
+	Container string        `json:"container"`
+	Duration  float64       `json:"duration"`
+	Tracks    []TrackDto    `json:"tracks"`
+	Keyframes []KeyframeDto `json:"keyframes"`
+}
+
+func MediaToDto(media *entity.Media) MediaDto {
+	result := MediaDto{
+		Container: media.Container,
+		Duration:  media.Duration,
+		Tracks:    make([]TrackDto, 0, len(media.Tracks)),
+		Keyframes: make([]KeyframeDto, 0, len(media.Keyframes)),
+	}
+
+	for _, track := range media.Tracks {
+		result.Tracks = append(result.Tracks, TrackDto{
+			Number:   track.Number,
+			Type:     track.Type,
+			Codec:    track.Codec,
+			Language: track.Language,
+			Name:     track.Name,
+		})
+	}
+
+	for _, keyframe := range media.Keyframes {
+		result.Keyframes = append(result.Keyframes, KeyframeDto{
+			Time:     keyframe.Time,
+			Position: keyframe.Position,
+		})
+	}
+
+	return result
+}
+
+type SeekDto struct {
+	Time        float64 `json:"time"`
+	Position    int64   `json:"position"`
+	Piece       int     `json:"piece"`
+	FileVersion int     `json:"version"`
+}
+
+func SeekToDto(seek *entity.Seek) SeekDto {
+	return SeekDto{
+		Time:        seek.Time,
+		Position:    seek.Position,
+		Piece:       seek.Piece,
+		FileVersion: seek.FileVersion,
 	}
-}
\ No newline at end of file
+}
+
+type SubtitleDto struct {
+	Id       string `json:"id"`
+	Language string `json:"language"`
+	Name     string `json:"name"`
+}
+
+func SubtitleToDto(subtitle *entity.Subtitle) SubtitleDto {
+	return SubtitleDto{
+		Id:       subtitle.Id,
+		Language: subtitle.Language,
+		Name:     subtitle.Name,
+	}
+}
+
+// StreamMessageDto is a control message of a stream client: "seek" and
+// "played" carry a piece index, "pause" and "resume" don`t.
+type StreamMessageDto struct {
+	Type  string `json:"type"`
+	Piece int    `json:"piece"`
+}
+
+type StreamEventDto struct {
+	Type        string `json:"type"`
+	SessionId   string `json:"session,omitempty"`
+	FileVersion int    `json:"version,omitempty"`
+	Pieces      int    `json:"pieces,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+type PieceProgressDto struct {
+	Index      int `json:"index"`
+	Downloaded int `json:"downloaded"`
+	Size       int `json:"size"`
+}
+
+type PieceRangeDto struct {
+	First int `json:"first"`
+	Last  int `json:"last"`
+}
+
+type ProgressDto struct {
+	SessionId string             `json:"session"`
+	Playhead  int                `json:"playhead"`
+	Pieces    []PieceProgressDto `json:"pieces"`
+	Buffered  []PieceRangeDto    `json:"buffered"`
+	Peers     int                `json:"peers"`
+	Rate      float64            `json:"rate"`
+}
+
+func ProgressToDto(progress *entity.Progress) ProgressDto {
+	result := ProgressDto{
+		SessionId: progress.SessionId,
+		Playhead:  progress.Playhead,
+		Pieces:    make([]PieceProgressDto, 0, len(progress.Pieces)),
+		Buffered:  make([]PieceRangeDto, 0, len(progress.Buffered)),
+		Peers:     progress.Peers,
+		Rate:      progress.Rate,
+	}
+
+	for _, piece := range progress.Pieces {
+		result.Pieces = append(result.Pieces, PieceProgressDto{
+			Index:      piece.Index,
+			Downloaded: piece.Downloaded,
+			Size:       piece.Size,
+		})
+	}
+
+	for _, r := range progress.Buffered {
+		result.Buffered = append(result.Buffered, PieceRangeDto{
+			First: r.First,
+			Last:  r.Last,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+)
+
+type SourceDto struct {
+	Id        uint64     `json:"id"`
+	Path      string     `json:"path"`
+	InfoHash  string     `json:"infoHash"`
+	AddedBy   uint64     `json:"addedBy,omitempty"`
+	Status    string     `json:"status"`
+	Health    string     `json:"health"`
+	LastError string     `json:"lastError,omitempty"`
+	Priority  int        `json:"priority"`
+	CheckedAt *time.Time `json:"checkedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type SourcesOrderDto struct {
+	Ids []uint64 `json:"ids" binding:"required"`
+}
+
+type SourceStatusDto struct {
+	Status string `json:"status" binding:"required"`
+}
+
+func SourceToDto(source *entity.Source) SourceDto {
+	return SourceDto{
+		Id:        source.Id,
+		Path:      source.Path,
+		InfoHash:  source.InfoHash,
+		AddedBy:   source.AddedBy,
+		Status:    source.Status,
+		Health:    source.Health,
+		LastError: source.LastError,
+		Priority:  source.Priority,
+		CheckedAt: source.CheckedAt,
+		CreatedAt: source.CreatedAt,
+	}
+}
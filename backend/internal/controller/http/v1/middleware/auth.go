@@ -28,9 +28,7 @@ func (m *Middleware) CheckAccess(roles ...string) gin.HandlerFunc {
 			return
 		}
 
-		parts := strings.Split(header, " ")
-		bearer := parts[0]
-		token := parts[1]
+		bearer, token, _ := strings.Cut(header, " ")
 
 		if bearer != bearerType {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, bearerErr)
@@ -43,7 +41,9 @@ func (m *Middleware) CheckAccess(roles ...string) gin.HandlerFunc {
 			return
 		}
 
-		if (len(roles) != 0 || !slices.Contains(roles, "USER")) && (!slices.Contains(roles, claims.Role)) {
+		// Without roles any signed in user is let through, otherwise the
+		// role has to be one of them.
+		if len(roles) != 0 && !slices.Contains(roles, claims.Role) {
 			ctx.AbortWithStatusJSON(http.StatusForbidden, forbiddenErr)
 			return
 		}
@@ -54,3 +54,20 @@ func (m *Middleware) CheckAccess(roles ...string) gin.HandlerFunc {
 	}
 }
 
+// Identify sets userId for requests with a valid token and lets the other
+// ones through, for routes that work for guests too.
+func (m *Middleware) Identify() gin.HandlerFunc {
+	return func (ctx *gin.Context) {
+		bearer, token, _ := strings.Cut(ctx.GetHeader("Authorization"), " ")
+
+		if bearer == bearerType {
+			claims, err := m.jwt.ValidateToken(token)
+
+			if err == nil {
+				ctx.Set("userId", claims.Id)
+			}
+		}
+
+		ctx.Next()
+	}
+}
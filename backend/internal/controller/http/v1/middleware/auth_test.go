@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/auth"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+// fakeJwt takes the role as the token.
+type fakeJwt struct{}
+
+func (fakeJwt) ValidateToken(token string) (*auth.Claims, *e.Error) {
+	return &auth.Claims{Id: 1, Role: token}, nil
+}
+
+func TestCheckAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := New(fakeJwt{})
+
+	tests := []struct {
+		name  string
+		roles []string
+		role  string
+		want  int
+	}{
+		{"no roles, user", nil, "USER", http.StatusOK},
+		{"no roles, admin", nil, "ADMIN", http.StatusOK},
+		{"admin route, admin", []string{"ADMIN"}, "ADMIN", http.StatusOK},
+		{"admin route, user", []string{"ADMIN"}, "USER", http.StatusForbidden},
+		{"user route, user", []string{"USER"}, "USER", http.StatusOK},
+		{"user route, admin", []string{"USER"}, "ADMIN", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		router := gin.New()
+
+		router.GET("/", m.CheckAccess(test.roles...), func(ctx *gin.Context) {
+			ctx.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+test.role)
+
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		if res.Code != test.want {
+			t.Errorf("%s: status = %d, want %d", test.name, res.Code, test.want)
+		}
+	}
+}
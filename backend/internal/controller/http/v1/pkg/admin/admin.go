@@ -2,8 +2,10 @@ package admin
 
 import (
 	"context"
+	"io/fs"
 	"mime/multipart"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
@@ -25,14 +27,39 @@ var (
 	rmMsg      = responses.NewMessage("Admin was removed.")
 	cretaedMsg = responses.NewMessage("New movie created.")
 	updatedMsg = responses.NewMessage("Movie updated.")
+	archivedMsg = responses.NewMessage("Movie archived.")
+	purgedMsg   = responses.NewMessage("Movie deleted.")
+	restoredMsg = responses.NewMessage("Movie restored.")
+	sourcesUpdatedMsg = responses.NewMessage("Sources updated.")
+	sourceDeletedMsg  = responses.NewMessage("Source deleted.")
+	limitsMsg  = responses.NewMessage("Bandwidth limits updated.")
+	collectionCreatedMsg = responses.NewMessage("New collection created.")
+	collectionUpdatedMsg = responses.NewMessage("Collection updated.")
+	collectionDeletedMsg = responses.NewMessage("Collection deleted.")
 )
 
 type AdminUseCase interface {
 	GetAdmins(ctx context.Context) ([]*entity.User, *e.Error)
 	AddAdmin(ctx context.Context, adminId uint64, username string, isSuper bool) *e.Error
 	RemoveAdmin(ctx context.Context, adminId uint64, username string) *e.Error
-	CreateMovie(ctx context.Context, movie *entity.Movie, files []*multipart.FileHeader) *e.Error
-	EditMovie(ctx context.Context, updated *entity.Movie, files []*multipart.FileHeader) *e.Error
+	CreateMovie(ctx context.Context, adminId uint64, movie *entity.Movie, files []*multipart.FileHeader, images map[string]*multipart.FileHeader) *e.Error
+	EditMovie(ctx context.Context, adminId uint64, updated *entity.Movie, files []*multipart.FileHeader, images map[string]*multipart.FileHeader) *e.Error
+	ImportMovies(ctx context.Context, adminId uint64, manifest []byte, format string, torrents fs.FS) ([]*entity.Import, *e.Error)
+	DeleteMovie(ctx context.Context, movieId uint64, purge bool) *e.Error
+	RestoreMovie(ctx context.Context, movieId uint64) *e.Error
+	GetSources(ctx context.Context, movieId uint64) ([]*entity.Source, *e.Error)
+	ReorderSources(ctx context.Context, movieId uint64, ids []uint64) *e.Error
+	SetSourceStatus(ctx context.Context, movieId uint64, sourceId uint64, status string) *e.Error
+	DeleteSource(ctx context.Context, movieId uint64, sourceId uint64) *e.Error
+	GetMovieHealth(ctx context.Context, movieId uint64) ([]*entity.Health, *e.Error)
+	InspectTorrent(ctx context.Context, data []byte) (*entity.Inspection, *e.Error)
+	InspectMagnet(ctx context.Context, link string) (*entity.Inspection, *e.Error)
+	GetViewers(ctx context.Context, movieId uint64) ([]*entity.Session, *e.Error)
+	GetBandwidth(ctx context.Context) *entity.Bandwidth
+	SetBandwidth(ctx context.Context, bandwidth *entity.Bandwidth) *e.Error
+	CreateCollection(ctx context.Context, collection *entity.Collection) *e.Error
+	EditCollection(ctx context.Context, updated *entity.Collection) *e.Error
+	DeleteCollection(ctx context.Context, id uint64) *e.Error
 }
 
 type Admin struct {
@@ -106,17 +133,17 @@ func (a *Admin) CreateMovie(ctx *gin.Context) {
 		return 
 	}
 
-	name, isFound := form.Value["name"]
+	movie, err := movieFromForm(form)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
+		return
+	}
 
-	if !isFound {
+	if movie.Name == "" {
 		ctx.AbortWithStatusJSON(badReq, badReqErr)
 		return 
 	}
 
-	movie := &entity.Movie{
-		Name: name[0],	
-	}
-
 	files, isFound := form.File["files"]
 
 	if !isFound || len(files) == 0 {
@@ -124,9 +151,9 @@ func (a *Admin) CreateMovie(ctx *gin.Context) {
 		return 
 	}
 
-	err := a.usecase.CreateMovie(ctx, movie, files)
+	err = a.usecase.CreateMovie(ctx, ctx.GetUint64("userId"), movie, files, imagesFromForm(form))
 	if err != nil {
-		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
 		return 
 	}
 
@@ -140,21 +167,137 @@ func (a *Admin) EditMovie(ctx *gin.Context) {
 		return 
 	}
 
-	var movie entity.Movie
-
-	name, isFound := form.Value["name"]
+	movie, err := movieFromForm(form)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
+		return
+	}
 
-	if isFound {
-		movie.Name = name[0]
+	movieId, parseErr := strconv.ParseUint(formValue(form, "id"), 10, 64)
+	if parseErr != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
 	}
+
+	movie.Id = movieId
  
 	files := form.File["files"]
 
-	err := a.usecase.EditMovie(ctx, &movie, files)
+	err = a.usecase.EditMovie(ctx, ctx.GetUint64("userId"), movie, files, imagesFromForm(form))
 	if err != nil {
 		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
 		return 
 	}
 
 	ctx.JSON(ok, updatedMsg)
+}
+
+// DeleteMovie archives the movie, with ?purge=true it is removed for good.
+func (a *Admin) DeleteMovie(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	purge, err := strconv.ParseBool(ctx.DefaultQuery("purge", "false"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	movieErr := a.usecase.DeleteMovie(ctx, movieId, purge)
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	if purge {
+		ctx.JSON(ok, purgedMsg)
+	} else {
+		ctx.JSON(ok, archivedMsg)
+	}
+}
+
+func (a *Admin) RestoreMovie(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	movieErr := a.usecase.RestoreMovie(ctx, movieId)
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	ctx.JSON(ok, restoredMsg)
+}
+
+func (a *Admin) GetMovieHealth(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	health, healthErr := a.usecase.GetMovieHealth(ctx, movieId)
+	if healthErr != nil {
+		ctx.AbortWithStatusJSON(healthErr.ToHttpCode(), healthErr)
+		return
+	}
+
+	result := make([]dto.HealthDto, 0)
+
+	for i := 0; i < len(health); i++ {
+		result = append(result, *dto.HealthToDto(health[i]))
+	}
+
+	ctx.JSON(ok, result)
+}
+
+func (a *Admin) GetViewers(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	viewers, viewersErr := a.usecase.GetViewers(ctx, movieId)
+	if viewersErr != nil {
+		ctx.AbortWithStatusJSON(viewersErr.ToHttpCode(), viewersErr)
+		return
+	}
+
+	result := make([]dto.SessionDto, 0)
+
+	for i := 0; i < len(viewers); i++ {
+		result = append(result, *dto.SessionToDto(viewers[i]))
+	}
+
+	ctx.JSON(ok, result)
+}
+
+func (a *Admin) GetBandwidth(ctx *gin.Context) {
+	bandwidth := a.usecase.GetBandwidth(ctx)
+
+	ctx.JSON(ok, dto.BandwidthToDto(bandwidth))
+}
+
+func (a *Admin) SetBandwidth(ctx *gin.Context) {
+	var body dto.BandwidthDto
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	err := a.usecase.SetBandwidth(ctx, body.ToEntity())
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
+		return
+	}
+
+	ctx.JSON(ok, limitsMsg)
 }
\ No newline at end of file
@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
+)
+
+func (a *Admin) CreateCollection(ctx *gin.Context) {
+	var body dto.CollectionFormDto
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	err := a.usecase.CreateCollection(ctx, body.ToEntity())
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
+		return
+	}
+
+	ctx.JSON(created, collectionCreatedMsg)
+}
+
+func (a *Admin) EditCollection(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	var body dto.CollectionFormDto
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	collection := body.ToEntity()
+	collection.Id = id
+
+	collectionErr := a.usecase.EditCollection(ctx, collection)
+	if collectionErr != nil {
+		ctx.AbortWithStatusJSON(collectionErr.ToHttpCode(), collectionErr)
+		return
+	}
+
+	ctx.JSON(ok, collectionUpdatedMsg)
+}
+
+func (a *Admin) DeleteCollection(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	collectionErr := a.usecase.DeleteCollection(ctx, id)
+	if collectionErr != nil {
+		ctx.AbortWithStatusJSON(collectionErr.ToHttpCode(), collectionErr)
+		return
+	}
+
+	ctx.JSON(ok, collectionDeletedMsg)
+}
@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"archive/zip"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
+)
+
+// ImportMovies takes the manifest as a .json or .csv file and the torrents
+// it names as a .zip archive.
+func (a *Admin) ImportMovies(ctx *gin.Context) {
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	manifests, archives := form.File["manifest"], form.File["archive"]
+
+	if len(manifests) == 0 || len(archives) == 0 || strings.ToLower(path.Ext(archives[0].Filename)) != ".zip" {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(path.Ext(manifests[0].Filename)), ".")
+
+	manifestFile, err := manifests[0].Open()
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+	defer manifestFile.Close()
+
+	manifest, err := io.ReadAll(manifestFile)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	archive, err := archives[0].Open()
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+	defer archive.Close()
+
+	torrents, err := zip.NewReader(archive, archives[0].Size)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	report, importErr := a.usecase.ImportMovies(ctx, ctx.GetUint64("userId"), manifest, format, torrents)
+	if importErr != nil {
+		ctx.AbortWithStatusJSON(importErr.ToHttpCode(), importErr)
+		return
+	}
+
+	ctx.JSON(ok, dto.ImportToDto(report))
+}
@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"strconv"
+	"strings"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+// movieFromForm reads the metadata fields of the form. Lists can be sent as
+// repeated fields or as one comma separated field, credits are a JSON array.
+// A list field that is sent empty clears the list on edit.
+func movieFromForm(form *multipart.Form) (*entity.Movie, *e.Error) {
+	var (
+		movie = &entity.Movie{}
+		err   error
+	)
+
+	movie.Name = formValue(form, "name")
+	movie.OriginalTitle = formValue(form, "originalTitle")
+	movie.Description = formValue(form, "description")
+	movie.AgeRating = formValue(form, "ageRating")
+
+	if year := formValue(form, "year"); year != "" {
+		if movie.ReleaseYear, err = strconv.Atoi(year); err != nil {
+			return nil, badReqErr
+		}
+	}
+
+	if runtime := formValue(form, "runtime"); runtime != "" {
+		if movie.Runtime, err = strconv.Atoi(runtime); err != nil {
+			return nil, badReqErr
+		}
+	}
+
+	movie.Genres = formList(form, "genres")
+	movie.Tags = formList(form, "tags")
+	movie.Countries = formList(form, "countries")
+
+	if values, isFound := form.Value["credits"]; isFound {
+		var credits []dto.CreditDto
+
+		if values[0] != "" {
+			if err := json.Unmarshal([]byte(values[0]), &credits); err != nil {
+				return nil, badReqErr
+			}
+		}
+
+		movie.Credits = dto.CreditsToEntity(credits)
+	}
+
+	return movie, nil
+}
+
+// imagesFromForm returns the uploaded images by their kind, only the first
+// file of a field is used.
+func imagesFromForm(form *multipart.Form) map[string]*multipart.FileHeader {
+	images := make(map[string]*multipart.FileHeader)
+
+	for _, kind := range []string{"poster", "backdrop"} {
+		if files := form.File[kind]; len(files) != 0 {
+			images[kind] = files[0]
+		}
+	}
+
+	return images
+}
+
+func formValue(form *multipart.Form, key string) string {
+	values, isFound := form.Value[key]
+
+	if !isFound {
+		return ""
+	}
+
+	return strings.TrimSpace(values[0])
+}
+
+func formList(form *multipart.Form, key string) []string {
+	values, isFound := form.Value[key]
+
+	if !isFound {
+		return nil
+	}
+
+	result := make([]string, 0)
+
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+
+	return result
+}
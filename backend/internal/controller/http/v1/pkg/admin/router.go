@@ -8,6 +8,21 @@ type AdminHandler interface {
 	RemoveAdmin(ctx *gin.Context)
 	CreateMovie(ctx *gin.Context)
 	EditMovie(ctx *gin.Context)
+	ImportMovies(ctx *gin.Context)
+	DeleteMovie(ctx *gin.Context)
+	RestoreMovie(ctx *gin.Context)
+	GetSources(ctx *gin.Context)
+	ReorderSources(ctx *gin.Context)
+	SetSourceStatus(ctx *gin.Context)
+	DeleteSource(ctx *gin.Context)
+	GetMovieHealth(ctx *gin.Context)
+	GetViewers(ctx *gin.Context)
+	InspectTorrent(ctx *gin.Context)
+	GetBandwidth(ctx *gin.Context)
+	SetBandwidth(ctx *gin.Context)
+	CreateCollection(ctx *gin.Context)
+	EditCollection(ctx *gin.Context)
+	DeleteCollection(ctx *gin.Context)
 }
 
 type Middleware interface {
@@ -23,6 +38,31 @@ func InitRoutes(handler *gin.RouterGroup, admin AdminHandler, mid Middleware) *g
 		{
 			movies.POST("/new", admin.CreateMovie)
 			movies.PATCH("/edit", admin.EditMovie)
+			movies.POST("/import", admin.ImportMovies)
+			movies.DELETE("/:id", admin.DeleteMovie)
+			movies.PATCH("/:id/restore", admin.RestoreMovie)
+			movies.GET("/:id/sources", admin.GetSources)
+			movies.PATCH("/:id/sources/order", admin.ReorderSources)
+			movies.PATCH("/:id/sources/:sourceId", admin.SetSourceStatus)
+			movies.DELETE("/:id/sources/:sourceId", admin.DeleteSource)
+			movies.GET("/:id/health", admin.GetMovieHealth)
+			movies.GET("/:id/viewers", admin.GetViewers)
+		}
+
+		torrents := router.Group("/torrents")
+
+		torrents.Use(mid.CheckAccess("ADMIN"))
+		{
+			torrents.POST("/inspect", admin.InspectTorrent)
+		}
+
+		collections := router.Group("/collections")
+
+		collections.Use(mid.CheckAccess("ADMIN"))
+		{
+			collections.POST("/new", admin.CreateCollection)
+			collections.PATCH("/:id/edit", admin.EditCollection)
+			collections.DELETE("/:id/del", admin.DeleteCollection)
 		}
 
 		admins := router.Group("/admins")
@@ -33,6 +73,14 @@ func InitRoutes(handler *gin.RouterGroup, admin AdminHandler, mid Middleware) *g
 			admins.PATCH("/add", admin.AddAdmin)
 			admins.PATCH("/remove", admin.RemoveAdmin)
 		}
+
+		bandwidth := router.Group("/bandwidth")
+
+		bandwidth.Use(mid.CheckAccess("SUPER_ADMIN"))
+		{
+			bandwidth.GET("/", admin.GetBandwidth)
+			bandwidth.PATCH("/", admin.SetBandwidth)
+		}
 	}
 
 	return router
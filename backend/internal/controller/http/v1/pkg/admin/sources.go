@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
+)
+
+func (a *Admin) GetSources(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	sources, sourceErr := a.usecase.GetSources(ctx, movieId)
+	if sourceErr != nil {
+		ctx.AbortWithStatusJSON(sourceErr.ToHttpCode(), sourceErr)
+		return
+	}
+
+	result := make([]dto.SourceDto, 0)
+
+	for i := 0; i < len(sources); i++ {
+		result = append(result, dto.SourceToDto(sources[i]))
+	}
+
+	ctx.JSON(ok, result)
+}
+
+func (a *Admin) ReorderSources(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	var body dto.SourcesOrderDto
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	sourceErr := a.usecase.ReorderSources(ctx, movieId, body.Ids)
+	if sourceErr != nil {
+		ctx.AbortWithStatusJSON(sourceErr.ToHttpCode(), sourceErr)
+		return
+	}
+
+	ctx.JSON(ok, sourcesUpdatedMsg)
+}
+
+func (a *Admin) SetSourceStatus(ctx *gin.Context) {
+	movieId, sourceId, isValid := sourceParams(ctx)
+	if !isValid {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	var body dto.SourceStatusDto
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	sourceErr := a.usecase.SetSourceStatus(ctx, movieId, sourceId, body.Status)
+	if sourceErr != nil {
+		ctx.AbortWithStatusJSON(sourceErr.ToHttpCode(), sourceErr)
+		return
+	}
+
+	ctx.JSON(ok, sourcesUpdatedMsg)
+}
+
+func (a *Admin) DeleteSource(ctx *gin.Context) {
+	movieId, sourceId, isValid := sourceParams(ctx)
+	if !isValid {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	sourceErr := a.usecase.DeleteSource(ctx, movieId, sourceId)
+	if sourceErr != nil {
+		ctx.AbortWithStatusJSON(sourceErr.ToHttpCode(), sourceErr)
+		return
+	}
+
+	ctx.JSON(ok, sourceDeletedMsg)
+}
+
+func sourceParams(ctx *gin.Context) (uint64, uint64, bool) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	sourceId, err := strconv.ParseUint(ctx.Param("sourceId"), 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return movieId, sourceId, true
+}
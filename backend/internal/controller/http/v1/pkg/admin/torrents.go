@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+// InspectTorrent takes a .torrent as the file field of a multipart form or
+// a magnet link as the magnet field.
+func (a *Admin) InspectTorrent(ctx *gin.Context) {
+	var (
+		inspection *entity.Inspection
+		err        *e.Error
+	)
+
+	if header, formErr := ctx.FormFile("file"); formErr == nil {
+		file, openErr := header.Open()
+		if openErr != nil {
+			ctx.AbortWithStatusJSON(badReq, badReqErr)
+			return
+		}
+		defer file.Close()
+
+		data, readErr := io.ReadAll(file)
+		if readErr != nil {
+			ctx.AbortWithStatusJSON(badReq, badReqErr)
+			return
+		}
+
+		inspection, err = a.usecase.InspectTorrent(ctx, data)
+	} else if magnet := strings.TrimSpace(ctx.PostForm("magnet")); magnet != "" {
+		inspection, err = a.usecase.InspectMagnet(ctx, magnet)
+	} else {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
+		return
+	}
+
+	ctx.JSON(ok, dto.InspectionToDto(inspection))
+}
@@ -0,0 +1,166 @@
+package catalogue
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	ok     = http.StatusOK
+	badReq = http.StatusBadRequest
+)
+
+var (
+	badReqErr = e.New("Incorrect data.", e.BadInput)
+)
+
+type CatalogueUseCase interface {
+	GetGenres(ctx context.Context) ([]*entity.Genre, *e.Error)
+	GetMoviesByGenre(ctx context.Context, name string, limit int, offset int) (*entity.MoviePage, *e.Error)
+	GetTags(ctx context.Context) ([]*entity.Tag, *e.Error)
+	GetMoviesByTag(ctx context.Context, name string, limit int, offset int) (*entity.MoviePage, *e.Error)
+	GetCollections(ctx context.Context, limit int, offset int) ([]*entity.Collection, *e.Error)
+	GetCollection(ctx context.Context, id uint64, limit int, offset int) (*entity.Collection, *entity.MoviePage, *e.Error)
+}
+
+type Catalogue struct {
+	usecase CatalogueUseCase
+}
+
+func New(usecase CatalogueUseCase) *Catalogue {
+	return &Catalogue{
+		usecase,
+	}
+}
+
+func (c *Catalogue) GetGenres(ctx *gin.Context) {
+	genres, err := c.usecase.GetGenres(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
+		return
+	}
+
+	result := make([]dto.GenreDto, 0)
+
+	for i := 0; i < len(genres); i++ {
+		result = append(result, dto.GenreToDto(genres[i]))
+	}
+
+	ctx.JSON(ok, result)
+}
+
+func (c *Catalogue) GetMoviesByGenre(ctx *gin.Context) {
+	limit, offset, isValid := getPage(ctx)
+	if !isValid {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	page, err := c.usecase.GetMoviesByGenre(ctx, ctx.Param("name"), limit, offset)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
+		return
+	}
+
+	ctx.JSON(ok, dto.MoviePageToDto(page, limit, offset))
+}
+
+func (c *Catalogue) GetTags(ctx *gin.Context) {
+	tags, err := c.usecase.GetTags(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
+		return
+	}
+
+	result := make([]dto.TagDto, 0)
+
+	for i := 0; i < len(tags); i++ {
+		result = append(result, dto.TagToDto(tags[i]))
+	}
+
+	ctx.JSON(ok, result)
+}
+
+func (c *Catalogue) GetMoviesByTag(ctx *gin.Context) {
+	limit, offset, isValid := getPage(ctx)
+	if !isValid {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	page, err := c.usecase.GetMoviesByTag(ctx, ctx.Param("name"), limit, offset)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
+		return
+	}
+
+	ctx.JSON(ok, dto.MoviePageToDto(page, limit, offset))
+}
+
+func (c *Catalogue) GetCollections(ctx *gin.Context) {
+	limit, offset, isValid := getPage(ctx)
+	if !isValid {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	collections, err := c.usecase.GetCollections(ctx, limit, offset)
+	if err != nil {
+		ctx.AbortWithStatusJSON(err.ToHttpCode(), err)
+		return
+	}
+
+	result := make([]dto.CollectionDto, 0)
+
+	for i := 0; i < len(collections); i++ {
+		result = append(result, dto.CollectionToDto(collections[i]))
+	}
+
+	ctx.JSON(ok, result)
+}
+
+// GetCollection returns the collection with one page of its movies.
+func (c *Catalogue) GetCollection(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	limit, offset, isValid := getPage(ctx)
+	if !isValid {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	collection, page, collectionErr := c.usecase.GetCollection(ctx, id, limit, offset)
+	if collectionErr != nil {
+		ctx.AbortWithStatusJSON(collectionErr.ToHttpCode(), collectionErr)
+		return
+	}
+
+	ctx.JSON(ok, dto.CollectionDetailsDto{
+		CollectionDto: dto.CollectionToDto(collection),
+		Movies:        dto.MoviePageToDto(page, limit, offset),
+	})
+}
+
+func getPage(ctx *gin.Context) (int, int, bool) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return limit, offset, true
+}
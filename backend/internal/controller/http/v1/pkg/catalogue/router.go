@@ -0,0 +1,32 @@
+package catalogue
+
+import "github.com/gin-gonic/gin"
+
+type CatalogueHandler interface {
+	GetGenres(ctx *gin.Context)
+	GetMoviesByGenre(ctx *gin.Context)
+	GetTags(ctx *gin.Context)
+	GetMoviesByTag(ctx *gin.Context)
+	GetCollections(ctx *gin.Context)
+	GetCollection(ctx *gin.Context)
+}
+
+func InitRoutes(handler *gin.RouterGroup, catalogue CatalogueHandler) {
+	genres := handler.Group("/genres")
+	{
+		genres.GET("/", catalogue.GetGenres)
+		genres.GET("/:name/movies", catalogue.GetMoviesByGenre)
+	}
+
+	tags := handler.Group("/tags")
+	{
+		tags.GET("/", catalogue.GetTags)
+		tags.GET("/:name/movies", catalogue.GetMoviesByTag)
+	}
+
+	collections := handler.Group("/collections")
+	{
+		collections.GET("/", catalogue.GetCollections)
+		collections.GET("/:id", catalogue.GetCollection)
+	}
+}
@@ -0,0 +1,82 @@
+package history
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	ok     = http.StatusOK
+	badReq = http.StatusBadRequest
+)
+
+var (
+	badReqErr = e.New("Incorrect data.", e.BadInput)
+)
+
+type HistoryUseCase interface {
+	GetHistory(ctx context.Context, userId uint64, limit int, offset int) ([]*entity.History, *e.Error)
+	ContinueWatching(ctx context.Context, userId uint64) ([]*entity.History, *e.Error)
+}
+
+type History struct {
+	usecase HistoryUseCase
+}
+
+func New(usecase HistoryUseCase) *History {
+	return &History{
+		usecase,
+	}
+}
+
+func (h *History) GetHistory(ctx *gin.Context) {
+	userId := ctx.GetUint64("userId")
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	history, historyErr := h.usecase.GetHistory(ctx, userId, limit, offset)
+	if historyErr != nil {
+		ctx.AbortWithStatusJSON(historyErr.ToHttpCode(), historyErr)
+		return
+	}
+
+	ctx.JSON(ok, toDto(history))
+}
+
+func (h *History) ContinueWatching(ctx *gin.Context) {
+	userId := ctx.GetUint64("userId")
+
+	history, historyErr := h.usecase.ContinueWatching(ctx, userId)
+	if historyErr != nil {
+		ctx.AbortWithStatusJSON(historyErr.ToHttpCode(), historyErr)
+		return
+	}
+
+	ctx.JSON(ok, toDto(history))
+}
+
+func toDto(history []*entity.History) []dto.HistoryDto {
+	result := make([]dto.HistoryDto, 0)
+
+	for i := 0; i < len(history); i++ {
+		result = append(result, dto.HistoryToDto(history[i]))
+	}
+
+	return result
+}
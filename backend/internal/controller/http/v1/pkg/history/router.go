@@ -0,0 +1,17 @@
+package history
+
+import "github.com/gin-gonic/gin"
+
+type HistoryHandler interface {
+	GetHistory(ctx *gin.Context)
+	ContinueWatching(ctx *gin.Context)
+}
+
+type Middleware interface {
+	CheckAccess(roles ...string) gin.HandlerFunc
+}
+
+func InitRoutes(handler *gin.RouterGroup, history HistoryHandler, mid Middleware) {
+	handler.GET("/history", mid.CheckAccess(), history.GetHistory)
+	handler.GET("/continue-watching", mid.CheckAccess(), history.ContinueWatching)
+}
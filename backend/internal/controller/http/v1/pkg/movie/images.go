@@ -0,0 +1,61 @@
+package movie
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	imageType = "image/jpeg"
+
+	// The url of an image stays the same when a new one is uploaded, so
+	// browsers revalidate it every time and get 304 while the ETag matches.
+	imageCacheControl = "public, no-cache"
+)
+
+func (m *Movies) GetImage(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	size, isFound := strings.CutSuffix(ctx.Param("size"), ".jpg")
+	if !isFound {
+		size = ctx.Param("size")
+	}
+
+	image, data, movieErr := m.usecase.GetImage(ctx, movieId, ctx.Param("kind"), size)
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	etag := strconv.Quote(image.ETag)
+
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", imageCacheControl)
+	ctx.Header("Last-Modified", image.UpdatedAt.UTC().Format(http.TimeFormat))
+
+	if matchETag(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.Data(ok, imageType, data)
+}
+
+func matchETag(header string, etag string) bool {
+	for _, item := range strings.Split(header, ",") {
+		item = strings.TrimPrefix(strings.TrimSpace(item), "W/")
+
+		if item == etag || item == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,10 +2,16 @@ package movie
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
 	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
@@ -13,27 +19,54 @@ import (
 
 const (
 	ok = http.StatusOK
+	partial = http.StatusPartialContent
 	badReq = http.StatusBadRequest
+	notFound = http.StatusNotFound
+
+	playlistType = "application/vnd.apple.mpegurl"
+	subtitleType = "text/vtt; charset=utf-8"
 )
 
 var (
 	badReqErr  = e.New("Incorrect data.", e.BadInput)
+	notFoundErr = e.New("This file wasn`t found.", e.NotFound)
+
+	videoTypes = map[string]string{
+		"video.ts":  "video/mp2t",
+		"video.mp4": "video/mp4",
+	}
 )
 
 type MovieUseCase interface {
 	GetMovies(ctx context.Context, limit int, offset int) ([]*entity.Movie, *e.Error)
 	GetMovieById(ctx context.Context, id uint64) (*entity.Movie, *e.Error)
+	SearchMovies(ctx context.Context, search *entity.MovieSearch, limit int, offset int) ([]*entity.Movie, *e.Error)
 	StartWatch(ctx context.Context, movieId uint64) (*entity.Chunk, *e.Error)
-	GetMovieChunck(ctx context.Context, movieId uint64, fileId int, index int) (*entity.Chunk, *e.Error)
+	GetMovieChunck(ctx context.Context, movieId uint64, fileId int, index int, sessionId string, userId uint64) (*entity.Chunk, *e.Error)
+	GetMovieMedia(ctx context.Context, movieId uint64) (*entity.Media, *e.Error)
+	Seek(ctx context.Context, movieId uint64, seconds float64) (*entity.Seek, *e.Error)
+	Remap(ctx context.Context, movieId uint64, version int, index int) (*entity.Seek, *e.Error)
+	GetSubtitles(ctx context.Context, movieId uint64) ([]*entity.Subtitle, *e.Error)
+	GetSubtitle(ctx context.Context, movieId uint64, id string) (string, *e.Error)
+	GetImage(ctx context.Context, movieId uint64, kind string, size string) (*entity.Image, []byte, *e.Error)
+	GetMasterPlaylist(ctx context.Context, movieId uint64) (string, *e.Error)
+	GetMediaPlaylist(ctx context.Context, movieId uint64, version int) (string, *e.Error)
+	OpenStream(ctx context.Context, movieId uint64, sessionId string, userId uint64) (entity.Stream, *e.Error)
+	WatchProgress(ctx context.Context, movieId uint64, sessionId string) (<-chan *entity.Progress, *e.Error)
+	ReadVideo(ctx context.Context, movieId uint64, version int, offset int64, length int64, userId uint64) (*entity.Video, *e.Error)
 }
 
 type Movies struct {
-	usecase MovieUseCase
+	usecase  MovieUseCase
+	upgrader websocket.Upgrader
 }
 
-func New(uc MovieUseCase) *Movies {
+// New lets the pages of frontendUrl open the stream WebSocket besides the
+// pages of the api host itself.
+func New(uc MovieUseCase, frontendUrl string) *Movies {
 	return &Movies{
-		usecase: uc,
+		usecase:  uc,
+		upgrader: newUpgrader(frontendUrl),
 	}
 }
 
@@ -65,6 +98,53 @@ func (m *Movies) GetMovies(ctx *gin.Context) {
 	ctx.JSON(ok, result)
 }
 
+func (m *Movies) SearchMovies(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	search := &entity.MovieSearch{
+		Query: ctx.Query("q"),
+		Genre: ctx.Query("genre"),
+	}
+
+	bounds := map[string]*int{
+		"yearFrom":   &search.YearFrom,
+		"yearTo":     &search.YearTo,
+		"runtimeMin": &search.RuntimeMin,
+		"runtimeMax": &search.RuntimeMax,
+	}
+
+	for key, bound := range bounds {
+		if *bound, err = strconv.Atoi(ctx.DefaultQuery(key, "0")); err != nil {
+			ctx.AbortWithStatusJSON(badReq, badReqErr)
+			return
+		}
+	}
+
+	movies, movieErr := m.usecase.SearchMovies(ctx, search, limit, offset)
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	result := make([]dto.MovieDto, 0)
+
+	for i := 0; i < len(movies); i++ {
+		result = append(result, dto.MovieToDto(movies[i]))
+	}
+
+	ctx.JSON(ok, result)
+}
+
 func (m *Movies) GetMovieById(ctx *gin.Context) {
 	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
 	if err != nil {
@@ -78,7 +158,7 @@ func (m *Movies) GetMovieById(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(ok, dto.MovieToDto(movie))
+	ctx.JSON(ok, dto.MovieToDetailsDto(movie))
 }
 
 func (m *Movies) StartWatch(ctx *gin.Context) {
@@ -116,11 +196,257 @@ func (m *Movies) GetMovieChunck(ctx *gin.Context) {
 		return
 	}
 
-	chunk, movieErr := m.usecase.GetMovieChunck(ctx, movieId, fileId, chunkId)
+	chunk, movieErr := m.usecase.GetMovieChunck(ctx, movieId, fileId, chunkId, ctx.Query("session"), ctx.GetUint64("userId"))
 	if movieErr != nil {
 		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
 		return
 	}
 
 	ctx.JSON(ok, dto.ChunkToDto(chunk))
+}
+
+func (m *Movies) GetMovieMedia(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	media, movieErr := m.usecase.GetMovieMedia(ctx, movieId)
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	ctx.JSON(ok, dto.MediaToDto(media))
+}
+
+func (m *Movies) Seek(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	seconds, err := strconv.ParseFloat(ctx.Query("t"), 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) || seconds < 0 {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	seek, movieErr := m.usecase.Seek(ctx, movieId, seconds)
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	ctx.JSON(ok, dto.SeekToDto(seek))
+}
+
+func (m *Movies) Remap(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	version, err := strconv.Atoi(ctx.Query("version"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	index, err := strconv.Atoi(ctx.Query("index"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	remap, movieErr := m.usecase.Remap(ctx, movieId, version, index)
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	ctx.JSON(ok, dto.SeekToDto(remap))
+}
+
+func (m *Movies) GetSubtitles(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	subtitles, movieErr := m.usecase.GetSubtitles(ctx, movieId)
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	result := make([]dto.SubtitleDto, 0)
+
+	for i := 0; i < len(subtitles); i++ {
+		result = append(result, dto.SubtitleToDto(subtitles[i]))
+	}
+
+	ctx.JSON(ok, result)
+}
+
+func (m *Movies) GetSubtitle(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	id, isFound := strings.CutSuffix(ctx.Param("lang"), ".vtt")
+	if !isFound {
+		ctx.AbortWithStatusJSON(notFound, notFoundErr)
+		return
+	}
+
+	vtt, movieErr := m.usecase.GetSubtitle(ctx, movieId, id)
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	ctx.Data(ok, subtitleType, []byte(vtt))
+}
+
+func (m *Movies) GetMasterPlaylist(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	playlist, movieErr := m.usecase.GetMasterPlaylist(ctx, movieId)
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	ctx.Data(ok, playlistType, []byte(playlist))
+}
+
+func (m *Movies) GetHlsFile(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	file := ctx.Param("file")
+
+	if file == "media.m3u8" {
+		playlist, movieErr := m.usecase.GetMediaPlaylist(ctx, movieId, version)
+		if movieErr != nil {
+			ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+			return
+		}
+
+		ctx.Data(ok, playlistType, []byte(playlist))
+		return
+	}
+
+	contentType, isFound := videoTypes[file]
+
+	if !isFound {
+		ctx.AbortWithStatusJSON(notFound, notFoundErr)
+		return
+	}
+
+	header := ctx.GetHeader("Range")
+
+	offset, length, isValid := parseRange(header)
+
+	if !isValid {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	video, movieErr := m.usecase.ReadVideo(ctx.Request.Context(), movieId, version, offset, length, ctx.GetUint64("userId"))
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	status := ok
+
+	headers := map[string]string{
+		"Accept-Ranges": "bytes",
+	}
+
+	if header != "" {
+		status = partial
+		headers["Content-Range"] = fmt.Sprintf("bytes %d-%d/%d", video.Offset, video.Offset+video.Length-1, video.Size)
+	}
+
+	// The write timeout of the server is for the whole response, a long
+	// range gets writeWait again for every read.
+	writer := http.NewResponseController(ctx.Writer)
+
+	data := &deadlineReader{
+		reader: video.Data,
+		extend: func() {
+			writer.SetWriteDeadline(time.Now().Add(writeWait))
+		},
+	}
+
+	ctx.DataFromReader(status, video.Length, contentType, data, headers)
+}
+
+type deadlineReader struct {
+	reader io.Reader
+	extend func()
+}
+
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	r.extend()
+
+	return n, err
+}
+
+// parseRange supports "bytes=start-end" and "bytes=start-". Zero length
+// means the rest of the file.
+func parseRange(header string) (int64, int64, bool) {
+	if header == "" {
+		return 0, 0, true
+	}
+
+	value, isFound := strings.CutPrefix(header, "bytes=")
+	if !isFound || strings.Contains(value, ",") {
+		return 0, 0, false
+	}
+
+	first, last, isFound := strings.Cut(value, "-")
+	if !isFound {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if last == "" {
+		return start, 0, true
+	}
+
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+
+	return start, end - start + 1, true
 }
\ No newline at end of file
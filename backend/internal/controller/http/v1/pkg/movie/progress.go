@@ -0,0 +1,47 @@
+package movie
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
+)
+
+// Progress is a Server-Sent Events stream of "progress" events for the
+// session, one every second until the client disconnects or the session
+// expires. The write deadline of the server is moved forward before every
+// event, otherwise it would end the stream after writeTimeout.
+func (m *Movies) Progress(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	updates, movieErr := m.usecase.WatchProgress(ctx.Request.Context(), movieId, ctx.Query("session"))
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	writer := http.NewResponseController(ctx.Writer)
+
+	ctx.Stream(func(w io.Writer) bool {
+		progress, isOpen := <-updates
+		if !isOpen {
+			return false
+		}
+
+		writer.SetWriteDeadline(time.Now().Add(writeWait))
+
+		ctx.SSEvent("progress", dto.ProgressToDto(progress))
+
+		return true
+	})
+}
@@ -0,0 +1,82 @@
+package movie
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+// fakeProgress sends events updates, one every interval.
+type fakeProgress struct {
+	MovieUseCase
+	events   int
+	interval time.Duration
+}
+
+func (f *fakeProgress) WatchProgress(ctx context.Context, movieId uint64, sessionId string) (<-chan *entity.Progress, *e.Error) {
+	updates := make(chan *entity.Progress)
+
+	go func() {
+		defer close(updates)
+
+		for i := 0; i < f.events; i++ {
+			time.Sleep(f.interval)
+
+			select {
+			case updates <- &entity.Progress{SessionId: sessionId, Playhead: i}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return updates, nil
+}
+
+func TestProgressOutlivesWriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const writeTimeout = 200 * time.Millisecond
+
+	usecase := &fakeProgress{events: 6, interval: writeTimeout / 2}
+
+	router := gin.New()
+	router.GET("/:id/progress", New(usecase, "").Progress)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &http.Server{Handler: router, WriteTimeout: writeTimeout}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	res, err := http.Get("http://" + listener.Addr().String() + "/1/progress?session=s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	events := 0
+	scanner := bufio.NewScanner(res.Body)
+
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "event:progress") {
+			events++
+		}
+	}
+
+	if events != usecase.events {
+		t.Fatalf("got %d events, want %d, err %v", events, usecase.events, scanner.Err())
+	}
+}
@@ -5,17 +5,43 @@ import "github.com/gin-gonic/gin"
 type MovieHandler interface {
 	GetMovies(ctx *gin.Context)
 	GetMovieById(ctx *gin.Context)
+	SearchMovies(ctx *gin.Context)
 	StartWatch(ctx *gin.Context)
 	GetMovieChunck(ctx *gin.Context)
+	GetMovieMedia(ctx *gin.Context)
+	Seek(ctx *gin.Context)
+	Remap(ctx *gin.Context)
+	Stream(ctx *gin.Context)
+	Progress(ctx *gin.Context)
+	GetSubtitles(ctx *gin.Context)
+	GetSubtitle(ctx *gin.Context)
+	GetImage(ctx *gin.Context)
+	GetMasterPlaylist(ctx *gin.Context)
+	GetHlsFile(ctx *gin.Context)
 }
 
-func InitRoutes(handler *gin.RouterGroup, movie MovieHandler) *gin.RouterGroup {
+type Middleware interface {
+	Identify() gin.HandlerFunc
+}
+
+func InitRoutes(handler *gin.RouterGroup, movie MovieHandler, mid Middleware) *gin.RouterGroup {
 	router := handler.Group("/movies")
 	{
 		router.GET("/", movie.GetMovies)
+		router.GET("/search", movie.SearchMovies)
 		router.GET("/:id", movie.GetMovieById)
 		router.GET("/:id/start", movie.StartWatch)
-		router.GET("/:id/:fileId/:chunkId", movie.GetMovieChunck)
+		router.GET("/:id/media", movie.GetMovieMedia)
+		router.GET("/:id/seek", movie.Seek)
+		router.GET("/:id/remap", movie.Remap)
+		router.GET("/:id/ws", mid.Identify(), movie.Stream)
+		router.GET("/:id/progress", movie.Progress)
+		router.GET("/:id/subtitles", movie.GetSubtitles)
+		router.GET("/:id/subtitles/:lang", movie.GetSubtitle)
+		router.GET("/:id/images/:kind/:size", movie.GetImage)
+		router.GET("/:id/:fileId/:chunkId", mid.Identify(), movie.GetMovieChunck)
+		router.GET("/:id/hls/master.m3u8", movie.GetMasterPlaylist)
+		router.GET("/:id/hls/:version/:file", mid.Identify(), movie.GetHlsFile)
 	}
 
 	return router
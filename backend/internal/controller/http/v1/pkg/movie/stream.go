@@ -0,0 +1,206 @@
+package movie
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/controller/http/v1/dto"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+)
+
+const (
+	writeWait    = 10 * time.Second
+	pongWait     = 60 * time.Second
+	pingInterval = 30 * time.Second
+)
+
+func newUpgrader(frontendUrl string) websocket.Upgrader {
+	frontend, err := url.Parse(frontendUrl)
+	if err != nil {
+		frontend = &url.URL{}
+	}
+
+	return websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 64 * 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			return checkOrigin(r, frontend.Host)
+		},
+	}
+}
+
+// checkOrigin lets in the pages of the frontend and of the api host, a
+// request without Origin doesn`t come from a browser.
+func checkOrigin(r *http.Request, frontendHost string) bool {
+	origin := r.Header.Get("Origin")
+
+	if origin == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+
+	if frontendHost != "" && strings.EqualFold(parsed.Host, frontendHost) {
+		return true
+	}
+
+	return strings.EqualFold(parsed.Host, r.Host)
+}
+
+// Stream pushes pieces over a WebSocket. The first message is a "session"
+// event, then every piece is a binary frame: the piece index as a 4 byte
+// big endian number followed by the piece data. An "end" event is sent
+// after the last piece.
+func (m *Movies) Stream(ctx *gin.Context) {
+	movieId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(badReq, badReqErr)
+		return
+	}
+
+	s, movieErr := m.usecase.OpenStream(ctx, movieId, ctx.Query("session"), ctx.GetUint64("userId"))
+	if movieErr != nil {
+		ctx.AbortWithStatusJSON(movieErr.ToHttpCode(), movieErr)
+		return
+	}
+
+	defer s.Close()
+
+	conn, err := m.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	// The connection context is done when the client goes away, so the
+	// piece being downloaded isn`t waited for.
+	connCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	session := s.Session()
+
+	info := dto.StreamEventDto{
+		Type:        "session",
+		SessionId:   session.Id,
+		FileVersion: session.FileVersion,
+		Pieces:      s.Pieces(),
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+	if err := conn.WriteJSON(info); err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go readControl(connCtx, cancel, conn, s)
+	go ping(conn, done)
+
+	for {
+		chunk, movieErr := s.Next(connCtx)
+		if connCtx.Err() != nil {
+			return
+		}
+
+		if movieErr != nil {
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			conn.WriteJSON(dto.StreamEventDto{Type: "error", Message: movieErr.Message})
+			return
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+		if chunk == nil {
+			if err := conn.WriteJSON(dto.StreamEventDto{Type: "end"}); err != nil {
+				return
+			}
+
+			continue
+		}
+
+		frame := make([]byte, 4+len(chunk.Buffer))
+
+		binary.BigEndian.PutUint32(frame[:4], uint32(chunk.NextIndex-1))
+		copy(frame[4:], chunk.Buffer)
+
+		if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+			return
+		}
+	}
+}
+
+// readControl applies the control messages until the client goes away,
+// then cancels the connection context so the writer stops too.
+func readControl(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, s entity.Stream) {
+	defer cancel()
+
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg dto.StreamMessageDto
+
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		switch msg.Type {
+
+		case "seek":
+			s.Seek(ctx, msg.Piece)
+
+		case "played":
+			s.Played(ctx, msg.Piece)
+
+		case "pause":
+			s.Pause()
+
+		case "resume":
+			s.Resume()
+
+		}
+	}
+}
+
+func ping(conn *websocket.Conn, done chan struct{}) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+
+		case <-done:
+			return
+
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package entity
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"sort"
+)
 
 type Adapter struct {
 	Id          uint64  `redis:"id"`
@@ -8,6 +11,8 @@ type Adapter struct {
 	Version     int     `redis:"version"`
 	Length      int  `redis:"length"`
 	PieceLength int  `redis:"pieceLength"`
+	Duration    float64     `redis:"duration"`
+	Keyframes   []*Keyframe `redis:"keyframes"`
 }
 
 func (a Adapter) MarshalBinary() ([]byte, error) {
@@ -25,5 +30,96 @@ func (a *Adapter) Scan(r row) error {
 		&a.Version,
 		&a.Length,
 		&a.PieceLength,
+		&a.Duration,
 	)
-}
\ No newline at end of file
+}
+func (a *Adapter) Pieces() int {
+	if a.PieceLength == 0 {
+		return 0
+	}
+
+	return (a.Length + a.PieceLength - 1) / a.PieceLength
+}
+
+// Time returns the moment of the movie the piece starts at. Between two
+// keyframes the time grows with the position, without them the whole file
+// is assumed to have a constant bitrate.
+func (a *Adapter) Time(index int) (float64, bool) {
+	if a.Duration == 0 || a.Length == 0 {
+		return 0, false
+	}
+
+	position := int64(index) * int64(a.PieceLength)
+
+	if len(a.Keyframes) == 0 {
+		return min(float64(position)/float64(a.Length)*a.Duration, a.Duration), true
+	}
+
+	next := sort.Search(len(a.Keyframes), func(i int) bool {
+		return a.Keyframes[i].Position > position
+	})
+
+	if next == 0 {
+		return 0, true
+	}
+
+	prev := a.Keyframes[next-1]
+
+	if next == len(a.Keyframes) {
+		return min(interpolate(prev.Position, prev.Time, int64(a.Length), a.Duration, position), a.Duration), true
+	}
+
+	return interpolate(prev.Position, prev.Time, a.Keyframes[next].Position, a.Keyframes[next].Time, position), true
+}
+
+// Index returns the piece the playback of the moment starts at. With
+// keyframes it is the piece of the last keyframe before the moment, so the
+// decoder can start from it.
+func (a *Adapter) Index(seconds float64) (int, bool) {
+	if a.Duration == 0 || a.PieceLength == 0 {
+		return 0, false
+	}
+
+	seconds = max(0, min(seconds, a.Duration))
+
+	var position int64
+
+	if len(a.Keyframes) == 0 {
+		position = int64(seconds / a.Duration * float64(a.Length))
+	} else {
+		i := sort.Search(len(a.Keyframes), func(i int) bool {
+			return a.Keyframes[i].Time > seconds
+		})
+
+		if i > 0 {
+			position = a.Keyframes[i-1].Position
+		}
+	}
+
+	return min(int(position/int64(a.PieceLength)), max(a.Pieces()-1, 0)), true
+}
+
+// Remap moves a piece index of this file version to the same moment of the
+// other version. It goes through the timestamp when both versions were
+// probed, otherwise both files are assumed to have a constant bitrate.
+func (a *Adapter) Remap(index int, to *Adapter) int {
+	if seconds, isFound := a.Time(index); isFound {
+		if result, isFound := to.Index(seconds); isFound {
+			return result
+		}
+	}
+
+	if a.Length == 0 || to.PieceLength == 0 {
+		return 0
+	}
+
+	return int(float64(index) * float64(a.PieceLength) / float64(a.Length) * float64(to.Length) / float64(to.PieceLength))
+}
+
+func interpolate(fromPosition int64, fromTime float64, toPosition int64, toTime float64, position int64) float64 {
+	if toPosition <= fromPosition {
+		return fromTime
+	}
+
+	return fromTime + (toTime-fromTime)*float64(position-fromPosition)/float64(toPosition-fromPosition)
+}
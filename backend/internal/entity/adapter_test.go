@@ -0,0 +1,125 @@
+package entity
+
+import "testing"
+
+func probedAdapter() *Adapter {
+	return &Adapter{
+		Length:      1000,
+		PieceLength: 100,
+		Duration:    100,
+		Keyframes: []*Keyframe{
+			{Time: 0, Position: 0},
+			{Time: 10, Position: 200},
+			{Time: 50, Position: 600},
+		},
+	}
+}
+
+func plainAdapter() *Adapter {
+	return &Adapter{
+		Length:      1000,
+		PieceLength: 100,
+		Duration:    100,
+	}
+}
+
+func TestAdapterTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		adapter *Adapter
+		index   int
+		time    float64
+		isFound bool
+	}{
+		{"first keyframe", probedAdapter(), 0, 0, true},
+		{"between keyframes", probedAdapter(), 1, 5, true},
+		{"on a keyframe", probedAdapter(), 2, 10, true},
+		{"last keyframe", probedAdapter(), 6, 50, true},
+		{"after the last keyframe", probedAdapter(), 8, 75, true},
+		{"after the end", probedAdapter(), 12, 100, true},
+		{"before the first keyframe", &Adapter{Length: 1000, PieceLength: 100, Duration: 100, Keyframes: []*Keyframe{{Time: 1, Position: 150}}}, 1, 0, true},
+		{"no keyframes", plainAdapter(), 5, 50, true},
+		{"no keyframes after the end", plainAdapter(), 20, 100, true},
+		{"not probed", &Adapter{Length: 1000, PieceLength: 100}, 5, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			time, isFound := test.adapter.Time(test.index)
+
+			if time != test.time || isFound != test.isFound {
+				t.Errorf("Time(%d) = %v, %v, want %v, %v", test.index, time, isFound, test.time, test.isFound)
+			}
+		})
+	}
+}
+
+func TestAdapterIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		adapter *Adapter
+		seconds float64
+		index   int
+		isFound bool
+	}{
+		{"first keyframe", probedAdapter(), 0, 0, true},
+		{"before the second keyframe", probedAdapter(), 7, 0, true},
+		{"on a keyframe", probedAdapter(), 10, 2, true},
+		{"last keyframe", probedAdapter(), 99, 6, true},
+		{"after the end", probedAdapter(), 500, 6, true},
+		{"negative", probedAdapter(), -5, 0, true},
+		{"no keyframes", plainAdapter(), 35, 3, true},
+		{"no keyframes at the end", plainAdapter(), 100, 9, true},
+		{"no keyframes after the end", plainAdapter(), 250, 9, true},
+		{"not probed", &Adapter{Length: 1000, PieceLength: 100}, 10, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			index, isFound := test.adapter.Index(test.seconds)
+
+			if index != test.index || isFound != test.isFound {
+				t.Errorf("Index(%v) = %d, %v, want %d, %v", test.seconds, index, isFound, test.index, test.isFound)
+			}
+		})
+	}
+}
+
+func TestAdapterRemap(t *testing.T) {
+	other := &Adapter{
+		Length:      2000,
+		PieceLength: 100,
+		Duration:    100,
+		Keyframes: []*Keyframe{
+			{Time: 0, Position: 0},
+			{Time: 10, Position: 400},
+			{Time: 50, Position: 1200},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		from  *Adapter
+		to    *Adapter
+		index int
+		want  int
+	}{
+		{"first keyframe", probedAdapter(), other, 0, 0},
+		{"keyframe", probedAdapter(), other, 2, 4},
+		{"last keyframe", probedAdapter(), other, 6, 12},
+		{"between keyframes", probedAdapter(), other, 1, 0},
+		{"same version", probedAdapter(), probedAdapter(), 6, 6},
+		{"no keyframes", plainAdapter(), &Adapter{Length: 2000, PieceLength: 200, Duration: 100}, 5, 5},
+		{"not probed", &Adapter{Length: 1000, PieceLength: 100}, &Adapter{Length: 2000, PieceLength: 100}, 5, 10},
+		{"target not probed", probedAdapter(), &Adapter{Length: 2000, PieceLength: 100}, 5, 10},
+		{"empty", &Adapter{}, &Adapter{}, 5, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.from.Remap(test.index, test.to); got != test.want {
+				t.Errorf("Remap(%d) = %d, want %d", test.index, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package entity
+
+type Limits struct {
+	Global  int
+	Torrent int
+	Peer    int
+}
+
+type Bandwidth struct {
+	Download Limits
+	Upload   Limits
+}
@@ -0,0 +1,85 @@
+package entity
+
+import "encoding/json"
+
+// Genre is read in two ways: in the list of genres only Count is set, a
+// single genre has the ids of its movies.
+type Genre struct {
+	Id        uint64   `redis:"id"`
+	Name      string   `redis:"name"`
+	Count     int      `redis:"count"`
+	MoviesIds []uint64 `redis:"moviesIds"`
+}
+
+// Tag has the same layout as Genre, tags are free-form labels like "based
+// on a true story" while the list of genres is kept short.
+type Tag struct {
+	Id        uint64   `redis:"id"`
+	Name      string   `redis:"name"`
+	Count     int      `redis:"count"`
+	MoviesIds []uint64 `redis:"moviesIds"`
+}
+
+// Collection is a list of movies curated by the admins, MoviesIds are in
+// the order they chose.
+type Collection struct {
+	Id          uint64   `redis:"id"`
+	Title       string   `redis:"title"`
+	Description string   `redis:"description"`
+	MoviesIds   []uint64 `redis:"moviesIds"`
+}
+
+// MoviePage is one page of a list of movies together with the length of
+// the whole list.
+type MoviePage struct {
+	Movies []*Movie
+	Total  int
+}
+
+func (g Genre) MarshalBinary() ([]byte, error) {
+	return json.Marshal(&g)
+}
+
+func (g *Genre) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, g)
+}
+
+func (g *Genre) Scan(r row) error {
+	return r.Scan(
+		&g.Id,
+		&g.Name,
+		&g.Count,
+	)
+}
+
+func (t Tag) MarshalBinary() ([]byte, error) {
+	return json.Marshal(&t)
+}
+
+func (t *Tag) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, t)
+}
+
+func (t *Tag) Scan(r row) error {
+	return r.Scan(
+		&t.Id,
+		&t.Name,
+		&t.Count,
+	)
+}
+
+func (c Collection) MarshalBinary() ([]byte, error) {
+	return json.Marshal(&c)
+}
+
+func (c *Collection) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, c)
+}
+
+func (c *Collection) Scan(r row) error {
+	return r.Scan(
+		&c.Id,
+		&c.Title,
+		&c.Description,
+	)
+}
@@ -4,4 +4,15 @@ type Chunk struct {
 	Buffer     []byte 
 	NextIndex   int
 	FileVersion int 
-}
\ No newline at end of file
+	SessionId   string
+	Required    []ByteRange
+}
+
+// ByteRange is a part of the torrent data together with the pieces that
+// hold it.
+type ByteRange struct {
+	Offset     int64
+	Length     int64
+	FirstPiece int
+	LastPiece  int
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Health struct {
+	Id        uint64    `redis:"id"`
+	MovieId   uint64    `redis:"movieId"`
+	Path      string    `redis:"path"`
+	Seeders   int       `redis:"seeders"`
+	Leechers  int       `redis:"leechers"`
+	Completed int       `redis:"completed"`
+	Error     string    `redis:"error"`
+	CheckedAt time.Time `redis:"checkedAt"`
+	Peers     int       `redis:"peers"`
+}
+
+func (h Health) MarshalBinary() ([]byte, error) {
+	return json.Marshal(&h)
+}
+
+func (h *Health) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, h)
+}
+
+func (h *Health) Scan(r row) error {
+	return r.Scan(
+		&h.Id,
+		&h.MovieId,
+		&h.Path,
+		&h.Seeders,
+		&h.Leechers,
+		&h.Completed,
+		&h.Error,
+		&h.CheckedAt,
+		&h.Peers,
+	)
+}
+
+func (h *Health) IsAlive() bool {
+	return !h.CheckedAt.IsZero() && h.Error == "" && (h.Seeders > 0 || h.Peers > 0)
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// History is where the user stopped watching a movie. Position is a piece
+// index of the file version it was saved with.
+type History struct {
+	Id          uint64
+	UserId      uint64
+	MovieId     uint64
+	Position    int
+	FileVersion int
+	UpdatedAt   time.Time
+	Movie       *Movie
+}
+
+func (h *History) Scan(r row) error {
+	return r.Scan(
+		&h.Id,
+		&h.UserId,
+		&h.MovieId,
+		&h.Position,
+		&h.FileVersion,
+		&h.UpdatedAt,
+	)
+}
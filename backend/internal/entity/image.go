@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// Image is one size of a poster or a backdrop of a movie. Path is relative
+// to the file store, ETag is the hash of the file.
+type Image struct {
+	Id        uint64
+	MovieId   uint64
+	Kind      string
+	Size      string
+	Path      string
+	Width     int
+	Height    int
+	ETag      string
+	UpdatedAt time.Time
+}
+
+func (i *Image) Scan(r row) error {
+	return r.Scan(
+		&i.Id,
+		&i.MovieId,
+		&i.Kind,
+		&i.Size,
+		&i.Path,
+		&i.Width,
+		&i.Height,
+		&i.ETag,
+		&i.UpdatedAt,
+	)
+}
@@ -0,0 +1,14 @@
+package entity
+
+// Import is one row of a catalogue import. A row that was read has the
+// movie and its sources, a row that failed has the error instead.
+type Import struct {
+	Row     int
+	Movie   *Movie
+	Sources []*Source
+	Error   string
+}
+
+func (i *Import) IsFailed() bool {
+	return i.Error != ""
+}
@@ -0,0 +1,45 @@
+package entity
+
+// The problems an inspection can find.
+const (
+	ProblemAnnounce   = "UNSUPPORTED_ANNOUNCE"
+	ProblemPrivate    = "PRIVATE"
+	ProblemNoVideo    = "NO_VIDEO"
+	ProblemPieceSize  = "PIECE_TOO_LARGE"
+	ProblemNoMetadata = "NO_METADATA"
+)
+
+// Inspection is what a torrent has inside, shown to the admins before the
+// torrent is added. An inspection of a magnet link only has what the link
+// tells, the pieces and the files are unknown.
+type Inspection struct {
+	InfoHash    string
+	Name        string
+	Length      int
+	PieceLength int
+	PieceCount  int
+	Files       []InspectedFile
+	Trackers    []string
+	WebSeeds    []string
+	Private     bool
+	IsMagnet    bool
+	Problems    []Problem
+}
+
+type InspectedFile struct {
+	Path    string
+	Length  int
+	IsVideo bool
+}
+
+type Problem struct {
+	Code    string
+	Message string
+}
+
+func (i *Inspection) AddProblem(code string, message string) {
+	i.Problems = append(i.Problems, Problem{
+		Code:    code,
+		Message: message,
+	})
+}
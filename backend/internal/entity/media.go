@@ -0,0 +1,87 @@
+package entity
+
+import "sort"
+
+type Media struct {
+	Id        uint64
+	MovieId   uint64
+	Version   int
+	Container string
+	Duration  float64
+	Tracks    []*Track
+	Keyframes []*Keyframe
+}
+
+func (m *Media) Scan(r row) error {
+	return r.Scan(
+		&m.Id,
+		&m.MovieId,
+		&m.Version,
+		&m.Container,
+		&m.Duration,
+	)
+}
+
+// KeyframeAt returns the last keyframe that isn`t after the given second,
+// playback started from it covers that moment.
+func (m *Media) KeyframeAt(seconds float64) (*Keyframe, bool) {
+	i := sort.Search(len(m.Keyframes), func(i int) bool {
+		return m.Keyframes[i].Time > seconds
+	})
+
+	if i == 0 {
+		return nil, false
+	}
+
+	return m.Keyframes[i-1], true
+}
+
+type Track struct {
+	Id       uint64
+	MediaId  uint64
+	Number   int
+	Type     string
+	Codec    string
+	Language string
+	Name     string
+}
+
+func (t *Track) Scan(r row) error {
+	return r.Scan(
+		&t.Id,
+		&t.MediaId,
+		&t.Number,
+		&t.Type,
+		&t.Codec,
+		&t.Language,
+		&t.Name,
+	)
+}
+
+// Keyframe is a point the playback can start from. Position is the offset
+// in the torrent data, divided by the piece length it gives the piece index.
+type Keyframe struct {
+	MediaId  uint64
+	Time     float64
+	Position int64
+}
+
+func (k *Keyframe) Scan(r row) error {
+	return r.Scan(
+		&k.MediaId,
+		&k.Time,
+		&k.Position,
+	)
+}
+
+func (k *Keyframe) Piece(pieceLength int) int {
+	return int(k.Position / int64(pieceLength))
+}
+
+// Seek is where the playback of a moment starts in the torrent data.
+type Seek struct {
+	Time        float64
+	Position    int64
+	Piece       int
+	FileVersion int
+}
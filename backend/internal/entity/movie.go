@@ -1,12 +1,33 @@
 package entity
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Movie struct {
 	Id   		 uint64  `redis:"id"`
 	Name 		 string	 `redis:"name"`
-	Paths 		 string  `redis:"paths"`
 	FileVersion  int     `redis:"fileVersion"`
+	SourceId      uint64
+	OriginalTitle string
+	Description   string
+	ReleaseYear   int
+	Runtime       int
+	Genres        []string
+	Tags          []string
+	Countries     []string
+	AgeRating     string
+	Credits       []Credit
+	DeletedAt     *time.Time
+}
+
+// Credit is a person of the cast or the crew. Character is only set for
+// actors.
+type Credit struct {
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Character string `json:"character,omitempty"`
 }
 
 func (m Movie) MarshalBinary() ([]byte, error) {
@@ -21,7 +42,23 @@ func (m *Movie) Scan(r row) error {
 	return r.Scan(
 		&m.Id,
 		&m.Name,
-		&m.Paths,
 		&m.FileVersion,
+		&m.SourceId,
+		&m.OriginalTitle,
+		&m.Description,
+		&m.ReleaseYear,
+		&m.Runtime,
+		&m.Genres,
+		&m.Tags,
+		&m.Countries,
+		&m.AgeRating,
+		&m.Credits,
+		&m.DeletedAt,
 	)
-}
\ No newline at end of file
+}
+
+// IsDeleted is true for an archived movie. It is kept for the history of
+// the users, but isn`t listed or watched any more.
+func (m *Movie) IsDeleted() bool {
+	return m.DeletedAt != nil
+}
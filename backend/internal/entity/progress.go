@@ -0,0 +1,24 @@
+package entity
+
+// Progress is what a viewer has buffered and what is being downloaded for
+// it. Rate is in bytes per second.
+type Progress struct {
+	SessionId string
+	Playhead  int
+	Pieces    []PieceProgress
+	Buffered  []PieceRange
+	Peers     int
+	Rate      float64
+}
+
+type PieceProgress struct {
+	Index      int
+	Downloaded int
+	Size       int
+}
+
+// PieceRange is a run of pieces that are all in the cache, Last included.
+type PieceRange struct {
+	First int
+	Last  int
+}
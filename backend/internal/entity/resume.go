@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+type Resume struct {
+	MovieId     uint64
+	InfoHash    string
+	Pieces      []byte
+	Peers       string
+	Interval    int
+	AnnouncedAt time.Time
+	Expires     time.Time
+}
+
+func (r *Resume) Scan(row row) error {
+	return row.Scan(
+		&r.MovieId,
+		&r.InfoHash,
+		&r.Pieces,
+		&r.Peers,
+		&r.Interval,
+		&r.AnnouncedAt,
+		&r.Expires,
+	)
+}
@@ -0,0 +1,12 @@
+package entity
+
+// MovieSearch is a catalogue query. Empty Query lists every movie that
+// passes the filters, zero bounds aren`t applied. Runtime is in minutes.
+type MovieSearch struct {
+	Query      string
+	Genre      string
+	YearFrom   int
+	YearTo     int
+	RuntimeMin int
+	RuntimeMax int
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"context"
+	"time"
+
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+// Session is a viewer watching a movie. Playhead is the index of the last
+// piece the viewer asked for.
+type Session struct {
+	Id          string
+	MovieId     uint64
+	FileVersion int
+	Playhead    int
+	StartedAt   time.Time
+	LastSeen    time.Time
+}
+
+// Stream pushes the pieces of a movie to one viewer from the session
+// position. Next returns a nil chunk after the last piece.
+type Stream interface {
+	Session() *Session
+	Pieces() int
+	Next(ctx context.Context) (*Chunk, *e.Error)
+	Seek(ctx context.Context, index int) *e.Error
+	Played(ctx context.Context, index int) *e.Error
+	Pause()
+	Resume()
+	Close()
+}
@@ -0,0 +1,89 @@
+package entity
+
+import "time"
+
+const (
+	SourceActive   = "ACTIVE"
+	SourceDisabled = "DISABLED"
+)
+
+// The health of a source, set by the background checks.
+const (
+	SourceUnknown  = "UNKNOWN"
+	SourceHealthy  = "HEALTHY"
+	SourceDegraded = "DEGRADED"
+	SourceDead     = "DEAD"
+)
+
+// Source is one .torrent file of a movie. Status is set by the admins,
+// Health by the background checks: Streak counts the checks in a row that
+// succeeded when positive and failed when negative, so one bad check only
+// degrades a source and a few are needed to make it dead. The movie is
+// played from a source that isn`t disabled or dead.
+type Source struct {
+	Id        uint64
+	MovieId   uint64
+	Path      string
+	InfoHash  string
+	AddedBy   uint64
+	Status    string
+	LastError string
+	Priority  int
+	Health    string
+	Streak    int
+	CheckedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (s *Source) Scan(r row) error {
+	return r.Scan(
+		&s.Id,
+		&s.MovieId,
+		&s.Path,
+		&s.InfoHash,
+		&s.AddedBy,
+		&s.Status,
+		&s.LastError,
+		&s.Priority,
+		&s.Health,
+		&s.Streak,
+		&s.CheckedAt,
+		&s.CreatedAt,
+	)
+}
+
+func (s *Source) IsDisabled() bool {
+	return s.Status == SourceDisabled
+}
+
+func (s *Source) IsDead() bool {
+	return s.Health == SourceDead
+}
+
+// IsUsable is true for a source the movie can be played from.
+func (s *Source) IsUsable() bool {
+	return !s.IsDisabled() && !s.IsDead()
+}
+
+// HealthRank orders the sources by their health, the healthy ones go first
+// and the dead ones last.
+func (s *Source) HealthRank() int {
+	switch s.Health {
+	case SourceHealthy:
+		return 0
+	case SourceDead:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// IsBetter is true when the source should be played before the other one:
+// it is healthier, or as healthy and has a higher priority.
+func (s *Source) IsBetter(other *Source) bool {
+	if s.HealthRank() != other.HealthRank() {
+		return s.HealthRank() < other.HealthRank()
+	}
+
+	return s.Priority < other.Priority
+}
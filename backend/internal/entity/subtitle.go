@@ -0,0 +1,10 @@
+package entity
+
+// Subtitle is a subtitle file of the torrent. Id is the language with a
+// number added when the torrent has several files of the same language.
+type Subtitle struct {
+	Id       string
+	Language string
+	Name     string
+	Format   string
+}
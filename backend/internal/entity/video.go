@@ -0,0 +1,12 @@
+package entity
+
+import "io"
+
+// Video is a byte range of the main video file of a movie, Data streams
+// Length bytes from Offset.
+type Video struct {
+	Offset int64
+	Length int64
+	Size   int64
+	Data   io.Reader
+}
@@ -2,13 +2,16 @@ package admin
 
 import (
 	"mime/multipart"
+	"encoding/hex"
 	"context"
 	"strings"
-	"os"
 	"io"
 
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/p2p"
 	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/logging"
 	"github.com/google/uuid"
 )
 
@@ -17,6 +20,7 @@ const rootAdmin = "admin"
 var (
 	badAdminReqErr = e.New("You can`t change your role or root admin`s role.", e.BadInput)
 	badReqErr      = e.New("Incorrect data.", e.BadInput)
+	torrentErr     = e.New("File isn`t a valid torrent.", e.BadInput)
 )
 
 type UserStorage interface {
@@ -28,19 +32,84 @@ type UserStorage interface {
 
 type MovieStorage interface {
 	GetMovieById(ctx context.Context, id uint64) (*entity.Movie, *e.Error)
-	CreateMovie(ctx context.Context, movie *entity.Movie) *e.Error
+	CreateMovie(ctx context.Context, movie *entity.Movie, sources []*entity.Source) *e.Error
 	UpdateMovie(ctx context.Context, movie *entity.Movie) *e.Error
+	EditMovie(ctx context.Context, movie *entity.Movie, sources []*entity.Source) *e.Error
+	DeleteMovie(ctx context.Context, id uint64) *e.Error
+	RestoreMovie(ctx context.Context, id uint64) *e.Error
+	PurgeMovie(ctx context.Context, id uint64) *e.Error
+	ImportMovies(ctx context.Context, imports []*entity.Import) *e.Error
+}
+
+type HealthStorage interface {
+	GetMovieHealth(ctx context.Context, movieId uint64) ([]*entity.Health, *e.Error)
+}
+
+type CatalogueStorage interface {
+	ForgetLabels(ctx context.Context, genres []string, tags []string) *e.Error
+	ForgetCollections(ctx context.Context, movieId uint64) *e.Error
+	GetCollection(ctx context.Context, id uint64) (*entity.Collection, *e.Error)
+	CreateCollection(ctx context.Context, collection *entity.Collection) *e.Error
+	UpdateCollection(ctx context.Context, collection *entity.Collection) *e.Error
+	DeleteCollection(ctx context.Context, id uint64) *e.Error
+}
+
+type ImageStorage interface {
+	GetImage(ctx context.Context, movieId uint64, kind string, size string) (*entity.Image, *e.Error)
+	GetImages(ctx context.Context, movieId uint64) ([]*entity.Image, *e.Error)
+	SaveImages(ctx context.Context, images []*entity.Image) *e.Error
+}
+
+type SourceStorage interface {
+	GetSources(ctx context.Context, movieId uint64) ([]*entity.Source, *e.Error)
+	GetSource(ctx context.Context, id uint64) (*entity.Source, *e.Error)
+	UpdateSource(ctx context.Context, source *entity.Source) *e.Error
+	ReorderSources(ctx context.Context, movieId uint64, ids []uint64) *e.Error
+	DeleteSource(ctx context.Context, id uint64) *e.Error
+}
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Delete(ctx context.Context, key string) error
+}
+
+type State interface {
+	Remove(id uint64) *decode.Torrent
+}
+
+type PieceCache interface {
+	Remove(infoHash [20]byte) error
+}
+
+type Sessions interface {
+	Viewers(movieId uint64) []*entity.Session
 }
 
 type Admin struct {
-	usersStorage  UserStorage
-	moviesStorage MovieStorage
+	usersStorage   UserStorage
+	moviesStorage  MovieStorage
+	healthStorage  HealthStorage
+	catalogue      CatalogueStorage
+	imagesStorage  ImageStorage
+	sourcesStorage SourceStorage
+	blobs          BlobStore
+	state          State
+	cache          PieceCache
+	sessions       Sessions
 }
 
-func New(users UserStorage, movies MovieStorage) *Admin {
+func New(users UserStorage, movies MovieStorage, health HealthStorage, catalogue CatalogueStorage, images ImageStorage, sources SourceStorage, blobs BlobStore, state State, cache PieceCache, sessions Sessions) *Admin {
 	return &Admin{
-		usersStorage:  users,
-		moviesStorage: movies,
+		usersStorage:   users,
+		moviesStorage:  movies,
+		healthStorage:  health,
+		catalogue:      catalogue,
+		imagesStorage:  images,
+		sourcesStorage: sources,
+		blobs:          blobs,
+		state:          state,
+		cache:          cache,
+		sessions:       sessions,
 	}
 }
 
@@ -98,66 +167,199 @@ func (a *Admin) RemoveAdmin(ctx context.Context, adminId uint64, username string
 	return a.usersStorage.Update(ctx, user)
 }
 
-func (a *Admin) CreateMovie(ctx context.Context, movie *entity.Movie, files []*multipart.FileHeader) *e.Error {
-	paths, err := saveFiles(files)
+func (a *Admin) CreateMovie(ctx context.Context, adminId uint64, movie *entity.Movie, files []*multipart.FileHeader, images map[string]*multipart.FileHeader) *e.Error {
+	if movie.Name == "" {
+		return titleErr
+	}
+
+	if err := validateMetadata(movie); err != nil {
+		return err
+	}
+
+	uploads, err := renderImages(images)
 	if err != nil {
 		return err
 	}
 
-	movie.Paths = paths
+	sources, err := a.saveFiles(ctx, adminId, files)
+	if err != nil {
+		return err
+	}
 
-	return a.moviesStorage.CreateMovie(ctx, movie)
+	if err := a.moviesStorage.CreateMovie(ctx, movie, sources); err != nil {
+		a.removeFiles(ctx, paths(sources))
+		return err
+	}
+
+	// A movie without the images it was created with is removed, the
+	// admin creates it again.
+	if err := a.saveImages(ctx, movie.Id, uploads); err != nil {
+		if purgeErr := a.purgeMovie(ctx, movie); purgeErr != nil {
+			logging.Default().Error("Can`t purge movie. Error: "+purgeErr.Message, "movieId", movie.Id)
+		}
+
+		return err
+	}
+
+	return a.catalogue.ForgetLabels(ctx, movie.Genres, movie.Tags)
 }
 
-func (a *Admin) EditMovie(ctx context.Context, updated *entity.Movie, files []*multipart.FileHeader) *e.Error {
+func (a *Admin) EditMovie(ctx context.Context, adminId uint64, updated *entity.Movie, files []*multipart.FileHeader, images map[string]*multipart.FileHeader) *e.Error {
+	if err := validateMetadata(updated); err != nil {
+		return err
+	}
+
+	uploads, err := renderImages(images)
+	if err != nil {
+		return err
+	}
+
 	movie, err := a.moviesStorage.GetMovieById(ctx, updated.Id)
 	if err != nil {
 		return err
 	}
 
-	if updated.Name != "" {
-		movie.Name = updated.Name
+	genres, tags := movie.Genres, movie.Tags
+
+	mergeMetadata(movie, updated)
+
+	sources, err := a.saveFiles(ctx, adminId, files)
+	if err != nil {
+		return err
 	}
 
-	if len(files) != 0 {
-		newPaths, err := saveFiles(files)
-		if err != nil {
-			return err
+	if err := a.moviesStorage.EditMovie(ctx, movie, sources); err != nil {
+		a.removeFiles(ctx, paths(sources))
+		return err
+	}
+
+	if err := a.saveImages(ctx, movie.Id, uploads); err != nil {
+		return err
+	}
+
+	return a.catalogue.ForgetLabels(ctx, append(genres, movie.Genres...), append(tags, movie.Tags...))
+}
+
+func (a *Admin) GetMovieHealth(ctx context.Context, movieId uint64) ([]*entity.Health, *e.Error) {
+	if _, err := a.moviesStorage.GetMovieById(ctx, movieId); err != nil {
+		return nil, err
+	}
+
+	sources, err := a.sourcesStorage.GetSources(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	checked, err := a.healthStorage.GetMovieHealth(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]*entity.Health)
+
+	for i := 0; i < len(checked); i++ {
+		byPath[checked[i].Path] = checked[i]
+	}
+
+	result := make([]*entity.Health, 0, len(sources))
+
+	for i := 0; i < len(sources); i++ {
+		health, isFound := byPath[sources[i].Path]
+
+		if !isFound {
+			health = &entity.Health{
+				MovieId: movieId,
+				Path:    sources[i].Path,
+			}
 		}
 
-		movie.Paths += ";" + newPaths
+		result = append(result, health)
+	}
+
+	return result, nil
+}
+
+func (a *Admin) GetBandwidth(ctx context.Context) *entity.Bandwidth {
+	cfg := p2p.GetBandwidth()
+
+	return &entity.Bandwidth{
+		Download: entity.Limits(cfg.Download),
+		Upload:   entity.Limits(cfg.Upload),
+	}
+}
+
+func (a *Admin) SetBandwidth(ctx context.Context, bandwidth *entity.Bandwidth) *e.Error {
+	limits := []entity.Limits{bandwidth.Download, bandwidth.Upload}
+
+	for i := 0; i < len(limits); i++ {
+		if limits[i].Global < 0 || limits[i].Torrent < 0 || limits[i].Peer < 0 {
+			return badReqErr
+		}
 	}
 
-	return a.moviesStorage.UpdateMovie(ctx, movie)
+	p2p.SetBandwidth(p2p.BandwidthConfig{
+		Download: p2p.Limits(bandwidth.Download),
+		Upload:   p2p.Limits(bandwidth.Upload),
+	})
+
+	return nil
 }
 
-func saveFiles(files []*multipart.FileHeader) (string, *e.Error) {
+// saveFiles puts the .torrent files to the blob store. Every file is parsed
+// first, a broken one is rejected before anything is saved.
+func (a *Admin) saveFiles(ctx context.Context, adminId uint64, files []*multipart.FileHeader) ([]*entity.Source, *e.Error) {
 	if err := checkFiles(files); err != nil {
-		return "", err
+		return nil, err
 	}
 
-	paths := []string{}
+	contents := make([][]byte, 0, len(files))
+	sources := make([]*entity.Source, 0, len(files))
 
 	for i := 0; i < len(files); i++ {
-		file := files[i]
-		fileName := uuid.New().String() + ".torrent"
+		toSave, err := files[i].Open()
+		if err != nil {
+			return nil, badReqErr
+		}
+
+		data, err := io.ReadAll(toSave)
+		toSave.Close()
 
-		toSave, err := file.Open()
 		if err != nil {
-			return "", badReqErr
+			return nil, badReqErr
 		}
 
-		local, err := os.OpenFile("files/" + fileName, os.O_CREATE|os.O_RDWR, 0644)
+		tf, err := decode.Parse(data)
 		if err != nil {
-			return "", badReqErr
+			return nil, torrentErr
 		}
 
-		io.Copy(local, toSave)
+		contents = append(contents, data)
 
-		paths = append(paths, fileName)
+		sources = append(sources, &entity.Source{
+			Path:     uuid.New().String() + ".torrent",
+			InfoHash: hex.EncodeToString(tf.InfoHash[:]),
+			AddedBy:  adminId,
+		})
 	}
 
-	return strings.Join(paths, ";"), nil
+	for i, source := range sources {
+		if err := a.blobs.Put(ctx, source.Path, contents[i]); err != nil {
+			a.removeFiles(ctx, paths(sources[:i]))
+			return nil, internalErr
+		}
+	}
+
+	return sources, nil
+}
+
+func paths(sources []*entity.Source) []string {
+	result := make([]string, 0, len(sources))
+
+	for _, source := range sources {
+		result = append(result, source.Path)
+	}
+
+	return result
 }
 
 func checkFiles(files []*multipart.FileHeader) *e.Error {
@@ -176,4 +378,12 @@ func checkFiles(files []*multipart.FileHeader) *e.Error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (a *Admin) GetViewers(ctx context.Context, movieId uint64) ([]*entity.Session, *e.Error) {
+	if _, err := a.moviesStorage.GetMovieById(ctx, movieId); err != nil {
+		return nil, err
+	}
+
+	return a.sessions.Viewers(movieId), nil
+}
@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/logging"
+)
+
+var (
+	archivedErr    = e.New("This movie is already archived.", e.BadInput)
+	notArchivedErr = e.New("This movie isn`t archived.", e.BadInput)
+)
+
+// DeleteMovie archives the movie, or removes it with everything that
+// belongs to it when purge is set. Archived movies can be purged too.
+func (a *Admin) DeleteMovie(ctx context.Context, movieId uint64, purge bool) *e.Error {
+	movie, err := a.moviesStorage.GetMovieById(ctx, movieId)
+	if err != nil {
+		return err
+	}
+
+	if purge {
+		return a.purgeMovie(ctx, movie)
+	}
+
+	if movie.IsDeleted() {
+		return archivedErr
+	}
+
+	if err := a.moviesStorage.DeleteMovie(ctx, movieId); err != nil {
+		return err
+	}
+
+	a.state.Remove(movieId)
+
+	return a.forgetMovie(ctx, movie)
+}
+
+func (a *Admin) RestoreMovie(ctx context.Context, movieId uint64) *e.Error {
+	movie, err := a.moviesStorage.GetMovieById(ctx, movieId)
+	if err != nil {
+		return err
+	}
+
+	if !movie.IsDeleted() {
+		return notArchivedErr
+	}
+
+	if err := a.moviesStorage.RestoreMovie(ctx, movieId); err != nil {
+		return err
+	}
+
+	return a.forgetMovie(ctx, movie)
+}
+
+func (a *Admin) forgetMovie(ctx context.Context, movie *entity.Movie) *e.Error {
+	if err := a.catalogue.ForgetLabels(ctx, movie.Genres, movie.Tags); err != nil {
+		return err
+	}
+
+	return a.catalogue.ForgetCollections(ctx, movie.Id)
+}
+
+// purgeMovie removes the rows first and the files after them. A file that
+// can`t be removed is only logged, nothing refers to it any more.
+func (a *Admin) purgeMovie(ctx context.Context, movie *entity.Movie) *e.Error {
+	images, err := a.imagesStorage.GetImages(ctx, movie.Id)
+	if err != nil {
+		return err
+	}
+
+	sources, err := a.sourcesStorage.GetSources(ctx, movie.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := a.moviesStorage.PurgeMovie(ctx, movie.Id); err != nil {
+		return err
+	}
+
+	infoHashes := infoHashes(a.state.Remove(movie.Id), sources)
+
+	for _, source := range sources {
+		a.removeBlob(ctx, source.Path)
+	}
+
+	for _, image := range images {
+		a.removeBlob(ctx, image.Path)
+	}
+
+	for _, infoHash := range infoHashes {
+		if err := a.cache.Remove(infoHash); err != nil {
+			logging.Default().Error("Can`t remove cached pieces. Error: " + err.Error())
+		}
+	}
+
+	return a.catalogue.ForgetLabels(ctx, movie.Genres, movie.Tags)
+}
+
+// infoHashes finds the torrents the piece cache may have, the one being
+// watched and the ones of all the sources of the movie.
+func infoHashes(watched *decode.Torrent, sources []*entity.Source) [][20]byte {
+	var result [][20]byte
+
+	seen := make(map[[20]byte]bool)
+
+	add := func(infoHash [20]byte) {
+		if !seen[infoHash] {
+			seen[infoHash] = true
+			result = append(result, infoHash)
+		}
+	}
+
+	if watched != nil {
+		add(watched.InfoHash)
+	}
+
+	for _, source := range sources {
+		var infoHash [20]byte
+
+		if n, err := hex.Decode(infoHash[:], []byte(source.InfoHash)); err == nil && n == len(infoHash) {
+			add(infoHash)
+		}
+	}
+
+	return result
+}
+
+func (a *Admin) removeBlob(ctx context.Context, key string) {
+	if err := a.blobs.Delete(ctx, key); err != nil {
+		logging.Default().Error("Can`t remove " + key + ". Error: " + err.Error())
+	}
+}
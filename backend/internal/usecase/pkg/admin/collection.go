@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	maxCollectionMovies = 500
+)
+
+var (
+	collectionErr      = e.New("Collection must have a title and at most 500 different movies.", e.BadInput)
+	collectionMovieErr = e.New("Collection has a movie that doesn`t exist.", e.BadInput)
+)
+
+func (a *Admin) CreateCollection(ctx context.Context, collection *entity.Collection) *e.Error {
+	if err := a.checkCollection(ctx, collection); err != nil {
+		return err
+	}
+
+	return a.catalogue.CreateCollection(ctx, collection)
+}
+
+// EditCollection replaces the fields that are given, MoviesIds replaces the
+// whole list so the admins can reorder it.
+func (a *Admin) EditCollection(ctx context.Context, updated *entity.Collection) *e.Error {
+	collection, err := a.catalogue.GetCollection(ctx, updated.Id)
+	if err != nil {
+		return err
+	}
+
+	if updated.Title != "" {
+		collection.Title = updated.Title
+	}
+
+	if updated.Description != "" {
+		collection.Description = updated.Description
+	}
+
+	if updated.MoviesIds != nil {
+		collection.MoviesIds = updated.MoviesIds
+	}
+
+	if err := a.checkCollection(ctx, collection); err != nil {
+		return err
+	}
+
+	return a.catalogue.UpdateCollection(ctx, collection)
+}
+
+func (a *Admin) DeleteCollection(ctx context.Context, id uint64) *e.Error {
+	if _, err := a.catalogue.GetCollection(ctx, id); err != nil {
+		return err
+	}
+
+	return a.catalogue.DeleteCollection(ctx, id)
+}
+
+func (a *Admin) checkCollection(ctx context.Context, collection *entity.Collection) *e.Error {
+	collection.Title = strings.TrimSpace(collection.Title)
+
+	if collection.Title == "" || utf8.RuneCountInString(collection.Title) > maxTitle {
+		return collectionErr
+	}
+
+	if utf8.RuneCountInString(collection.Description) > maxDescription {
+		return descriptionErr
+	}
+
+	if len(collection.MoviesIds) > maxCollectionMovies {
+		return collectionErr
+	}
+
+	seen := make(map[uint64]bool, len(collection.MoviesIds))
+
+	for _, movieId := range collection.MoviesIds {
+		if seen[movieId] {
+			return collectionErr
+		}
+
+		seen[movieId] = true
+
+		if _, err := a.moviesStorage.GetMovieById(ctx, movieId); err != nil {
+			if err.Code == e.NotFound {
+				return collectionMovieErr
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
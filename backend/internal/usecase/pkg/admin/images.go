@@ -0,0 +1,151 @@
+package admin
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media/artwork"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	maxImageSize = 10 << 20
+)
+
+var (
+	imageErr    = e.New("Image must be a JPEG, PNG, GIF or WebP file of at most 10MB.", e.BadInput)
+	internalErr = e.New("Something going wrong...", e.Internal)
+)
+
+// imageSizes are the widths every upload is rendered in. Thumbnails are
+// for lists, the other sizes for the movie page on different screens.
+var imageSizes = map[string][]artwork.Size{
+	"poster": {
+		{Name: "thumb", Width: 92},
+		{Name: "small", Width: 185},
+		{Name: "medium", Width: 342},
+		{Name: "large", Width: 780},
+	},
+	"backdrop": {
+		{Name: "thumb", Width: 300},
+		{Name: "medium", Width: 780},
+		{Name: "large", Width: 1280},
+	},
+}
+
+type upload struct {
+	kind       string
+	renditions []artwork.Rendition
+}
+
+// renderImages checks and resizes the uploads before anything is saved, so
+// a broken image doesn`t leave a movie half created.
+func renderImages(images map[string]*multipart.FileHeader) ([]upload, *e.Error) {
+	var result []upload
+
+	for kind, file := range images {
+		sizes, isFound := imageSizes[kind]
+
+		if !isFound || file.Size <= 0 || file.Size > maxImageSize {
+			return nil, imageErr
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return nil, imageErr
+		}
+
+		data, err := io.ReadAll(io.LimitReader(src, maxImageSize+1))
+		src.Close()
+
+		if err != nil || len(data) > maxImageSize {
+			return nil, imageErr
+		}
+
+		renditions, err := artwork.Render(data, sizes)
+		if err != nil {
+			return nil, imageErr
+		}
+
+		result = append(result, upload{
+			kind:       kind,
+			renditions: renditions,
+		})
+	}
+
+	return result, nil
+}
+
+// saveImages puts the files to the blob store next to the torrents. Names
+// contain the hash of the content, a new upload never overwrites a file a
+// client may have cached, the replaced files are removed after the new
+// ones are saved.
+func (a *Admin) saveImages(ctx context.Context, movieId uint64, uploads []upload) *e.Error {
+	var (
+		images   []*entity.Image
+		replaced []string
+		written  []string
+	)
+
+	now := time.Now()
+
+	for _, u := range uploads {
+		for _, rendition := range u.renditions {
+			sum := sha1.Sum(rendition.Data)
+			etag := hex.EncodeToString(sum[:])
+
+			path := fmt.Sprintf("images/%d/%s-%s-%s.jpg", movieId, u.kind, rendition.Size, etag[:12])
+
+			old, err := a.imagesStorage.GetImage(ctx, movieId, u.kind, rendition.Size)
+			isSaved := err == nil && old.Path == path
+
+			if err := a.blobs.Put(ctx, path, rendition.Data); err != nil {
+				a.removeFiles(ctx, written)
+				return internalErr
+			}
+
+			if err == nil && !isSaved {
+				replaced = append(replaced, old.Path)
+			}
+
+			// The file of the same content is already referenced, it is
+			// kept if the images can`t be saved.
+			if !isSaved {
+				written = append(written, path)
+			}
+
+			images = append(images, &entity.Image{
+				MovieId:   movieId,
+				Kind:      u.kind,
+				Size:      rendition.Size,
+				Path:      path,
+				Width:     rendition.Width,
+				Height:    rendition.Height,
+				ETag:      etag,
+				UpdatedAt: now,
+			})
+		}
+	}
+
+	if len(images) == 0 {
+		return nil
+	}
+
+	if err := a.imagesStorage.SaveImages(ctx, images); err != nil {
+		a.removeFiles(ctx, written)
+		return err
+	}
+
+	for _, path := range replaced {
+		a.blobs.Delete(ctx, path)
+	}
+
+	return nil
+}
+
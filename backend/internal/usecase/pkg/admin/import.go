@@ -0,0 +1,386 @@
+package admin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/logging"
+)
+
+const (
+	ManifestJson = "json"
+	ManifestCsv  = "csv"
+
+	maxImportRows = 500
+
+	// A .torrent of a few thousand pieces takes well under it.
+	maxImportTorrentSize = 4 << 20
+
+	// Lists in a CSV manifest are joined with it, commas are left for
+	// the names.
+	csvListSep = ";"
+)
+
+var (
+	manifestErr     = e.New("Manifest can`t be read.", e.BadInput)
+	manifestSizeErr = e.New("Manifest can have from 1 to 500 movies.", e.BadInput)
+	importFilesErr  = e.New("Movie must have at least one .torrent file.", e.BadInput)
+)
+
+// manifestRow is one movie of the manifest. Files are the paths of its
+// torrents in the directory or the archive, in the order of priority.
+type manifestRow struct {
+	Name          string          `json:"name"`
+	OriginalTitle string          `json:"originalTitle"`
+	Description   string          `json:"description"`
+	Year          int             `json:"year"`
+	Runtime       int             `json:"runtime"`
+	Genres        []string        `json:"genres"`
+	Tags          []string        `json:"tags"`
+	Countries     []string        `json:"countries"`
+	AgeRating     string          `json:"ageRating"`
+	Credits       []entity.Credit `json:"credits"`
+	Files         []string        `json:"files"`
+
+	// err is set for a CSV row with a field that can`t be parsed.
+	err *e.Error
+}
+
+type importFile struct {
+	source *entity.Source
+	data   []byte
+}
+
+// ImportMovies creates the movies of the manifest with their sources taken
+// from torrents. A row that fails the checks is reported with its error
+// and the rest are created in one transaction. The report has a row for
+// every row of the manifest, numbered from 1.
+func (a *Admin) ImportMovies(ctx context.Context, adminId uint64, manifest []byte, format string, torrents fs.FS) ([]*entity.Import, *e.Error) {
+	rows, err := parseManifest(manifest, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 || len(rows) > maxImportRows {
+		return nil, manifestSizeErr
+	}
+
+	imports := make([]*entity.Import, 0, len(rows))
+	files := make([]*importFile, 0)
+
+	for i, row := range rows {
+		item := &entity.Import{
+			Row:   i + 1,
+			Movie: row.toMovie(),
+		}
+
+		rowFiles, err := readRow(row, item.Movie, adminId, torrents)
+		if err != nil {
+			item.Error = err.Message
+		} else {
+			for _, file := range rowFiles {
+				item.Sources = append(item.Sources, file.source)
+			}
+
+			files = append(files, rowFiles...)
+		}
+
+		imports = append(imports, item)
+	}
+
+	written := make([]string, 0, len(files))
+
+	for _, file := range files {
+		if putErr := a.blobs.Put(ctx, file.source.Path, file.data); putErr != nil {
+			a.removeFiles(ctx, written)
+			return nil, internalErr
+		}
+
+		written = append(written, file.source.Path)
+	}
+
+	if err := a.moviesStorage.ImportMovies(ctx, imports); err != nil {
+		a.removeFiles(ctx, written)
+		return nil, err
+	}
+
+	var genres, tags []string
+
+	for _, item := range imports {
+		if !item.IsFailed() {
+			genres = append(genres, item.Movie.Genres...)
+			tags = append(tags, item.Movie.Tags...)
+		}
+	}
+
+	// The movies are already created, the cached genres and tags are only
+	// stale until they expire.
+	if err := a.catalogue.ForgetLabels(ctx, genres, tags); err != nil {
+		logging.Default().Error("Can`t forget labels. Error: " + err.Message)
+	}
+
+	return imports, nil
+}
+
+// removeFiles removes the files that were written for rows that weren`t
+// saved.
+func (a *Admin) removeFiles(ctx context.Context, paths []string) {
+	for _, path := range paths {
+		a.removeBlob(ctx, path)
+	}
+}
+
+// readRow checks the movie of the row and reads its torrents.
+func readRow(row *manifestRow, movie *entity.Movie, adminId uint64, torrents fs.FS) ([]*importFile, *e.Error) {
+	if row.err != nil {
+		return nil, row.err
+	}
+
+	if movie.Name == "" {
+		return nil, titleErr
+	}
+
+	if err := validateMetadata(movie); err != nil {
+		return nil, err
+	}
+
+	if len(row.Files) == 0 {
+		return nil, importFilesErr
+	}
+
+	files := make([]*importFile, 0, len(row.Files))
+
+	for _, name := range row.Files {
+		file, err := readTorrent(torrents, name)
+		if err != nil {
+			return nil, err
+		}
+
+		file.source.AddedBy = adminId
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+func readTorrent(torrents fs.FS, name string) (*importFile, *e.Error) {
+	name = path.Clean(strings.TrimPrefix(strings.TrimSpace(name), "/"))
+
+	if path.Ext(name) != ".torrent" || !fs.ValidPath(name) {
+		return nil, importFilesErr
+	}
+
+	data, readErr := readLimited(torrents, name)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	tf, err := decode.Parse(data)
+	if err != nil {
+		return nil, e.New("File "+name+" isn`t a valid torrent.", e.BadInput)
+	}
+
+	file := &importFile{
+		source: &entity.Source{
+			Path:     uuid.New().String() + ".torrent",
+			InfoHash: hex.EncodeToString(tf.InfoHash[:]),
+		},
+		data: data,
+	}
+
+	return file, nil
+}
+
+// readLimited reads a file of the torrents, a file over
+// maxImportTorrentSize is rejected before it is read. The size in the
+// header of a zip entry can lie, so the reading is limited too.
+func readLimited(torrents fs.FS, name string) ([]byte, *e.Error) {
+	notFoundErr := e.New("File "+name+" wasn`t found in the torrents.", e.BadInput)
+	sizeErr := e.New("File "+name+" is too large for a torrent.", e.BadInput)
+
+	info, err := fs.Stat(torrents, name)
+	if err != nil || info.IsDir() {
+		return nil, notFoundErr
+	}
+
+	size := uint64(info.Size())
+
+	if header, isZip := info.Sys().(*zip.FileHeader); isZip {
+		size = header.UncompressedSize64
+	}
+
+	if size > maxImportTorrentSize {
+		return nil, sizeErr
+	}
+
+	file, err := torrents.Open(name)
+	if err != nil {
+		return nil, notFoundErr
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImportTorrentSize+1))
+	if err != nil {
+		return nil, notFoundErr
+	}
+
+	if len(data) > maxImportTorrentSize {
+		return nil, sizeErr
+	}
+
+	return data, nil
+}
+
+func (r *manifestRow) toMovie() *entity.Movie {
+	return &entity.Movie{
+		Name:          strings.TrimSpace(r.Name),
+		OriginalTitle: strings.TrimSpace(r.OriginalTitle),
+		Description:   strings.TrimSpace(r.Description),
+		ReleaseYear:   r.Year,
+		Runtime:       r.Runtime,
+		Genres:        r.Genres,
+		Tags:          r.Tags,
+		Countries:     r.Countries,
+		AgeRating:     strings.TrimSpace(r.AgeRating),
+		Credits:       r.Credits,
+	}
+}
+
+func parseManifest(data []byte, format string) ([]*manifestRow, *e.Error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	switch format {
+	case ManifestJson:
+		var rows []*manifestRow
+
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, manifestErr
+		}
+
+		for _, row := range rows {
+			if row == nil {
+				return nil, manifestErr
+			}
+		}
+
+		return rows, nil
+	case ManifestCsv:
+		return parseCsv(data)
+	default:
+		return nil, manifestErr
+	}
+}
+
+// parseCsv reads a manifest with a header of the JSON field names. Lists
+// are joined with semicolons and credits are a JSON array, the columns
+// that aren`t needed can be left out.
+func parseCsv(data []byte) ([]*manifestRow, *e.Error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = 0
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, manifestErr
+	}
+
+	known := map[string]bool{
+		"name": true, "originalTitle": true, "description": true, "year": true, "runtime": true,
+		"genres": true, "tags": true, "countries": true, "ageRating": true, "credits": true, "files": true,
+	}
+
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+
+		if !known[header[i]] {
+			return nil, manifestErr
+		}
+	}
+
+	rows := make([]*manifestRow, 0)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+
+		if err != nil {
+			return nil, manifestErr
+		}
+
+		row := &manifestRow{}
+
+		for i, value := range record {
+			row.set(header[i], strings.TrimSpace(value))
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+func (r *manifestRow) set(column string, value string) {
+	var err error
+
+	switch column {
+	case "name":
+		r.Name = value
+	case "originalTitle":
+		r.OriginalTitle = value
+	case "description":
+		r.Description = value
+	case "year":
+		if value != "" {
+			if r.Year, err = strconv.Atoi(value); err != nil {
+				r.err = yearErr
+			}
+		}
+	case "runtime":
+		if value != "" {
+			if r.Runtime, err = strconv.Atoi(value); err != nil {
+				r.err = runtimeErr
+			}
+		}
+	case "genres":
+		r.Genres = splitList(value)
+	case "tags":
+		r.Tags = splitList(value)
+	case "countries":
+		r.Countries = splitList(value)
+	case "ageRating":
+		r.AgeRating = value
+	case "credits":
+		if value != "" {
+			if err = json.Unmarshal([]byte(value), &r.Credits); err != nil {
+				r.err = creditsErr
+			}
+		}
+	case "files":
+		r.Files = splitList(value)
+	}
+}
+
+func splitList(value string) []string {
+	result := make([]string, 0)
+
+	for _, item := range strings.Split(value, csvListSep) {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
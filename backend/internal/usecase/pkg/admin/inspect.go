@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"context"
+	"encoding/hex"
+	"net/url"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media"
+)
+
+const (
+	// Every piece is downloaded whole before it is played, so big pieces
+	// make the playback start and seek slowly.
+	maxPieceLength = 4 << 20
+)
+
+var (
+	magnetErr = e.New("Link isn`t a valid magnet.", e.BadInput)
+)
+
+// InspectTorrent reads the .torrent file without adding it and finds the
+// problems it would have with streaming.
+func (a *Admin) InspectTorrent(ctx context.Context, data []byte) (*entity.Inspection, *e.Error) {
+	tf, err := decode.Parse(data)
+	if err != nil {
+		return nil, torrentErr
+	}
+
+	inspection := &entity.Inspection{
+		InfoHash:    hex.EncodeToString(tf.InfoHash[:]),
+		Name:        tf.Name,
+		Length:      tf.Length,
+		PieceLength: tf.PieceLength,
+		PieceCount:  len(tf.PieceHashes),
+		Files:       make([]entity.InspectedFile, 0, len(tf.Files)),
+		Trackers:    tf.Trackers,
+		WebSeeds:    tf.WebSeeds,
+		Private:     tf.Private,
+		Problems:    make([]entity.Problem, 0),
+	}
+
+	for _, file := range tf.Files {
+		inspection.Files = append(inspection.Files, entity.InspectedFile{
+			Path:    file.Path,
+			Length:  file.Length,
+			IsVideo: media.IsVideo(file.Path),
+		})
+	}
+
+	if !isSupportedTracker(tf.Announce) {
+		inspection.AddProblem(entity.ProblemAnnounce, "Peers are only requested from an http or https announce url.")
+	}
+
+	if tf.Private {
+		inspection.AddProblem(entity.ProblemPrivate, "Torrent is private, its tracker may not give peers to the service.")
+	}
+
+	if _, isFound := media.MainVideo(tf.Files); !isFound {
+		inspection.AddProblem(entity.ProblemNoVideo, "Torrent has no video file.")
+	}
+
+	if tf.PieceLength > maxPieceLength {
+		inspection.AddProblem(entity.ProblemPieceSize, "Pieces of more than 4 MiB make the playback start slowly.")
+	}
+
+	return inspection, nil
+}
+
+// InspectMagnet reads what the magnet link tells. The metadata isn`t
+// downloaded from the peers, so the files are only known from the
+// .torrent file.
+func (a *Admin) InspectMagnet(ctx context.Context, link string) (*entity.Inspection, *e.Error) {
+	magnet, err := decode.ParseMagnet(link)
+	if err != nil {
+		return nil, magnetErr
+	}
+
+	inspection := &entity.Inspection{
+		InfoHash: hex.EncodeToString(magnet.InfoHash[:]),
+		Name:     magnet.Name,
+		Length:   magnet.Length,
+		Files:    make([]entity.InspectedFile, 0),
+		Trackers: magnet.Trackers,
+		WebSeeds: magnet.WebSeeds,
+		IsMagnet: true,
+		Problems: make([]entity.Problem, 0),
+	}
+
+	if inspection.Trackers == nil {
+		inspection.Trackers = make([]string, 0)
+	}
+
+	if inspection.WebSeeds == nil {
+		inspection.WebSeeds = make([]string, 0)
+	}
+
+	isSupported := false
+
+	for _, tracker := range magnet.Trackers {
+		isSupported = isSupported || isSupportedTracker(tracker)
+	}
+
+	if !isSupported {
+		inspection.AddProblem(entity.ProblemAnnounce, "Magnet has no http or https tracker to request peers from.")
+	}
+
+	inspection.AddProblem(entity.ProblemNoMetadata, "Magnet has no files and pieces, upload the .torrent file to add the movie.")
+
+	return inspection, nil
+}
+
+// isSupportedTracker is true for the trackers peers can be requested from,
+// announces are only made over http.
+func isSupportedTracker(announce string) bool {
+	base, err := url.Parse(announce)
+	if err != nil {
+		return false
+	}
+
+	return base.Scheme == "http" || base.Scheme == "https"
+}
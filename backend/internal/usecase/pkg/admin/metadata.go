@@ -0,0 +1,198 @@
+package admin
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	maxTitle       = 255
+	maxDescription = 5000
+	maxName        = 128
+	maxGenres      = 10
+	maxTags        = 30
+	maxCountries   = 20
+	maxCredits     = 200
+
+	// The first films are from 1888, the years ahead are for announcements.
+	firstYear  = 1888
+	yearsAhead = 5
+	maxRuntime = 1000
+)
+
+var (
+	titleErr       = e.New("Title must have from 1 to 255 characters.", e.BadInput)
+	descriptionErr = e.New("Description must be at most 5000 characters.", e.BadInput)
+	yearErr        = e.New("Release year is out of range.", e.BadInput)
+	runtimeErr     = e.New("Runtime must be at most 1000 minutes.", e.BadInput)
+	genresErr      = e.New("Movie can have at most 10 genres of 1 to 128 characters.", e.BadInput)
+	tagsErr        = e.New("Movie can have at most 30 tags of 1 to 128 characters.", e.BadInput)
+	countriesErr   = e.New("Countries must be ISO 3166-1 alpha-2 codes, at most 20 of them.", e.BadInput)
+	ageRatingErr   = e.New("Unknown age rating.", e.BadInput)
+	creditsErr     = e.New("Credits must have a name and a known role, at most 200 of them.", e.BadInput)
+)
+
+// ageRatings are the MPAA ratings and the age marks used in Russia.
+var ageRatings = map[string]bool{
+	"G": true, "PG": true, "PG-13": true, "R": true, "NC-17": true,
+	"0+": true, "6+": true, "12+": true, "16+": true, "18+": true,
+}
+
+var creditRoles = map[string]bool{
+	"actor":    true,
+	"director": true,
+	"writer":   true,
+	"producer": true,
+	"composer": true,
+	"operator": true,
+}
+
+// validateMetadata checks the fields that are set, zero values mean the
+// field isn`t given.
+func validateMetadata(movie *entity.Movie) *e.Error {
+	if utf8.RuneCountInString(movie.Name) > maxTitle || utf8.RuneCountInString(movie.OriginalTitle) > maxTitle {
+		return titleErr
+	}
+
+	if utf8.RuneCountInString(movie.Description) > maxDescription {
+		return descriptionErr
+	}
+
+	if movie.ReleaseYear != 0 && (movie.ReleaseYear < firstYear || movie.ReleaseYear > time.Now().Year()+yearsAhead) {
+		return yearErr
+	}
+
+	if movie.Runtime < 0 || movie.Runtime > maxRuntime {
+		return runtimeErr
+	}
+
+	if !normalizeLabels(movie.Genres, maxGenres) {
+		return genresErr
+	}
+
+	if !normalizeLabels(movie.Tags, maxTags) {
+		return tagsErr
+	}
+
+	if len(movie.Countries) > maxCountries {
+		return countriesErr
+	}
+
+	for i, country := range movie.Countries {
+		country = strings.ToUpper(strings.TrimSpace(country))
+
+		if !isCountryCode(country) {
+			return countriesErr
+		}
+
+		movie.Countries[i] = country
+	}
+
+	if movie.AgeRating != "" && !ageRatings[movie.AgeRating] {
+		return ageRatingErr
+	}
+
+	if len(movie.Credits) > maxCredits {
+		return creditsErr
+	}
+
+	for i := range movie.Credits {
+		credit := &movie.Credits[i]
+
+		credit.Name = strings.TrimSpace(credit.Name)
+		credit.Role = strings.ToLower(strings.TrimSpace(credit.Role))
+		credit.Character = strings.TrimSpace(credit.Character)
+
+		if credit.Name == "" || utf8.RuneCountInString(credit.Name) > maxName || !creditRoles[credit.Role] {
+			return creditsErr
+		}
+
+		if utf8.RuneCountInString(credit.Character) > maxName {
+			return creditsErr
+		}
+	}
+
+	return nil
+}
+
+// mergeMetadata copies the fields given for an edit. Lists are replaced
+// when they aren`t nil, an empty list clears them.
+func mergeMetadata(movie *entity.Movie, updated *entity.Movie) {
+	if updated.Name != "" {
+		movie.Name = updated.Name
+	}
+
+	if updated.OriginalTitle != "" {
+		movie.OriginalTitle = updated.OriginalTitle
+	}
+
+	if updated.Description != "" {
+		movie.Description = updated.Description
+	}
+
+	if updated.ReleaseYear != 0 {
+		movie.ReleaseYear = updated.ReleaseYear
+	}
+
+	if updated.Runtime != 0 {
+		movie.Runtime = updated.Runtime
+	}
+
+	if updated.Genres != nil {
+		movie.Genres = updated.Genres
+	}
+
+	if updated.Tags != nil {
+		movie.Tags = updated.Tags
+	}
+
+	if updated.Countries != nil {
+		movie.Countries = updated.Countries
+	}
+
+	if updated.AgeRating != "" {
+		movie.AgeRating = updated.AgeRating
+	}
+
+	if updated.Credits != nil {
+		movie.Credits = updated.Credits
+	}
+}
+
+// normalizeLabels lowercases the genres or tags in place, they are looked
+// up by name.
+func normalizeLabels(labels []string, limit int) bool {
+	if len(labels) > limit {
+		return false
+	}
+
+	for i, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+
+		if label == "" || utf8.RuneCountInString(label) > maxName {
+			return false
+		}
+
+		labels[i] = label
+	}
+
+	return true
+}
+
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+var (
+	sourceNotFoundErr = e.New("This source wasn`t found", e.NotFound)
+	sourceStatusErr   = e.New("Source can only be made ACTIVE or DISABLED.", e.BadInput)
+	sourcesOrderErr   = e.New("Order must have every source of the movie once.", e.BadInput)
+)
+
+func (a *Admin) GetSources(ctx context.Context, movieId uint64) ([]*entity.Source, *e.Error) {
+	if _, err := a.moviesStorage.GetMovieById(ctx, movieId); err != nil {
+		return nil, err
+	}
+
+	return a.sourcesStorage.GetSources(ctx, movieId)
+}
+
+// ReorderSources sets the priorities, ids are the sources of the movie
+// from the first one to try to the last.
+func (a *Admin) ReorderSources(ctx context.Context, movieId uint64, ids []uint64) *e.Error {
+	sources, err := a.GetSources(ctx, movieId)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) != len(sources) {
+		return sourcesOrderErr
+	}
+
+	left := make(map[uint64]bool, len(sources))
+
+	for _, source := range sources {
+		left[source.Id] = true
+	}
+
+	for _, id := range ids {
+		if !left[id] {
+			return sourcesOrderErr
+		}
+
+		delete(left, id)
+	}
+
+	if err := a.sourcesStorage.ReorderSources(ctx, movieId, ids); err != nil {
+		return err
+	}
+
+	a.state.Remove(movieId)
+
+	return nil
+}
+
+// SetSourceStatus disables a source or enables it again. An enabled source
+// loses its last error and its health, it is tried again the next time
+// the movie is opened and checked from scratch.
+func (a *Admin) SetSourceStatus(ctx context.Context, movieId uint64, sourceId uint64, status string) *e.Error {
+	if status != entity.SourceActive && status != entity.SourceDisabled {
+		return sourceStatusErr
+	}
+
+	source, err := a.getSource(ctx, movieId, sourceId)
+	if err != nil {
+		return err
+	}
+
+	if source.Status == status {
+		return nil
+	}
+
+	source.Status = status
+	source.LastError = ""
+
+	if status == entity.SourceActive {
+		source.Health = entity.SourceUnknown
+		source.Streak = 0
+	}
+
+	if err := a.sourcesStorage.UpdateSource(ctx, source); err != nil {
+		return err
+	}
+
+	a.state.Remove(movieId)
+
+	return nil
+}
+
+func (a *Admin) DeleteSource(ctx context.Context, movieId uint64, sourceId uint64) *e.Error {
+	source, err := a.getSource(ctx, movieId, sourceId)
+	if err != nil {
+		return err
+	}
+
+	if err := a.sourcesStorage.DeleteSource(ctx, sourceId); err != nil {
+		return err
+	}
+
+	a.state.Remove(movieId)
+	a.removeBlob(ctx, source.Path)
+
+	return nil
+}
+
+func (a *Admin) getSource(ctx context.Context, movieId uint64, sourceId uint64) (*entity.Source, *e.Error) {
+	source, err := a.sourcesStorage.GetSource(ctx, sourceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.MovieId != movieId {
+		return nil, sourceNotFoundErr
+	}
+
+	return source, nil
+}
@@ -0,0 +1,145 @@
+package catalogue
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	maxLimit = 50
+)
+
+var (
+	badReqErr = e.New("Incorrect data.", e.BadInput)
+)
+
+type CatalogueStorage interface {
+	GetGenres(ctx context.Context) ([]*entity.Genre, *e.Error)
+	GetGenre(ctx context.Context, name string) (*entity.Genre, *e.Error)
+	GetTags(ctx context.Context) ([]*entity.Tag, *e.Error)
+	GetTag(ctx context.Context, name string) (*entity.Tag, *e.Error)
+	GetCollections(ctx context.Context, limit int, offset int) ([]*entity.Collection, *e.Error)
+	GetCollection(ctx context.Context, id uint64) (*entity.Collection, *e.Error)
+}
+
+type MovieStorage interface {
+	GetMovieById(ctx context.Context, id uint64) (*entity.Movie, *e.Error)
+}
+
+type Catalogue struct {
+	catalogue CatalogueStorage
+	movies    MovieStorage
+}
+
+func New(catalogue CatalogueStorage, movies MovieStorage) *Catalogue {
+	return &Catalogue{
+		catalogue: catalogue,
+		movies:    movies,
+	}
+}
+
+func (c *Catalogue) GetGenres(ctx context.Context) ([]*entity.Genre, *e.Error) {
+	return c.catalogue.GetGenres(ctx)
+}
+
+func (c *Catalogue) GetMoviesByGenre(ctx context.Context, name string, limit int, offset int) (*entity.MoviePage, *e.Error) {
+	if err := checkPage(limit, offset); err != nil {
+		return nil, err
+	}
+
+	genre, err := c.catalogue.GetGenre(ctx, strings.ToLower(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.getPage(ctx, genre.MoviesIds, limit, offset)
+}
+
+func (c *Catalogue) GetTags(ctx context.Context) ([]*entity.Tag, *e.Error) {
+	return c.catalogue.GetTags(ctx)
+}
+
+func (c *Catalogue) GetMoviesByTag(ctx context.Context, name string, limit int, offset int) (*entity.MoviePage, *e.Error) {
+	if err := checkPage(limit, offset); err != nil {
+		return nil, err
+	}
+
+	tag, err := c.catalogue.GetTag(ctx, strings.ToLower(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.getPage(ctx, tag.MoviesIds, limit, offset)
+}
+
+func (c *Catalogue) GetCollections(ctx context.Context, limit int, offset int) ([]*entity.Collection, *e.Error) {
+	if err := checkPage(limit, offset); err != nil {
+		return nil, err
+	}
+
+	return c.catalogue.GetCollections(ctx, limit, offset)
+}
+
+func (c *Catalogue) GetCollection(ctx context.Context, id uint64, limit int, offset int) (*entity.Collection, *entity.MoviePage, *e.Error) {
+	if err := checkPage(limit, offset); err != nil {
+		return nil, nil, err
+	}
+
+	collection, err := c.catalogue.GetCollection(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, err := c.getPage(ctx, collection.MoviesIds, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return collection, page, nil
+}
+
+// getPage reads the movies of one page of the ids. The movies come from
+// their own cache, a movie removed or archived since the ids were cached
+// is skipped.
+func (c *Catalogue) getPage(ctx context.Context, ids []uint64, limit int, offset int) (*entity.MoviePage, *e.Error) {
+	page := &entity.MoviePage{
+		Movies: make([]*entity.Movie, 0, limit),
+		Total:  len(ids),
+	}
+
+	if offset >= len(ids) {
+		return page, nil
+	}
+
+	ids = ids[offset:min(offset+limit, len(ids))]
+
+	for _, id := range ids {
+		movie, err := c.movies.GetMovieById(ctx, id)
+		if err != nil {
+			if err.Code == e.NotFound {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if movie.IsDeleted() {
+			continue
+		}
+
+		page.Movies = append(page.Movies, movie)
+	}
+
+	return page, nil
+}
+
+func checkPage(limit int, offset int) *e.Error {
+	if limit <= 0 || limit > maxLimit || offset < 0 {
+		return badReqErr
+	}
+
+	return nil
+}
@@ -8,7 +8,8 @@ import (
 )
 
 var (
-	forbiddenErr = e.New("Forbidden.", e.Forbidden)
+	forbiddenErr     = e.New("Forbidden.", e.Forbidden)
+	movieNotFoundErr = e.New("This movie wasn`t found", e.NotFound)
 )
 
 type CommentStorage interface {
@@ -40,8 +41,7 @@ func (c *Comment) GetComments(ctx context.Context, movieId uint64, limit int, of
 }
 
 func (c *Comment) CreateComment(ctx context.Context, comment *entity.Comment) *e.Error {
-	_, err := c.movieStorage.GetMovieById(ctx, comment.MovieId)
-	if err != nil {
+	if err := c.checkMovie(ctx, comment.MovieId); err != nil {
 		return err
 	}
 
@@ -49,8 +49,7 @@ func (c *Comment) CreateComment(ctx context.Context, comment *entity.Comment) *e
 }
 
 func (c *Comment) EditComment(ctx context.Context, updated *entity.Comment) *e.Error {
-	_, err := c.movieStorage.GetMovieById(ctx, updated.MovieId)
-	if err != nil {
+	if err := c.checkMovie(ctx, updated.MovieId); err != nil {
 		return err
 	}
 
@@ -81,4 +80,18 @@ func (c *Comment) DeleteComment(ctx context.Context, comment *entity.Comment) *e
 	}
 
 	return c.commentsStorage.DeleteComment(ctx, toDel.Id)
-}
\ No newline at end of file
+}
+
+// checkMovie forbids the comments to the archived movies.
+func (c *Comment) checkMovie(ctx context.Context, movieId uint64) *e.Error {
+	movie, err := c.movieStorage.GetMovieById(ctx, movieId)
+	if err != nil {
+		return err
+	}
+
+	if movie.IsDeleted() {
+		return movieNotFoundErr
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/logging"
+)
+
+const (
+	moviesPageSize = 50
+)
+
+// Config of the background checks. A source becomes dead after DeadAfter
+// failed checks in a row and healthy again after HealthyAfter good ones,
+// a check is good when at least one of Peers peers given by the tracker
+// accepts the handshake.
+type Config struct {
+	Interval     time.Duration `yaml:"interval" env-default:"30m"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"30s"`
+	Peers        int           `yaml:"peers" env-default:"3"`
+	DeadAfter    int           `yaml:"deadAfter" env-default:"3"`
+	HealthyAfter int           `yaml:"healthyAfter" env-default:"2"`
+}
+
+type MovieStorage interface {
+	GetAllMovies(ctx context.Context, limit int, offset int) ([]*entity.Movie, *e.Error)
+	UpdateMovie(ctx context.Context, movie *entity.Movie) *e.Error
+}
+
+type HealthStorage interface {
+	SaveHealth(ctx context.Context, health *entity.Health) *e.Error
+}
+
+type SourceStorage interface {
+	GetSources(ctx context.Context, movieId uint64) ([]*entity.Source, *e.Error)
+	UpdateSource(ctx context.Context, source *entity.Source) *e.Error
+}
+
+type BlobStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+type State interface {
+	Remove(id uint64) *decode.Torrent
+}
+
+type Health struct {
+	movies  MovieStorage
+	health  HealthStorage
+	sources SourceStorage
+	blobs   BlobStore
+	state   State
+	cfg     Config
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func New(cfg *Config, movies MovieStorage, health HealthStorage, sources SourceStorage, blobs BlobStore, state State) *Health {
+	h := &Health{
+		movies:  movies,
+		health:  health,
+		sources: sources,
+		blobs:   blobs,
+		state:   state,
+		cfg:     *cfg,
+	}
+
+	return h
+}
+
+// Start runs the checks in the background every cfg.Interval until Stop
+// is called.
+func (h *Health) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h.cancel = cancel
+	h.done = make(chan struct{})
+
+	go h.checkMovies(ctx)
+}
+
+// Stop cancels the running check and waits for the background loop to
+// return.
+func (h *Health) Stop() {
+	if h.cancel == nil {
+		return
+	}
+
+	h.cancel()
+
+	<-h.done
+}
+
+// CheckAll checks the movies one by one. A movie that can`t be checked is
+// logged and skipped, so it doesn`t stop the checks of the others.
+func (h *Health) CheckAll(ctx context.Context) *e.Error {
+	failed := 0
+
+	for offset := 0; ; offset += moviesPageSize {
+		movies, err := h.movies.GetAllMovies(ctx, moviesPageSize, offset)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(movies); i++ {
+			if err := h.CheckMovie(ctx, movies[i]); err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+
+				logging.Default().Error("Can`t check movie health. Error: "+err.Message, "movieId", movies[i].Id)
+
+				failed++
+			}
+		}
+
+		if len(movies) < moviesPageSize {
+			break
+		}
+	}
+
+	if failed != 0 {
+		return e.New(fmt.Sprintf("%d movies weren`t checked.", failed), e.Internal)
+	}
+
+	return nil
+}
+
+// CheckMovie checks every source of the movie that isn`t disabled and
+// moves the movie to another source when its current one is no longer
+// usable.
+func (h *Health) CheckMovie(ctx context.Context, movie *entity.Movie) *e.Error {
+	sources, err := h.sources.GetSources(ctx, movie.Id)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(sources); i++ {
+		if sources[i].IsDisabled() {
+			continue
+		}
+
+		health := h.probe(ctx, movie.Id, sources[i])
+
+		if err := h.health.SaveHealth(ctx, health); err != nil {
+			return err
+		}
+
+		if err := h.saveSource(ctx, sources[i], health); err != nil {
+			return err
+		}
+	}
+
+	return h.failover(ctx, movie, sources)
+}
+
+func (h *Health) checkMovies(ctx context.Context) {
+	defer close(h.done)
+
+	for {
+		if err := h.CheckAll(ctx); err != nil && ctx.Err() == nil {
+			logging.Default().Error("Can`t check swarm health. Error: " + err.Message)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(h.cfg.Interval):
+		}
+	}
+}
+
+// saveSource moves the health of the source by the result of the check.
+// One failed check only degrades a healthy source, it is dead after
+// DeadAfter of them in a row. A dead source needs HealthyAfter good checks
+// in a row to be healthy again and is degraded until then.
+func (h *Health) saveSource(ctx context.Context, source *entity.Source, health *entity.Health) *e.Error {
+	if health.Error == "" {
+		source.Streak = max(source.Streak, 0) + 1
+
+		switch {
+		case source.Streak >= h.cfg.HealthyAfter || source.Health == entity.SourceUnknown:
+			source.Health = entity.SourceHealthy
+		case source.IsDead():
+			source.Health = entity.SourceDegraded
+		}
+	} else {
+		source.Streak = min(source.Streak, 0) - 1
+
+		switch {
+		case -source.Streak >= h.cfg.DeadAfter:
+			source.Health = entity.SourceDead
+		case !source.IsDead():
+			source.Health = entity.SourceDegraded
+		}
+	}
+
+	source.LastError = health.Error
+	source.CheckedAt = &health.CheckedAt
+
+	return h.sources.UpdateSource(ctx, source)
+}
+
+// failover moves the movie to the best usable source, the healthiest one
+// with the highest priority, when the current source is dead, disabled or
+// removed or when the best one is better than it. The next viewer opens
+// the new source, the file version changes with it. A movie without a
+// current source, one that has no files yet, is left alone.
+func (h *Health) failover(ctx context.Context, movie *entity.Movie, sources []*entity.Source) *e.Error {
+	if movie.SourceId == 0 {
+		return nil
+	}
+
+	var current, next *entity.Source
+
+	for _, source := range sources {
+		if !source.IsUsable() {
+			continue
+		}
+
+		if source.Id == movie.SourceId {
+			current = source
+		}
+
+		if next == nil || source.IsBetter(next) {
+			next = source
+		}
+	}
+
+	if next == nil || (current != nil && !next.IsBetter(current)) {
+		return nil
+	}
+
+	logging.Default().Info("Movie moved to another source.", "movieId", movie.Id, "from", movie.SourceId, "to", next.Id)
+
+	movie.SourceId = next.Id
+	movie.FileVersion += 1
+
+	if err := h.movies.UpdateMovie(ctx, movie); err != nil {
+		return err
+	}
+
+	h.state.Remove(movie.Id)
+
+	return nil
+}
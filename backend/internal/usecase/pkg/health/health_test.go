@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+type fakeMovies struct {
+	MovieStorage
+	movies []*entity.Movie
+}
+
+func (f *fakeMovies) GetAllMovies(ctx context.Context, limit int, offset int) ([]*entity.Movie, *e.Error) {
+	if offset >= len(f.movies) {
+		return nil, nil
+	}
+
+	return f.movies[offset:min(offset+limit, len(f.movies))], nil
+}
+
+// fakeSources fails for the broken movies and has no sources for the rest.
+type fakeSources struct {
+	SourceStorage
+	broken  map[uint64]bool
+	checked []uint64
+}
+
+func (f *fakeSources) GetSources(ctx context.Context, movieId uint64) ([]*entity.Source, *e.Error) {
+	f.checked = append(f.checked, movieId)
+
+	if f.broken[movieId] {
+		return nil, e.New("Something going wrong...", e.Internal)
+	}
+
+	return nil, nil
+}
+
+func TestCheckAllGoesOnAfterFailure(t *testing.T) {
+	movies := &fakeMovies{
+		movies: []*entity.Movie{{Id: 1}, {Id: 2}, {Id: 3}},
+	}
+
+	sources := &fakeSources{
+		broken: map[uint64]bool{1: true},
+	}
+
+	h := New(&Config{}, movies, nil, sources, nil, nil)
+
+	if err := h.CheckAll(context.Background()); err == nil {
+		t.Error("CheckAll didn`t report the broken movie")
+	}
+
+	if len(sources.checked) != 3 {
+		t.Errorf("checked movies %v, want all three", sources.checked)
+	}
+}
@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/p2p"
+)
+
+var (
+	errTimeout = errors.New("tracker didn`t answer in time")
+	errNoPeers = errors.New("no peer accepted the handshake")
+)
+
+// probe announces the source to its tracker and tries to connect to a few
+// of the peers it gives. The scrape only adds the swarm counts, a tracker
+// without scrape support doesn`t fail the check.
+func (h *Health) probe(ctx context.Context, movieId uint64, source *entity.Source) *entity.Health {
+	health := &entity.Health{
+		MovieId:   movieId,
+		Path:      source.Path,
+		CheckedAt: time.Now(),
+	}
+
+	data, err := h.blobs.Get(ctx, source.Path)
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+
+	tf, err := decode.Parse(data)
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+
+	source.InfoHash = hex.EncodeToString(tf.InfoHash[:])
+
+	torrent, err := h.announce(ctx, &tf)
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+
+	if result, err := tf.Scrape(); err == nil {
+		health.Seeders = result.Seeders
+		health.Leechers = result.Leechers
+		health.Completed = result.Completed
+	}
+
+	health.Peers = h.connect(torrent)
+
+	if health.Peers == 0 {
+		health.Error = errNoPeers.Error()
+	}
+
+	return health
+}
+
+// announce asks the tracker for peers, the request to a tracker that hangs
+// is cancelled after cfg.Timeout.
+func (h *Health) announce(ctx context.Context, tf *decode.TorrentFile) (decode.Torrent, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+	defer cancel()
+
+	torrent, err := tf.GetTorrentFileContext(ctx)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return decode.Torrent{}, errTimeout
+	}
+
+	return torrent, err
+}
+
+// connect makes handshakes with up to cfg.Peers peers at once and counts
+// the ones that answered.
+func (h *Health) connect(torrent decode.Torrent) int {
+	var (
+		wg        sync.WaitGroup
+		connected atomic.Int32
+	)
+
+	for i := 0; i < len(torrent.Peers) && i < h.cfg.Peers; i++ {
+		wg.Add(1)
+
+		go func(peer decode.Peer) {
+			defer wg.Done()
+
+			client, err := p2p.NewClient(torrent.InfoHash, torrent.PeerID, peer)
+			if err != nil {
+				return
+			}
+
+			client.Close()
+			connected.Add(1)
+		}(torrent.Peers[i])
+	}
+
+	wg.Wait()
+
+	return int(connected.Load())
+}
@@ -0,0 +1,125 @@
+package history
+
+import (
+	"context"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	// Movies watched past this part are finished.
+	watchedPart = 0.95
+
+	continueLimit = 20
+)
+
+type HistoryStorage interface {
+	GetHistory(ctx context.Context, userId uint64, limit int, offset int) ([]*entity.History, *e.Error)
+}
+
+type MovieStorage interface {
+	GetMovieById(ctx context.Context, id uint64) (*entity.Movie, *e.Error)
+}
+
+type AdapterStorage interface {
+	GetAdapter(ctx context.Context, movieId uint64, version int) (*entity.Adapter, *e.Error)
+}
+
+type History struct {
+	history  HistoryStorage
+	movies   MovieStorage
+	adapters AdapterStorage
+}
+
+func New(history HistoryStorage, movies MovieStorage, adapters AdapterStorage) *History {
+	return &History{
+		history:  history,
+		movies:   movies,
+		adapters: adapters,
+	}
+}
+
+// GetHistory returns the history with positions in the current versions of
+// the movies.
+func (h *History) GetHistory(ctx context.Context, userId uint64, limit int, offset int) ([]*entity.History, *e.Error) {
+	history, err := h.history.GetHistory(ctx, userId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entity.History, 0, len(history))
+
+	for i := 0; i < len(history); i++ {
+		item, _, err := h.remap(ctx, history[i])
+		if err != nil {
+			if err.Code == e.NotFound {
+				continue
+			}
+
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// ContinueWatching returns the movies the user started but didn`t finish.
+func (h *History) ContinueWatching(ctx context.Context, userId uint64) ([]*entity.History, *e.Error) {
+	history, err := h.history.GetHistory(ctx, userId, continueLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entity.History, 0, len(history))
+
+	for i := 0; i < len(history); i++ {
+		item, adapter, err := h.remap(ctx, history[i])
+		if err != nil {
+			if err.Code == e.NotFound {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if item.Movie.IsDeleted() || item.Position == 0 || float64(item.Position) >= float64(adapter.Pieces())*watchedPart {
+			continue
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// remap converts the position to the current file version of the movie,
+// the adapters of both versions know their lengths.
+func (h *History) remap(ctx context.Context, item *entity.History) (*entity.History, *entity.Adapter, *e.Error) {
+	movie, err := h.movies.GetMovieById(ctx, item.MovieId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := h.adapters.GetAdapter(ctx, movie.Id, movie.FileVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := *item
+	result.Movie = movie
+
+	if item.FileVersion != movie.FileVersion {
+		saved, err := h.adapters.GetAdapter(ctx, movie.Id, item.FileVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result.Position = saved.Remap(item.Position, current)
+		result.FileVersion = movie.FileVersion
+	}
+
+	return &result, current, nil
+}
@@ -0,0 +1,439 @@
+package movie
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media/hls"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media/mp4"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media/mpegts"
+)
+
+const (
+	segmentDuration = 6.0
+
+	// Layouts are small, the bound only keeps a catalogue that is browsed
+	// for a long time from growing the map.
+	maxLayouts = 256
+
+	// The reference count of a sidx box is 16 bits, the largest valid
+	// index takes about 768KiB.
+	maxSidxSize = 1 << 20
+)
+
+var (
+	noVideoErr     = e.New("This movie has no video file.", e.NotFound)
+	unsupportedErr = e.New("This movie can`t be streamed with HLS.", e.BadInput)
+	versionErr     = e.New("This version of the movie is outdated.", e.NotFound)
+)
+
+type hlsLayout struct {
+	file      decode.File
+	container media.Container
+	bitrate   float64
+	media     *hls.Media
+}
+
+type layoutKey struct {
+	movieId     uint64
+	fileVersion int
+}
+
+type layoutEntry struct {
+	key    layoutKey
+	layout *hlsLayout
+}
+
+// hlsLayouts keeps the layouts of the last maxLayouts movies watched with
+// HLS. A movie has only the layout of its current version.
+type hlsLayouts struct {
+	mutex sync.Mutex
+	order *list.List
+	items map[layoutKey]*list.Element
+}
+
+func newLayouts() *hlsLayouts {
+	return &hlsLayouts{
+		order: list.New(),
+		items: make(map[layoutKey]*list.Element),
+	}
+}
+
+func (l *hlsLayouts) get(key layoutKey) (*hlsLayout, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	element, isFound := l.items[key]
+	if !isFound {
+		return nil, false
+	}
+
+	l.order.MoveToFront(element)
+
+	return element.Value.(*layoutEntry).layout, true
+}
+
+func (l *hlsLayouts) put(key layoutKey, layout *hlsLayout) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for old, element := range l.items {
+		if old.movieId == key.movieId {
+			l.order.Remove(element)
+			delete(l.items, old)
+		}
+	}
+
+	l.items[key] = l.order.PushFront(&layoutEntry{key: key, layout: layout})
+
+	for l.order.Len() > maxLayouts {
+		oldest := l.order.Back()
+
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*layoutEntry).key)
+	}
+}
+
+func (m *Movie) GetMasterPlaylist(ctx context.Context, movieId uint64) (string, *e.Error) {
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return "", err
+	}
+
+	layout, err := m.getLayout(ctx, movie)
+	if err != nil {
+		return "", err
+	}
+
+	variant := hls.Variant{
+		Bandwidth: int(layout.bitrate * 8),
+		URI:       fmt.Sprintf("%d/media.m3u8", movie.FileVersion),
+	}
+
+	return hls.Master([]hls.Variant{variant}), nil
+}
+
+func (m *Movie) GetMediaPlaylist(ctx context.Context, movieId uint64, version int) (string, *e.Error) {
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return "", err
+	}
+
+	if version != movie.FileVersion {
+		return "", versionErr
+	}
+
+	layout, err := m.getLayout(ctx, movie)
+	if err != nil {
+		return "", err
+	}
+
+	return layout.media.String(), nil
+}
+
+// ReadVideo serves byte ranges of the main video file, the segments of the
+// media playlist point into it. The piece at offset is downloaded before it
+// returns, the rest of the range is streamed piece by piece while Data is
+// read.
+func (m *Movie) ReadVideo(ctx context.Context, movieId uint64, version int, offset int64, length int64, userId uint64) (*entity.Video, *e.Error) {
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != movie.FileVersion {
+		return nil, versionErr
+	}
+
+	torrent, err := m.getTorrent(ctx, movie)
+	if err != nil {
+		return nil, err
+	}
+
+	file, isFound := media.MainVideo(torrent.Files)
+	if !isFound {
+		return nil, noVideoErr
+	}
+
+	size := int64(file.Length)
+
+	if offset < 0 || offset >= size {
+		return nil, badReqErr
+	}
+
+	if length <= 0 || offset+length > size {
+		length = size - offset
+	}
+
+	playing := int((int64(file.Offset) + offset) / int64(torrent.PieceLength))
+
+	if _, err := m.getUrgentPiece(ctx, movieId, torrent, playing); err != nil {
+		return nil, err
+	}
+
+	m.saveHistory(ctx, userId, movie, playing)
+
+	video := &entity.Video{
+		Offset: offset,
+		Length: length,
+		Size:   size,
+		Data: &rangeReader{
+			ctx:     ctx,
+			movie:   m,
+			movieId: movieId,
+			torrent: torrent,
+			pos:     int64(file.Offset) + offset,
+			end:     int64(file.Offset) + offset + length,
+		},
+	}
+
+	return video, nil
+}
+
+// rangeReader reads a range of the torrent data, a piece is downloaded
+// only when the previous one was read.
+type rangeReader struct {
+	ctx     context.Context
+	movie   *Movie
+	movieId uint64
+	torrent *decode.Torrent
+	pos     int64
+	end     int64
+	buff    []byte
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if len(r.buff) == 0 {
+		if r.pos >= r.end {
+			return 0, io.EOF
+		}
+
+		if err := r.ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		pieceLength := int64(r.torrent.PieceLength)
+		index := int(r.pos / pieceLength)
+
+		buff, err := r.movie.getPiece(r.ctx, r.movieId, r.torrent, index)
+		if err != nil {
+			return 0, errors.New(err.Message)
+		}
+
+		start := r.pos - int64(index)*pieceLength
+		end := min(int64(len(buff)), start+r.end-r.pos)
+
+		if start >= end {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		r.buff = buff[start:end]
+		r.pos += end - start
+	}
+
+	n := copy(p, r.buff)
+
+	r.buff = r.buff[n:]
+
+	return n, nil
+}
+
+func (m *Movie) getLayout(ctx context.Context, movie *entity.Movie) (*hlsLayout, *e.Error) {
+	key := layoutKey{movieId: movie.Id, fileVersion: movie.FileVersion}
+
+	layout, isFound := m.layouts.get(key)
+	if isFound {
+		return layout, nil
+	}
+
+	torrent, err := m.getTorrent(ctx, movie)
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err = m.buildLayout(movie.Id, torrent)
+	if err != nil {
+		return nil, err
+	}
+
+	m.layouts.put(key, layout)
+
+	return layout, nil
+}
+
+func (m *Movie) buildLayout(movieId uint64, torrent *decode.Torrent) (*hlsLayout, *e.Error) {
+	file, isFound := media.MainVideo(torrent.Files)
+	if !isFound {
+		return nil, noVideoErr
+	}
+
+	read := m.fileReader(movieId, torrent, file)
+
+	head, err := read(0, min(torrent.PieceLength, file.Length))
+	if err != nil {
+		return nil, internalErr
+	}
+
+	layout := &hlsLayout{
+		file:      file,
+		container: media.Detect(head),
+	}
+
+	switch layout.container {
+
+	case media.MPEGTS:
+		return layout, m.buildTSLayout(layout, head, read)
+
+	case media.MP4:
+		return layout, m.buildMP4Layout(layout, read)
+
+	default:
+		return nil, unsupportedErr
+
+	}
+}
+
+// buildTSLayout cuts the file into segments of the same size. Their
+// durations are interpolated from the average bitrate.
+func (m *Movie) buildTSLayout(layout *hlsLayout, head []byte, read media.ReadAt) *e.Error {
+	size := int64(layout.file.Length)
+
+	tailLength := min(int64(len(head)), size)
+
+	tail, err := read(size-tailLength, int(tailLength))
+	if err != nil {
+		return internalErr
+	}
+
+	duration, isFound := mpegts.Duration(head, tail)
+	if !isFound {
+		return unsupportedErr
+	}
+
+	layout.bitrate = float64(size) / duration
+
+	segmentSize := int64(layout.bitrate*segmentDuration) / mpegts.PacketSize * mpegts.PacketSize
+	segmentSize = max(segmentSize, mpegts.PacketSize)
+
+	playlist := &hls.Media{}
+
+	for offset := int64(0); offset < size; offset += segmentSize {
+		length := min(segmentSize, size-offset)
+
+		playlist.Segments = append(playlist.Segments, hls.Segment{
+			Duration: float64(length) / layout.bitrate,
+			URI:      "video.ts",
+			Offset:   offset,
+			Length:   length,
+		})
+	}
+
+	layout.media = playlist
+
+	return nil
+}
+
+// buildMP4Layout maps the fragments listed in the sidx box onto byte ranges.
+// Files without the index aren`t fragmented or would need a full scan.
+func (m *Movie) buildMP4Layout(layout *hlsLayout, read media.ReadAt) *e.Error {
+	var moov, sidx *mp4.Box
+
+	err := mp4.Walk(read, int64(layout.file.Length), func(box mp4.Box) bool {
+		switch box.Type {
+
+		case "moov":
+			moov = &box
+
+		case "sidx":
+			sidx = &box
+			return false
+
+		case "moof", "mdat":
+			return false
+
+		}
+
+		return true
+	})
+	if err != nil {
+		return internalErr
+	}
+
+	if moov == nil || sidx == nil {
+		return unsupportedErr
+	}
+
+	// The size comes from the file, it is checked before the payload is
+	// allocated.
+	if sidx.PayloadSize() > maxSidxSize {
+		return unsupportedErr
+	}
+
+	payload, err := read(sidx.PayloadOffset(), int(sidx.PayloadSize()))
+	if err != nil {
+		return internalErr
+	}
+
+	index, err := mp4.ParseSidx(payload)
+	if err != nil || index.Timescale == 0 {
+		return unsupportedErr
+	}
+
+	playlist := &hls.Media{
+		Init: &hls.Segment{
+			URI:    "video.mp4",
+			Offset: 0,
+			Length: moov.End(),
+		},
+	}
+
+	offset := sidx.End() + index.FirstOffset
+	duration := 0.0
+
+	for _, reference := range index.References {
+		segment := hls.Segment{
+			Duration: float64(reference.Duration) / float64(index.Timescale),
+			URI:      "video.mp4",
+			Offset:   offset,
+			Length:   reference.Size,
+		}
+
+		playlist.Segments = append(playlist.Segments, segment)
+
+		offset += reference.Size
+		duration += segment.Duration
+	}
+
+	if duration == 0 {
+		return unsupportedErr
+	}
+
+	layout.bitrate = float64(layout.file.Length) / duration
+	layout.media = playlist
+
+	return nil
+}
+
+// fileReader reads a file of the torrent through the piece downloader.
+func (m *Movie) fileReader(movieId uint64, torrent *decode.Torrent, file decode.File) media.ReadAt {
+	return func(offset int64, length int) ([]byte, error) {
+		if offset < 0 || offset+int64(length) > int64(file.Length) {
+			return nil, errors.New("read out of the file")
+		}
+
+		data, err := m.readRange(movieId, torrent, int64(file.Offset)+offset, length)
+		if err != nil {
+			return nil, errors.New(err.Message)
+		}
+
+		return data, nil
+	}
+}
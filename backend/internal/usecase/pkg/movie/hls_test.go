@@ -0,0 +1,65 @@
+package movie
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+)
+
+func TestLayoutsKeepCurrentVersions(t *testing.T) {
+	layouts := newLayouts()
+
+	layouts.put(layoutKey{movieId: 1, fileVersion: 1}, &hlsLayout{})
+	layouts.put(layoutKey{movieId: 1, fileVersion: 2}, &hlsLayout{})
+
+	if _, isFound := layouts.get(layoutKey{movieId: 1, fileVersion: 1}); isFound {
+		t.Error("the layout of the old version is kept")
+	}
+
+	for id := uint64(2); id <= maxLayouts+1; id++ {
+		layouts.put(layoutKey{movieId: id, fileVersion: 1}, &hlsLayout{})
+	}
+
+	if layouts.order.Len() != maxLayouts || len(layouts.items) != maxLayouts {
+		t.Fatalf("kept %d layouts, want %d", len(layouts.items), maxLayouts)
+	}
+
+	if _, isFound := layouts.get(layoutKey{movieId: 1, fileVersion: 2}); isFound {
+		t.Error("the least recently used layout isn`t evicted")
+	}
+}
+
+func TestMP4LayoutRejectsLargeSidx(t *testing.T) {
+	var head []byte
+
+	head = append(head, box("moov", 16)...)
+	head = append(head, box("sidx", 16)...)
+
+	// The header claims a 3GiB index.
+	binary.BigEndian.PutUint32(head[16:], 3<<30)
+
+	var largest int
+
+	read := func(offset int64, length int) ([]byte, error) {
+		largest = max(largest, length)
+
+		data := make([]byte, length)
+
+		if offset < int64(len(head)) {
+			copy(data, head[offset:])
+		}
+
+		return data, nil
+	}
+
+	layout := &hlsLayout{file: decode.File{Path: "movie.mp4", Length: 4 << 30}}
+
+	if err := (&Movie{}).buildMP4Layout(layout, read); err != unsupportedErr {
+		t.Errorf("got %v, want unsupportedErr", err)
+	}
+
+	if largest > maxSidxSize {
+		t.Errorf("read %d bytes at once", largest)
+	}
+}
@@ -0,0 +1,24 @@
+package movie
+
+import (
+	"context"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+// GetImage returns a rendition of the poster or the backdrop with the
+// content of its file.
+func (m *Movie) GetImage(ctx context.Context, movieId uint64, kind string, size string) (*entity.Image, []byte, *e.Error) {
+	image, err := m.images.GetImage(ctx, movieId, kind, size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, readErr := m.blobs.Get(ctx, image.Path)
+	if readErr != nil {
+		return nil, nil, internalErr
+	}
+
+	return image, data, nil
+}
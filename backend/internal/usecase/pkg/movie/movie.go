@@ -2,35 +2,59 @@ package movie
 
 import (
 	"context"
-	"math"
-	"os"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/p2p"
 	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/logging"
 )
 
 const (
 	expires = 4 * time.Hour
+
+	maxSearchQuery = 200
+	maxSearchLimit = 50
+
+	historyInterval = 15 * time.Second
+	maxHistorySaves = 1024
 )
 
 var (
 	internalErr = e.New("Something going wrong...", e.Internal)
+	badReqErr   = e.New("Incorrect data.", e.BadInput)
+
+	movieNotFoundErr = e.New("This movie wasn`t found", e.NotFound)
+	noSourcesErr     = e.New("This movie has no working torrent.", e.Internal)
+	canceledErr      = e.New("Request was canceled.", e.BadInput)
+
+	// The swarm downloads, the tests replace them.
+	download       = p2p.Download
+	downloadUrgent = p2p.DownloadUrgent
 )
 
 type State interface {
 	Get(id uint64) *decode.Torrent
 	Add(id uint64, torrent *decode.Torrent, expires time.Duration)
 	ChangeExpires(id uint64, expires time.Duration)
+	HasPiece(id uint64, index int) bool
+	SetPiece(id uint64, index int)
+}
+
+type PieceCache interface {
+	Get(infoHash [20]byte, index int) ([]byte, bool)
+	Put(infoHash [20]byte, index int, data []byte) error
 }
 
 type MovieStorage interface {
 	GetAllMovies(ctx context.Context, limit int, offset int) ([]*entity.Movie, *e.Error)
 	GetMovieById(ctx context.Context, id uint64) (*entity.Movie, *e.Error)
-	CreateMovie(ctx context.Context, movie *entity.Movie) *e.Error
+	SearchMovies(ctx context.Context, search *entity.MovieSearch, limit int, offset int) ([]*entity.Movie, *e.Error)
+	CreateMovie(ctx context.Context, movie *entity.Movie, sources []*entity.Source) *e.Error
 	UpdateMovie(ctx context.Context, movie *entity.Movie) *e.Error
 }
 
@@ -39,94 +63,195 @@ type AdapterStorage interface {
 	CreateAdapter(ctx context.Context, adapter *entity.Adapter) *e.Error
 }
 
+type MediaStorage interface {
+	GetMedia(ctx context.Context, movieId uint64, version int) (*entity.Media, *e.Error)
+	SaveMedia(ctx context.Context, media *entity.Media) *e.Error
+}
+
+type ImageStorage interface {
+	GetImage(ctx context.Context, movieId uint64, kind string, size string) (*entity.Image, *e.Error)
+}
+
+type SourceStorage interface {
+	GetSources(ctx context.Context, movieId uint64) ([]*entity.Source, *e.Error)
+	UpdateSource(ctx context.Context, source *entity.Source) *e.Error
+}
+
+type BlobStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+type HistoryStorage interface {
+	SaveHistory(ctx context.Context, history *entity.History) *e.Error
+}
+
+type Sessions interface {
+	Start(movieId uint64, version int, playhead int) *entity.Session
+	Get(id string) (*entity.Session, bool)
+	Touch(id string, movieId uint64, version int, playhead int)
+	Playheads() map[uint64][]int
+}
+
 type Movie struct {
 	movies   MovieStorage
 	adapters AdapterStorage
+	media    MediaStorage
+	images   ImageStorage
+	sources  SourceStorage
+	blobs    BlobStore
 	state    State
+	cache    PieceCache
+	sessions Sessions
+	history  HistoryStorage
+	layouts  *hlsLayouts
+	loads    *pieceLoads
+	saves    *historySaves
+	queue    chan func()
+	ahead    *readAheads
 }
 
-func New(movies MovieStorage, adapters AdapterStorage, state State) *Movie {
-	return &Movie{
+func New(movies MovieStorage, adapters AdapterStorage, media MediaStorage, images ImageStorage, sources SourceStorage, blobs BlobStore, state State, cache PieceCache, sessions Sessions, history HistoryStorage) *Movie {
+	m := &Movie{
 		movies,
 		adapters,
+		media,
+		images,
+		sources,
+		blobs,
 		state,
+		cache,
+		sessions,
+		history,
+		newLayouts(),
+		newLoads(),
+		newHistorySaves(),
+		make(chan func(), queueSize),
+		newReadAheads(maxReadAheads),
 	}
+
+	go m.schedule()
+
+	return m
 }
 
 func (m *Movie) GetMovies(ctx context.Context, limit int, offset int) ([]*entity.Movie, *e.Error) {
 	return m.movies.GetAllMovies(ctx, limit, offset)
 }
 
+// SearchMovies rejects queries that can`t match anything instead of
+// sending them to the database.
+func (m *Movie) SearchMovies(ctx context.Context, search *entity.MovieSearch, limit int, offset int) ([]*entity.Movie, *e.Error) {
+	search.Query = strings.TrimSpace(search.Query)
+	search.Genre = strings.ToLower(strings.TrimSpace(search.Genre))
+
+	if utf8.RuneCountInString(search.Query) > maxSearchQuery {
+		return nil, badReqErr
+	}
+
+	if limit <= 0 || limit > maxSearchLimit || offset < 0 {
+		return nil, badReqErr
+	}
+
+	if search.YearFrom < 0 || search.YearTo < 0 || search.RuntimeMin < 0 || search.RuntimeMax < 0 {
+		return nil, badReqErr
+	}
+
+	if search.YearTo != 0 && search.YearFrom > search.YearTo {
+		return nil, badReqErr
+	}
+
+	if search.RuntimeMax != 0 && search.RuntimeMin > search.RuntimeMax {
+		return nil, badReqErr
+	}
+
+	return m.movies.SearchMovies(ctx, search, limit, offset)
+}
+
 func (m *Movie) GetMovieById(ctx context.Context, id uint64) (*entity.Movie, *e.Error) {
-	return m.movies.GetMovieById(ctx, id)
+	return m.getMovie(ctx, id)
 }
 
-func (m *Movie) StartWatch(ctx context.Context, movieId uint64) (*entity.Chunk, *e.Error) {
-	movie, err := m.movies.GetMovieById(ctx, movieId)
+// getMovie hides the archived movies, they can`t be watched any more.
+func (m *Movie) getMovie(ctx context.Context, id uint64) (*entity.Movie, *e.Error) {
+	movie, err := m.movies.GetMovieById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	torrent := m.state.Get(movieId)
+	if movie.IsDeleted() {
+		return nil, movieNotFoundErr
+	}
 
-	if torrent != nil {
-		m.state.ChangeExpires(movieId, expires)
-	} else {
-		torrent, err = m.openTorrent(ctx, movie)
-		if err != nil {
-			return nil, err
-		}
+	return movie, nil
+}
 
-		m.state.Add(movieId, torrent, expires)
+func (m *Movie) StartWatch(ctx context.Context, movieId uint64) (*entity.Chunk, *e.Error) {
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	torrent, err := m.getTorrent(ctx, movie)
+	if err != nil {
+		return nil, err
 	}
 
-	piece, getChunkErr := p2p.Download(*torrent, 0)
-	if getChunkErr != nil {
-		return nil, internalErr
+	buff, err := m.getUrgentPiece(ctx, movieId, torrent, 0)
+	if err != nil {
+		return nil, err
 	}
 
+	session := m.sessions.Start(movieId, movie.FileVersion, 0)
+
 	chunk := &entity.Chunk{
-		Buffer:      piece.Buff,
+		Buffer:      buff,
 		NextIndex:   1,
 		FileVersion: movie.FileVersion,
+		SessionId:   session.Id,
+		Required:    m.prefetchStartup(ctx, movieId, torrent),
 	}
 
 	return chunk, nil
 }
 
-func (m *Movie) GetMovieChunck(ctx context.Context, movieId uint64, fileId int, index int) (*entity.Chunk, *e.Error) {
-	movie, err := m.movies.GetMovieById(ctx, movieId)
+func (m *Movie) GetMovieChunck(ctx context.Context, movieId uint64, fileId int, index int, sessionId string, userId uint64) (*entity.Chunk, *e.Error) {
+	movie, err := m.getMovie(ctx, movieId)
 	if err != nil {
 		return nil, err
 	}
 
-	torrent := m.state.Get(movieId)
+	torrent, err := m.getTorrent(ctx, movie)
+	if err != nil {
+		return nil, err
+	}
 
-	if torrent == nil {
-		torrent, err = m.openTorrent(ctx, movie)
+	if fileId != movie.FileVersion {
+		adapter, err := m.adapters.GetAdapter(ctx, movieId, fileId)
 		if err != nil {
 			return nil, err
 		}
 
-		m.state.Add(movieId, torrent, expires)
-	}
-
-	if fileId != movie.FileVersion {
-		adapter, err := m.adapters.GetAdapter(ctx, movieId, fileId)
+		current, err := m.adapters.GetAdapter(ctx, movieId, movie.FileVersion)
 		if err != nil {
 			return nil, err
 		}
 
-		index = countIndex(index, adapter, torrent)
+		index = adapter.Remap(index, current)
+	}
+
+	if sessionId != "" {
+		m.sessions.Touch(sessionId, movieId, movie.FileVersion, index)
 	}
 
-	piece, getChunkErr := p2p.Download(*torrent, index)
-	if getChunkErr != nil {
-		return nil, internalErr
+	m.saveHistory(ctx, userId, movie, index)
+
+	buff, err := m.getUrgentPiece(ctx, movieId, torrent, index)
+	if err != nil {
+		return nil, err
 	}
 
 	chunk := &entity.Chunk{
-		Buffer:      piece.Buff,
+		Buffer:      buff,
 		NextIndex:   index + 1,
 		FileVersion: movie.FileVersion,
 	}
@@ -134,44 +259,246 @@ func (m *Movie) GetMovieChunck(ctx context.Context, movieId uint64, fileId int,
 	return chunk, nil
 }
 
-func (m *Movie) openTorrent(ctx context.Context, movie *entity.Movie) (*decode.Torrent, *e.Error) {
-	var torrent decode.Torrent
+func (m *Movie) getTorrent(ctx context.Context, movie *entity.Movie) (*decode.Torrent, *e.Error) {
+	torrent := m.state.Get(movie.Id)
 
-	firstIndex := 0
+	if torrent != nil {
+		m.state.ChangeExpires(movie.Id, expires)
 
-	paths := strings.Split(movie.Paths, ";")
+		return torrent, nil
+	}
 
-	for i := 0; i < len(paths); i++ {
-		path := paths[i]
+	torrent, err := m.openTorrent(ctx, movie)
+	if err != nil {
+		return nil, err
+	}
 
-		tf, err := decode.Open("files/" + path)
-		if err != nil {
-			if err = os.Remove("files/" + path); err != nil {
-				return nil, internalErr
-			}
+	m.state.Add(movie.Id, torrent, expires)
 
-			continue
+	go m.probeMedia(movie.Id, movie.FileVersion, torrent)
+
+	return torrent, nil
+}
+
+// saveHistory remembers the position of a signed in viewer. Playback
+// doesn`t depend on it, so errors are only logged. Every piece request
+// comes here, so the position is only written when it moved and at most
+// once per historyInterval for a viewer and a movie.
+func (m *Movie) saveHistory(ctx context.Context, userId uint64, movie *entity.Movie, position int) {
+	if userId == 0 {
+		return
+	}
+
+	if !m.saves.allow(userId, movie.Id, movie.FileVersion, position, time.Now()) {
+		return
+	}
+
+	history := &entity.History{
+		UserId:      userId,
+		MovieId:     movie.Id,
+		Position:    position,
+		FileVersion: movie.FileVersion,
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := m.history.SaveHistory(ctx, history); err != nil {
+		logging.Default().Error("Can`t save history. Error: " + err.Message)
+	}
+}
+
+type historyKey struct {
+	userId  uint64
+	movieId uint64
+}
+
+type savedPosition struct {
+	position int
+	version  int
+	savedAt  time.Time
+}
+
+// historySaves remembers the last saved position of every viewer and movie.
+type historySaves struct {
+	mutex sync.Mutex
+	items map[historyKey]savedPosition
+}
+
+func newHistorySaves() *historySaves {
+	return &historySaves{
+		items: make(map[historyKey]savedPosition),
+	}
+}
+
+func (h *historySaves) allow(userId uint64, movieId uint64, version int, position int, now time.Time) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := historyKey{userId, movieId}
+
+	last, isFound := h.items[key]
+
+	if isFound && now.Sub(last.savedAt) < historyInterval {
+		return false
+	}
+
+	if isFound && last.position == position && last.version == version {
+		return false
+	}
+
+	// A position saved longer than historyInterval ago doesn`t hold the
+	// next save back, so the old ones can go.
+	if len(h.items) >= maxHistorySaves {
+		for current, saved := range h.items {
+			if now.Sub(saved.savedAt) >= historyInterval {
+				delete(h.items, current)
+			}
 		}
-		
-		torrent, err = tf.GetTorrentFile()
+	}
+
+	h.items[key] = savedPosition{position, version, now}
+
+	return true
+}
+
+// readRange reads length bytes of the torrent data starting at offset,
+// downloading the pieces that cover them.
+func (m *Movie) readRange(movieId uint64, torrent *decode.Torrent, offset int64, length int) ([]byte, *e.Error) {
+	if offset < 0 || length <= 0 || offset+int64(length) > int64(torrent.Length) {
+		return nil, badReqErr
+	}
+
+	result := make([]byte, 0, length)
+
+	pieceLength := int64(torrent.PieceLength)
+
+	for pos := offset; pos < offset+int64(length); {
+		index := int(pos / pieceLength)
+
+		buff, err := m.getPiece(context.Background(), movieId, torrent, index)
 		if err != nil {
-			if err = os.Remove("files/" + path); err != nil {
+			return nil, err
+		}
+
+		start := pos - int64(index)*pieceLength
+		end := int64(len(buff))
+
+		if left := offset + int64(length) - pos; end-start > left {
+			end = start + left
+		}
+
+		result = append(result, buff[start:end]...)
+
+		pos += end - start
+	}
+
+	return result, nil
+}
+
+// getPiece serves pieces verified earlier from the cache and downloads the
+// rest from one peer.
+func (m *Movie) getPiece(ctx context.Context, movieId uint64, torrent *decode.Torrent, index int) ([]byte, *e.Error) {
+	return m.loadPiece(ctx, movieId, torrent, index, download)
+}
+
+// getUrgentPiece is getPiece for the piece the viewer is playing right now,
+// it is downloaded from several peers so a slow one can`t stall the
+// playback.
+func (m *Movie) getUrgentPiece(ctx context.Context, movieId uint64, torrent *decode.Torrent, index int) ([]byte, *e.Error) {
+	return m.loadPiece(ctx, movieId, torrent, index, downloadUrgent)
+}
+
+// loadPiece stops waiting when the context is done, the download goes on
+// for the cache and the other viewers.
+func (m *Movie) loadPiece(ctx context.Context, movieId uint64, torrent *decode.Torrent, index int, fetch func(decode.Torrent, int) (p2p.Piece, error)) ([]byte, *e.Error) {
+	if m.state.HasPiece(movieId, index) {
+		if buff, isFound := m.cache.Get(torrent.InfoHash, index); isFound {
+			return buff, nil
+		}
+	}
+
+	key := pieceKey{
+		infoHash: torrent.InfoHash,
+		index:    index,
+	}
+
+	load := func() ([]byte, *e.Error) {
+		return m.loads.do(key, func() ([]byte, *e.Error) {
+			piece, err := fetch(*torrent, index)
+			if err != nil {
 				return nil, internalErr
 			}
 
+			if err := m.cache.Put(torrent.InfoHash, index, piece.Buff); err == nil {
+				m.state.SetPiece(movieId, index)
+			}
+
+			return piece.Buff, nil
+		})
+	}
+
+	if ctx.Done() == nil {
+		return load()
+	}
+
+	type result struct {
+		buff []byte
+		err  *e.Error
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		buff, err := load()
+		done <- result{buff, err}
+	}()
+
+	select {
+
+	case loaded := <-done:
+		return loaded.buff, loaded.err
+
+	case <-ctx.Done():
+		return nil, canceledErr
+
+	}
+}
+
+// openTorrent opens the sources in the order of orderSources until one
+// of them opens, so a dead source is only tried when nothing else works.
+// The file version changes when the movie is played from another source.
+func (m *Movie) openTorrent(ctx context.Context, movie *entity.Movie) (*decode.Torrent, *e.Error) {
+	sources, err := m.sources.GetSources(ctx, movie.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		torrent decode.Torrent
+		current *entity.Source
+	)
+
+	for _, source := range orderSources(sources, movie.SourceId) {
+		opened, openErr := m.openSource(ctx, source)
+
+		if err := m.saveOpenResult(ctx, source, &opened, openErr); err != nil {
+			return nil, err
+		}
+
+		if openErr != nil {
 			continue
 		}
 
-		firstIndex = i
+		torrent = opened
+		current = source
 		break
 	}
 
-	if torrent.Length == 0 {
-		return nil, internalErr
+	if current == nil {
+		return nil, noSourcesErr
 	}
 
-	if firstIndex != 0 {
-		movie.Paths = strings.Join(paths[firstIndex:], ";")
+	if current.Id != movie.SourceId {
+		movie.SourceId = current.Id
 		movie.FileVersion += 1
 
 		err := m.movies.UpdateMovie(ctx, movie)
@@ -185,7 +512,7 @@ func (m *Movie) openTorrent(ctx context.Context, movie *entity.Movie) (*decode.T
 		return nil, err
 	}
 
-	if adapter.Id == 0 {
+	if adapter == nil {
 		new := &entity.Adapter{
 			MovieId:     movie.Id,
 			Version:     movie.FileVersion,
@@ -200,8 +527,4 @@ func (m *Movie) openTorrent(ctx context.Context, movie *entity.Movie) (*decode.T
 	}
 
 	return &torrent, nil
-}
-
-func countIndex(index int, adapter *entity.Adapter, torrent *decode.Torrent) int {
-	return int(math.Floor((float64(adapter.PieceLength) / float64(adapter.Length) * float64(index) * float64(100)) / (float64(torrent.PieceLength) / float64(torrent.Length) * float64(100))))
 }
\ No newline at end of file
@@ -0,0 +1,34 @@
+package movie
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistorySavesAllow(t *testing.T) {
+	saves := newHistorySaves()
+
+	start := time.Now()
+
+	tests := []struct {
+		name     string
+		userId   uint64
+		position int
+		version  int
+		after    time.Duration
+		want     bool
+	}{
+		{"first save", 1, 10, 1, 0, true},
+		{"moved too soon", 1, 11, 1, time.Second, false},
+		{"another viewer", 2, 11, 1, time.Second, true},
+		{"moved later", 1, 12, 1, historyInterval, true},
+		{"didn`t move", 1, 12, 1, 3 * historyInterval, false},
+		{"another version", 1, 12, 2, 3 * historyInterval, true},
+	}
+
+	for _, test := range tests {
+		if got := saves.allow(test.userId, 1, test.version, test.position, start.Add(test.after)); got != test.want {
+			t.Errorf("%s: allow = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
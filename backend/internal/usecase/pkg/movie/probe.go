@@ -0,0 +1,238 @@
+package movie
+
+import (
+	"context"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/logging"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media/mkv"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media/mp4"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media/mpegts"
+)
+
+const (
+	maxMoovSize = 64 << 20
+)
+
+var (
+	probeErr = e.New("This movie`s media can`t be probed.", e.BadInput)
+)
+
+// GetMovieMedia returns the duration, tracks and keyframes of the current
+// version of the movie, probing the file if it wasn`t done yet.
+func (m *Movie) GetMovieMedia(ctx context.Context, movieId uint64) (*entity.Media, *e.Error) {
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.media.GetMedia(ctx, movieId, movie.FileVersion)
+	if err == nil {
+		return result, nil
+	}
+
+	if err.Code != e.NotFound {
+		return nil, err
+	}
+
+	torrent, err := m.getTorrent(ctx, movie)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = m.probe(movieId, movie.FileVersion, torrent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.media.SaveMedia(ctx, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// probeMedia is started when a torrent is opened, so the media is known
+// before anyone asks for it.
+func (m *Movie) probeMedia(movieId uint64, version int, torrent *decode.Torrent) {
+	ctx := context.Background()
+
+	_, err := m.media.GetMedia(ctx, movieId, version)
+	if err == nil || err.Code != e.NotFound {
+		return
+	}
+
+	result, err := m.probe(movieId, version, torrent)
+	if err != nil {
+		return
+	}
+
+	if err := m.media.SaveMedia(ctx, result); err != nil {
+		logging.Default().Error("Can`t save media. Error: " + err.Message)
+	}
+}
+
+func (m *Movie) probe(movieId uint64, version int, torrent *decode.Torrent) (*entity.Media, *e.Error) {
+	file, isFound := media.MainVideo(torrent.Files)
+	if !isFound {
+		return nil, noVideoErr
+	}
+
+	read := m.fileReader(movieId, torrent, file)
+
+	head, err := read(0, min(torrent.PieceLength, file.Length))
+	if err != nil {
+		return nil, internalErr
+	}
+
+	switch media.Detect(head) {
+
+	case media.Matroska:
+		return probeMatroska(read, movieId, version, file)
+
+	case media.MP4:
+		return probeMP4(read, movieId, version, file)
+
+	case media.MPEGTS:
+		return probeMPEGTS(read, head, movieId, version, file)
+
+	default:
+		return nil, probeErr
+
+	}
+}
+
+func probeMatroska(read media.ReadAt, movieId uint64, version int, file decode.File) (*entity.Media, *e.Error) {
+	info, err := mkv.Probe(read, int64(file.Length))
+	if err != nil {
+		return nil, probeErr
+	}
+
+	result := &entity.Media{
+		MovieId:   movieId,
+		Version:   version,
+		Container: string(media.Matroska),
+		Duration:  info.Duration,
+	}
+
+	for _, track := range info.Tracks {
+		result.Tracks = append(result.Tracks, &entity.Track{
+			Number:   track.Number,
+			Type:     string(track.Type),
+			Codec:    track.Codec,
+			Language: track.Language,
+			Name:     track.Name,
+		})
+	}
+
+	for _, cue := range info.Cues {
+		result.Keyframes = append(result.Keyframes, &entity.Keyframe{
+			Time:     cue.Time,
+			Position: int64(file.Offset) + cue.Offset,
+		})
+	}
+
+	return result, nil
+}
+
+// probeMP4 takes the keyframes of the first video track from its sync
+// sample table.
+func probeMP4(read media.ReadAt, movieId uint64, version int, file decode.File) (*entity.Media, *e.Error) {
+	layout, err := mp4.Locate(read, int64(file.Length))
+	if err != nil {
+		return nil, probeErr
+	}
+
+	if layout.Moov.PayloadSize() > maxMoovSize {
+		return nil, probeErr
+	}
+
+	payload, err := read(layout.Moov.PayloadOffset(), int(layout.Moov.PayloadSize()))
+	if err != nil {
+		return nil, internalErr
+	}
+
+	movie, err := mp4.ParseMoov(payload)
+	if err != nil {
+		return nil, probeErr
+	}
+
+	result := &entity.Media{
+		MovieId:   movieId,
+		Version:   version,
+		Container: string(media.MP4),
+		Duration:  movie.Duration,
+	}
+
+	hasKeyframes := false
+
+	for i, track := range movie.Tracks {
+		result.Tracks = append(result.Tracks, &entity.Track{
+			Number:   i + 1,
+			Type:     mp4TrackType(track.Handler),
+			Codec:    track.Codec,
+			Language: track.Language,
+		})
+
+		if !track.IsVideo() || hasKeyframes || len(track.Keyframes) == 0 {
+			continue
+		}
+
+		hasKeyframes = true
+
+		for _, keyframe := range track.Keyframes {
+			result.Keyframes = append(result.Keyframes, &entity.Keyframe{
+				Time:     keyframe.Time,
+				Position: int64(file.Offset) + keyframe.Offset,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// probeMPEGTS only finds the duration, seeking in these files is done by
+// the average bitrate.
+func probeMPEGTS(read media.ReadAt, head []byte, movieId uint64, version int, file decode.File) (*entity.Media, *e.Error) {
+	tailLength := min(len(head), file.Length)
+
+	tail, err := read(int64(file.Length-tailLength), tailLength)
+	if err != nil {
+		return nil, internalErr
+	}
+
+	duration, isFound := mpegts.Duration(head, tail)
+	if !isFound {
+		return nil, probeErr
+	}
+
+	result := &entity.Media{
+		MovieId:   movieId,
+		Version:   version,
+		Container: string(media.MPEGTS),
+		Duration:  duration,
+	}
+
+	return result, nil
+}
+
+func mp4TrackType(handler string) string {
+	switch handler {
+
+	case "vide":
+		return string(mkv.Video)
+
+	case "soun":
+		return string(mkv.Audio)
+
+	case "subt", "sbtl", "text":
+		return string(mkv.Subtitle)
+
+	default:
+		return string(mkv.Other)
+
+	}
+}
@@ -0,0 +1,212 @@
+package movie
+
+import (
+	"context"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/p2p"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	progressInterval = 1 * time.Second
+
+	// The download rate is averaged over the blocks received in this time.
+	rateWindow = 5 * time.Second
+)
+
+var (
+	sessionNotFoundErr = e.New("This session wasn`t found.", e.NotFound)
+)
+
+type progressTracker struct {
+	movie     *Movie
+	movieId   uint64
+	sessionId string
+	torrent   *decode.Torrent
+	loading   map[int]entity.PieceProgress
+	received  []p2p.Event
+}
+
+// WatchProgress sends the progress of the session every progressInterval
+// until ctx is done or the session expires, then the channel is closed.
+func (m *Movie) WatchProgress(ctx context.Context, movieId uint64, sessionId string) (<-chan *entity.Progress, *e.Error) {
+	session, isFound := m.sessions.Get(sessionId)
+
+	if !isFound || session.MovieId != movieId {
+		return nil, sessionNotFoundErr
+	}
+
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	if movie.FileVersion != session.FileVersion {
+		return nil, versionErr
+	}
+
+	torrent, err := m.getTorrent(ctx, movie)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &progressTracker{
+		movie:     m,
+		movieId:   movieId,
+		sessionId: sessionId,
+		torrent:   torrent,
+		loading:   make(map[int]entity.PieceProgress),
+	}
+
+	result := make(chan *entity.Progress)
+
+	go t.run(ctx, result)
+
+	return result, nil
+}
+
+func (t *progressTracker) run(ctx context.Context, result chan<- *entity.Progress) {
+	events, unsubscribe := p2p.Subscribe(t.torrent.InfoHash)
+	defer unsubscribe()
+
+	defer close(result)
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for {
+		progress, isActive := t.snapshot()
+		if !isActive {
+			return
+		}
+
+		select {
+
+		case result <- progress:
+
+		case <-ctx.Done():
+			return
+
+		}
+
+	wait:
+		for {
+			select {
+
+			case event := <-events:
+				t.apply(event)
+
+			case <-ticker.C:
+				break wait
+
+			case <-ctx.Done():
+				return
+
+			}
+		}
+	}
+}
+
+func (t *progressTracker) apply(event p2p.Event) {
+	switch event.Type {
+
+	case p2p.BlockEvent:
+		t.loading[event.Piece] = entity.PieceProgress{
+			Index:      event.Piece,
+			Downloaded: event.Downloaded,
+			Size:       event.Size,
+		}
+
+		t.received = append(t.received, event)
+
+	case p2p.PieceEvent, p2p.PieceFailEvent:
+		delete(t.loading, event.Piece)
+
+	}
+}
+
+// snapshot builds the progress of the pieces after the playhead of the
+// session. It is false once the session is gone.
+func (t *progressTracker) snapshot() (*entity.Progress, bool) {
+	session, isFound := t.movie.sessions.Get(t.sessionId)
+	if !isFound {
+		return nil, false
+	}
+
+	count := len(t.torrent.PieceHashes)
+
+	progress := &entity.Progress{
+		SessionId: session.Id,
+		Playhead:  session.Playhead,
+		Pieces:    make([]entity.PieceProgress, 0, readAhead),
+		Buffered:  t.buffered(count),
+		Peers:     p2p.Connected(t.torrent.InfoHash),
+		Rate:      t.rate(),
+	}
+
+	for index := session.Playhead; index < count && index <= session.Playhead+readAhead; index++ {
+		piece, isLoading := t.loading[index]
+
+		if !isLoading {
+			piece = entity.PieceProgress{
+				Index: index,
+				Size:  pieceSize(t.torrent, index),
+			}
+		}
+
+		if t.movie.state.HasPiece(t.movieId, index) {
+			piece.Downloaded = piece.Size
+		}
+
+		progress.Pieces = append(progress.Pieces, piece)
+	}
+
+	return progress, true
+}
+
+func (t *progressTracker) buffered(count int) []entity.PieceRange {
+	result := make([]entity.PieceRange, 0)
+
+	for index := 0; index < count; index++ {
+		if !t.movie.state.HasPiece(t.movieId, index) {
+			continue
+		}
+
+		if last := len(result) - 1; last >= 0 && result[last].Last == index-1 {
+			result[last].Last = index
+			continue
+		}
+
+		result = append(result, entity.PieceRange{
+			First: index,
+			Last:  index,
+		})
+	}
+
+	return result
+}
+
+func (t *progressTracker) rate() float64 {
+	from := time.Now().Add(-rateWindow)
+
+	for len(t.received) > 0 && t.received[0].Time.Before(from) {
+		t.received = t.received[1:]
+	}
+
+	bytes := 0
+
+	for _, event := range t.received {
+		bytes += event.Bytes
+	}
+
+	return float64(bytes) / rateWindow.Seconds()
+}
+
+func pieceSize(torrent *decode.Torrent, index int) int {
+	begin := index * torrent.PieceLength
+
+	return min(torrent.PieceLength, torrent.Length-begin)
+}
@@ -0,0 +1,209 @@
+package movie
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	readAhead         = 8
+	schedulerInterval = 2 * time.Second
+
+	// Movies whose read-ahead downloads at once.
+	maxReadAheads = 4
+
+	// Jobs waiting for the scheduler, a job over it is dropped.
+	queueSize = 64
+)
+
+// schedule keeps downloading the pieces right after the playheads of the
+// viewers, so their next chunk requests are served from the cache. The
+// pieces ahead go through getPiece, each from one peer, the urgent mode is
+// left to the piece being played so read-ahead doesn`t take its peers.
+// The movies are read ahead in parallel, so a slow swarm only holds up its
+// own movie. The queued jobs have a worker of their own.
+func (m *Movie) schedule() {
+	go m.runQueue()
+
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.prefetchPlayheads()
+	}
+}
+
+func (m *Movie) runQueue() {
+	for job := range m.queue {
+		job()
+	}
+}
+
+// enqueue hands a download to the scheduler, so the caller doesn`t wait
+// for it.
+func (m *Movie) enqueue(job func()) {
+	select {
+
+	case m.queue <- job:
+
+	default:
+
+	}
+}
+
+func (m *Movie) prefetchPlayheads() {
+	for movieId, playheads := range m.sessions.Playheads() {
+		torrent := m.state.Get(movieId)
+
+		if torrent == nil {
+			continue
+		}
+
+		movieId, playheads := movieId, playheads
+
+		m.ahead.start(movieId, func() {
+			pieces := readAheadWindow(playheads, len(torrent.PieceHashes), func(index int) bool {
+				return m.state.HasPiece(movieId, index)
+			})
+
+			m.prefetch(movieId, torrent, pieces)
+		})
+	}
+}
+
+// readAheads runs the read-ahead of every movie in its own goroutine, at
+// most maxReadAheads of them download at once.
+type readAheads struct {
+	mutex   sync.Mutex
+	running map[uint64]bool
+	slots   chan struct{}
+}
+
+func newReadAheads(limit int) *readAheads {
+	return &readAheads{
+		running: make(map[uint64]bool),
+		slots:   make(chan struct{}, limit),
+	}
+}
+
+// start skips a movie whose read-ahead from an earlier round is still
+// running or waiting for a slot.
+func (r *readAheads) start(movieId uint64, job func()) {
+	r.mutex.Lock()
+
+	if r.running[movieId] {
+		r.mutex.Unlock()
+		return
+	}
+
+	r.running[movieId] = true
+
+	r.mutex.Unlock()
+
+	go func() {
+		r.slots <- struct{}{}
+
+		job()
+
+		<-r.slots
+
+		r.mutex.Lock()
+		delete(r.running, movieId)
+		r.mutex.Unlock()
+	}()
+}
+
+// readAheadWindow is the union of the windows after every playhead without
+// the pieces we already have. The pieces closest to a playhead go first.
+func readAheadWindow(playheads []int, count int, has func(index int) bool) []int {
+	distance := make(map[int]int)
+
+	for _, playhead := range playheads {
+		for i := playhead + 1; i < count && i <= playhead+readAhead; i++ {
+			if current, isFound := distance[i]; isFound && current <= i-playhead {
+				continue
+			}
+
+			distance[i] = i - playhead
+		}
+	}
+
+	pieces := make([]int, 0, len(distance))
+
+	for index := range distance {
+		if !has(index) {
+			pieces = append(pieces, index)
+		}
+	}
+
+	sort.Slice(pieces, func(i, j int) bool {
+		if distance[pieces[i]] != distance[pieces[j]] {
+			return distance[pieces[i]] < distance[pieces[j]]
+		}
+
+		return pieces[i] < pieces[j]
+	})
+
+	return pieces
+}
+
+type pieceLoad struct {
+	done chan struct{}
+	buff []byte
+	err  *e.Error
+}
+
+// pieceLoads lets the viewers and the scheduler wait for the same download
+// instead of starting their own.
+type pieceLoads struct {
+	mutex sync.Mutex
+	items map[pieceKey]*pieceLoad
+}
+
+type pieceKey struct {
+	infoHash [20]byte
+	index    int
+}
+
+func newLoads() *pieceLoads {
+	return &pieceLoads{
+		items: make(map[pieceKey]*pieceLoad),
+	}
+}
+
+func (l *pieceLoads) do(key pieceKey, load func() ([]byte, *e.Error)) ([]byte, *e.Error) {
+	l.mutex.Lock()
+
+	current, isFound := l.items[key]
+
+	if isFound {
+		l.mutex.Unlock()
+
+		<-current.done
+
+		return current.buff, current.err
+	}
+
+	current = &pieceLoad{
+		done: make(chan struct{}),
+	}
+
+	l.items[key] = current
+
+	l.mutex.Unlock()
+
+	current.buff, current.err = load()
+
+	l.mutex.Lock()
+
+	delete(l.items, key)
+
+	l.mutex.Unlock()
+
+	close(current.done)
+
+	return current.buff, current.err
+}
@@ -0,0 +1,58 @@
+package movie
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadAheadsStart(t *testing.T) {
+	r := newReadAheads(2)
+
+	var (
+		current, peak atomic.Int32
+		jobs          sync.WaitGroup
+	)
+
+	release := make(chan struct{})
+
+	job := func() {
+		defer jobs.Done()
+
+		now := current.Add(1)
+
+		for {
+			old := peak.Load()
+
+			if now <= old || peak.CompareAndSwap(old, now) {
+				break
+			}
+		}
+
+		<-release
+
+		current.Add(-1)
+	}
+
+	jobs.Add(4)
+
+	for movieId := uint64(1); movieId <= 4; movieId++ {
+		r.start(movieId, job)
+	}
+
+	// The movie is still running, so its next round is skipped.
+	r.start(1, func() {
+		t.Error("read-ahead of movie 1 started twice")
+	})
+
+	time.Sleep(50 * time.Millisecond)
+
+	close(release)
+
+	jobs.Wait()
+
+	if peak.Load() != 2 {
+		t.Errorf("%d read-aheads ran at once, want 2", peak.Load())
+	}
+}
@@ -0,0 +1,132 @@
+package movie
+
+import (
+	"context"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media/mpegts"
+)
+
+const (
+	seekPrefetchPieces = 4
+)
+
+// Seek finds the piece the playback from the given second starts at. The
+// keyframe index of the container is used when the file has one, otherwise
+// the position is interpolated from the average bitrate. The pieces after
+// that point are downloaded in the background.
+func (m *Movie) Seek(ctx context.Context, movieId uint64, seconds float64) (*entity.Seek, *e.Error) {
+	info, err := m.GetMovieMedia(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	torrent, err := m.getTorrent(ctx, movie)
+	if err != nil {
+		return nil, err
+	}
+
+	file, isFound := media.MainVideo(torrent.Files)
+	if !isFound {
+		return nil, noVideoErr
+	}
+
+	result := &entity.Seek{
+		FileVersion: movie.FileVersion,
+	}
+
+	result.Time, result.Position, isFound = seekPosition(info, file, seconds)
+	if !isFound {
+		return nil, probeErr
+	}
+
+	result.Piece = int(result.Position / int64(torrent.PieceLength))
+
+	var pieces []int
+
+	for i := result.Piece; i < len(torrent.PieceHashes) && i < result.Piece+seekPrefetchPieces; i++ {
+		pieces = append(pieces, i)
+	}
+
+	go m.prefetch(movieId, torrent, pieces)
+
+	return result, nil
+}
+
+// seekPosition returns the moment the playback from the given second
+// starts at and its offset in the torrent data. Nothing is found when the
+// file has neither keyframes nor a duration.
+func seekPosition(info *entity.Media, file decode.File, seconds float64) (float64, int64, bool) {
+	switch {
+
+	case len(info.Keyframes) != 0:
+		keyframe, isFound := info.KeyframeAt(seconds)
+
+		if !isFound {
+			keyframe = info.Keyframes[0]
+		}
+
+		return keyframe.Time, keyframe.Position, true
+
+	case info.Duration > 0:
+		seconds = max(0, min(seconds, info.Duration))
+
+		offset := int64(seconds / info.Duration * float64(file.Length))
+		offset = min(offset, int64(file.Length-1))
+
+		if info.Container == string(media.MPEGTS) {
+			offset -= offset % mpegts.PacketSize
+		}
+
+		return seconds, int64(file.Offset) + offset, true
+
+	default:
+		return 0, 0, false
+
+	}
+}
+
+// Remap finds the piece of the current file version that plays the same
+// moment as the piece of an older version.
+func (m *Movie) Remap(ctx context.Context, movieId uint64, version int, index int) (*entity.Seek, *e.Error) {
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := m.adapters.GetAdapter(ctx, movieId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if index < 0 || index >= from.Pieces() {
+		return nil, badReqErr
+	}
+
+	to := from
+
+	if version != movie.FileVersion {
+		to, err = m.adapters.GetAdapter(ctx, movieId, movie.FileVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &entity.Seek{
+		Piece:       from.Remap(index, to),
+		FileVersion: movie.FileVersion,
+	}
+
+	result.Position = int64(result.Piece) * int64(to.PieceLength)
+	result.Time, _ = to.Time(result.Piece)
+
+	return result, nil
+}
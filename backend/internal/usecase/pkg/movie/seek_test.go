@@ -0,0 +1,162 @@
+package movie
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media"
+)
+
+func TestSeekPosition(t *testing.T) {
+	file := decode.File{
+		Length: 10000,
+		Offset: 1000,
+	}
+
+	probed := &entity.Media{
+		Container: string(media.MP4),
+		Duration:  100,
+		Keyframes: []*entity.Keyframe{
+			{Time: 0, Position: 1000},
+			{Time: 10, Position: 5000},
+			{Time: 60, Position: 9000},
+		},
+	}
+
+	plain := &entity.Media{
+		Container: string(media.MP4),
+		Duration:  100,
+	}
+
+	ts := &entity.Media{
+		Container: string(media.MPEGTS),
+		Duration:  100,
+	}
+
+	tests := []struct {
+		name     string
+		info     *entity.Media
+		seconds  float64
+		time     float64
+		position int64
+		isFound  bool
+	}{
+		{"first keyframe", probed, 0, 0, 1000, true},
+		{"between keyframes", probed, 5, 0, 1000, true},
+		{"on a keyframe", probed, 10, 10, 5000, true},
+		{"last keyframe", probed, 99, 60, 9000, true},
+		{"after the end with keyframes", probed, 500, 60, 9000, true},
+		{"before the start with keyframes", probed, -1, 0, 1000, true},
+		{"no keyframes", plain, 50, 50, 6000, true},
+		{"no keyframes after the end", plain, 200, 100, 10999, true},
+		{"no keyframes before the start", plain, -3, 0, 1000, true},
+		{"mpegts packets", ts, 50, 50, 5888, true},
+		{"not probed", &entity.Media{}, 10, 0, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			time, position, isFound := seekPosition(test.info, file, test.seconds)
+
+			if time != test.time || position != test.position || isFound != test.isFound {
+				t.Errorf("seekPosition(%v) = %v, %d, %v, want %v, %d, %v", test.seconds, time, position, isFound, test.time, test.position, test.isFound)
+			}
+		})
+	}
+}
+
+type fakeMovies struct {
+	MovieStorage
+	movie *entity.Movie
+}
+
+func (f *fakeMovies) GetMovieById(ctx context.Context, id uint64) (*entity.Movie, *e.Error) {
+	return f.movie, nil
+}
+
+type fakeAdapters struct {
+	AdapterStorage
+	items map[int]*entity.Adapter
+}
+
+func (f *fakeAdapters) GetAdapter(ctx context.Context, movieId uint64, version int) (*entity.Adapter, *e.Error) {
+	adapter, isFound := f.items[version]
+	if !isFound {
+		return nil, movieNotFoundErr
+	}
+
+	return adapter, nil
+}
+
+func TestRemap(t *testing.T) {
+	m := &Movie{
+		movies: &fakeMovies{
+			movie: &entity.Movie{Id: 1, FileVersion: 2},
+		},
+		adapters: &fakeAdapters{
+			items: map[int]*entity.Adapter{
+				1: {
+					Version:     1,
+					Length:      1000,
+					PieceLength: 100,
+					Duration:    100,
+					Keyframes: []*entity.Keyframe{
+						{Time: 0, Position: 0},
+						{Time: 10, Position: 200},
+						{Time: 50, Position: 600},
+					},
+				},
+				2: {
+					Version:     2,
+					Length:      2000,
+					PieceLength: 100,
+					Duration:    100,
+					Keyframes: []*entity.Keyframe{
+						{Time: 0, Position: 0},
+						{Time: 10, Position: 400},
+						{Time: 50, Position: 1200},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		version  int
+		index    int
+		piece    int
+		time     float64
+		position int64
+		err      *e.Error
+	}{
+		{"first keyframe", 1, 0, 0, 0, 0, nil},
+		{"keyframe", 1, 2, 4, 10, 400, nil},
+		{"last keyframe", 1, 9, 12, 50, 1200, nil},
+		{"current version", 2, 4, 4, 10, 400, nil},
+		{"out of range", 1, 10, 0, 0, 0, badReqErr},
+		{"negative", 1, -1, 0, 0, 0, badReqErr},
+		{"unknown version", 3, 0, 0, 0, 0, movieNotFoundErr},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := m.Remap(context.Background(), 1, test.version, test.index)
+
+			if err != test.err {
+				t.Fatalf("Remap(%d, %d) error = %v, want %v", test.version, test.index, err, test.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if result.Piece != test.piece || result.Time != test.time || result.Position != test.position || result.FileVersion != 2 {
+				t.Errorf("Remap(%d, %d) = %+v, want piece %d, time %v, position %d", test.version, test.index, result, test.piece, test.time, test.position)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package movie
+
+import (
+	"context"
+	"encoding/hex"
+	"sort"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+func (m *Movie) openSource(ctx context.Context, source *entity.Source) (decode.Torrent, error) {
+	data, err := m.blobs.Get(ctx, source.Path)
+	if err != nil {
+		return decode.Torrent{}, err
+	}
+
+	tf, err := decode.Parse(data)
+	if err != nil {
+		return decode.Torrent{}, err
+	}
+
+	return tf.GetTorrentFile()
+}
+
+// saveOpenResult remembers the error of opening the source, the row is
+// only written when something has changed. The health of the source is
+// left to the background checks, so one failed announce doesn`t take it
+// out of use.
+func (m *Movie) saveOpenResult(ctx context.Context, source *entity.Source, torrent *decode.Torrent, openErr error) *e.Error {
+	lastError, infoHash := "", source.InfoHash
+
+	if openErr != nil {
+		lastError = openErr.Error()
+	} else {
+		infoHash = hex.EncodeToString(torrent.InfoHash[:])
+	}
+
+	if lastError == source.LastError && infoHash == source.InfoHash {
+		return nil
+	}
+
+	source.LastError = lastError
+	source.InfoHash = infoHash
+
+	return m.sources.UpdateSource(ctx, source)
+}
+
+// orderSources gives the sources in the order to try them: by health, the
+// dead ones last, and by priority among the equally healthy ones. The
+// current source goes first while it is as good as the best one, so the
+// file version doesn`t change for nothing. Disabled sources are left out.
+func orderSources(sources []*entity.Source, currentId uint64) []*entity.Source {
+	result := make([]*entity.Source, 0, len(sources))
+
+	for _, source := range sources {
+		if !source.IsDisabled() {
+			result = append(result, source)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].IsBetter(result[j])
+	})
+
+	for i, source := range result {
+		if source.Id == currentId && source.IsUsable() && !result[0].IsBetter(source) {
+			copy(result[1:i+1], result[:i])
+			result[0] = source
+			break
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,214 @@
+package movie
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media/mp4"
+)
+
+const (
+	// Pieces of media data after the header the player needs to start.
+	startupMediaPieces = 2
+
+	maxPrefetchPieces = 16
+	prefetchWorkers   = 4
+
+	// How long StartWatch waits for the box headers of a file that isn`t
+	// cached yet.
+	startupTimeout = 10 * time.Second
+)
+
+var (
+	notCachedErr = errors.New("piece isn`t cached")
+)
+
+// prefetchStartup returns the byte ranges an MP4 player needs before the
+// playback can begin and queues their pieces on the scheduler. The ranges
+// are found from the box headers, they are read from the cache when we
+// have them. Otherwise only the pieces with the headers are downloaded
+// here, for a file with moov at the end that is piece 0 and the piece
+// where moov starts. Other containers can be played from the beginning of
+// the file, so nothing is returned for them.
+func (m *Movie) prefetchStartup(ctx context.Context, movieId uint64, torrent *decode.Torrent) []entity.ByteRange {
+	file, isFound := media.MainVideo(torrent.Files)
+	if !isFound {
+		return nil
+	}
+
+	ranges, err := startupRanges(torrent, file, m.cachedReader(movieId, torrent, file))
+
+	if errors.Is(err, notCachedErr) {
+		ctx, cancel := context.WithTimeout(ctx, startupTimeout)
+		defer cancel()
+
+		ranges, err = startupRanges(torrent, file, m.urgentReader(ctx, movieId, torrent, file))
+	}
+
+	if err != nil || len(ranges) == 0 {
+		return nil
+	}
+
+	m.enqueue(func() {
+		m.prefetch(movieId, torrent, startupPieces(ranges))
+	})
+
+	return ranges
+}
+
+func startupPieces(ranges []entity.ByteRange) []int {
+	var pieces []int
+
+	seen := make(map[int]bool)
+
+	for _, r := range ranges {
+		for index := r.FirstPiece; index <= r.LastPiece && len(pieces) < maxPrefetchPieces; index++ {
+			if !seen[index] {
+				seen[index] = true
+				pieces = append(pieces, index)
+			}
+		}
+	}
+
+	return pieces
+}
+
+// startupRanges returns no ranges and no error for a file that isn`t MP4.
+func startupRanges(torrent *decode.Torrent, file decode.File, read media.ReadAt) ([]entity.ByteRange, error) {
+	head, err := read(0, min(torrent.PieceLength, file.Length))
+	if err != nil {
+		return nil, err
+	}
+
+	if media.Detect(head) != media.MP4 {
+		return nil, nil
+	}
+
+	layout, err := mp4.Locate(read, int64(file.Length))
+	if err != nil {
+		return nil, err
+	}
+
+	mediaLength := min(int64(startupMediaPieces*torrent.PieceLength), layout.Mdat.PayloadSize())
+
+	// Everything before the media data is needed anyway, with fast start
+	// files it already contains moov.
+	ranges := []entity.ByteRange{
+		newByteRange(torrent, file, 0, layout.Mdat.PayloadOffset()+mediaLength),
+	}
+
+	if layout.MoovAtEnd() {
+		ranges = append(ranges, newByteRange(torrent, file, layout.Moov.Offset, layout.Moov.Size))
+	}
+
+	return ranges, nil
+}
+
+// cachedReader reads a file of the torrent only from the pieces in the
+// cache, it never downloads.
+func (m *Movie) cachedReader(movieId uint64, torrent *decode.Torrent, file decode.File) media.ReadAt {
+	return pieceReader(torrent, file, func(index int) ([]byte, error) {
+		if !m.state.HasPiece(movieId, index) {
+			return nil, notCachedErr
+		}
+
+		buff, isFound := m.cache.Get(torrent.InfoHash, index)
+		if !isFound {
+			return nil, notCachedErr
+		}
+
+		return buff, nil
+	})
+}
+
+// urgentReader downloads the pieces it reads like the piece being played,
+// the viewer waits for them.
+func (m *Movie) urgentReader(ctx context.Context, movieId uint64, torrent *decode.Torrent, file decode.File) media.ReadAt {
+	return pieceReader(torrent, file, func(index int) ([]byte, error) {
+		buff, err := m.getUrgentPiece(ctx, movieId, torrent, index)
+		if err != nil {
+			return nil, errors.New(err.Message)
+		}
+
+		return buff, nil
+	})
+}
+
+// pieceReader reads a file of the torrent from the pieces given by get.
+func pieceReader(torrent *decode.Torrent, file decode.File, get func(index int) ([]byte, error)) media.ReadAt {
+	return func(offset int64, length int) ([]byte, error) {
+		if offset < 0 || offset+int64(length) > int64(file.Length) {
+			return nil, errors.New("read out of the file")
+		}
+
+		start := int64(file.Offset) + offset
+		pieceLength := int64(torrent.PieceLength)
+
+		result := make([]byte, 0, length)
+
+		for pos := start; pos < start+int64(length); {
+			index := int(pos / pieceLength)
+
+			buff, err := get(index)
+			if err != nil {
+				return nil, err
+			}
+
+			from := pos - int64(index)*pieceLength
+			to := min(int64(len(buff)), from+start+int64(length)-pos)
+
+			result = append(result, buff[from:to]...)
+
+			pos += to - from
+		}
+
+		return result, nil
+	}
+}
+
+// prefetch downloads the pieces in parallel. Failures are ignored, the
+// client will ask for these pieces again.
+func (m *Movie) prefetch(movieId uint64, torrent *decode.Torrent, pieces []int) {
+	queue := make(chan int)
+
+	var workers sync.WaitGroup
+
+	for i := 0; i < prefetchWorkers; i++ {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			for index := range queue {
+				m.getPiece(context.Background(), movieId, torrent, index)
+			}
+		}()
+	}
+
+	for _, index := range pieces {
+		queue <- index
+	}
+
+	close(queue)
+
+	workers.Wait()
+}
+
+func newByteRange(torrent *decode.Torrent, file decode.File, offset int64, length int64) entity.ByteRange {
+	start := int64(file.Offset) + offset
+	end := min(start+length, int64(file.Offset+file.Length))
+
+	pieceLength := int64(torrent.PieceLength)
+
+	return entity.ByteRange{
+		Offset:     start,
+		Length:     end - start,
+		FirstPiece: int(start / pieceLength),
+		LastPiece:  int((end - 1) / pieceLength),
+	}
+}
@@ -0,0 +1,147 @@
+package movie
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/p2p"
+)
+
+type fakeState struct {
+	State
+	mutex  sync.Mutex
+	pieces map[int]bool
+}
+
+func (f *fakeState) HasPiece(id uint64, index int) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.pieces[index]
+}
+
+func (f *fakeState) SetPiece(id uint64, index int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.pieces[index] = true
+}
+
+type fakeCache struct {
+	mutex  sync.Mutex
+	pieces map[int][]byte
+}
+
+func (f *fakeCache) Get(infoHash [20]byte, index int) ([]byte, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	buff, isFound := f.pieces[index]
+
+	return buff, isFound
+}
+
+func (f *fakeCache) Put(infoHash [20]byte, index int, data []byte) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.pieces[index] = data
+
+	return nil
+}
+
+func box(kind string, size int) []byte {
+	data := make([]byte, size)
+
+	binary.BigEndian.PutUint32(data, uint32(size))
+	copy(data[4:], kind)
+
+	return data
+}
+
+func TestPrefetchStartupEmptyCache(t *testing.T) {
+	const pieceLength = 1024
+
+	// A file written without fast start, moov is after a large mdat.
+	var data []byte
+
+	data = append(data, box("ftyp", 32)...)
+	data = append(data, box("mdat", 20*pieceLength)...)
+
+	moovOffset := int64(len(data))
+
+	data = append(data, box("moov", 500)...)
+
+	torrent := &decode.Torrent{
+		PieceLength: pieceLength,
+		Length:      len(data),
+		PieceHashes: make([][20]byte, (len(data)+pieceLength-1)/pieceLength),
+		Files: []decode.File{
+			{Path: "movie.mp4", Length: len(data)},
+		},
+	}
+
+	var (
+		mutex      sync.Mutex
+		downloaded []int
+	)
+
+	fetch := func(t decode.Torrent, index int) (p2p.Piece, error) {
+		mutex.Lock()
+		downloaded = append(downloaded, index)
+		mutex.Unlock()
+
+		end := min((index+1)*pieceLength, len(data))
+
+		return p2p.Piece{Buff: data[index*pieceLength : end]}, nil
+	}
+
+	download, downloadUrgent = fetch, fetch
+
+	defer func() {
+		download, downloadUrgent = p2p.Download, p2p.DownloadUrgent
+	}()
+
+	m := &Movie{
+		state: &fakeState{pieces: make(map[int]bool)},
+		cache: &fakeCache{pieces: make(map[int][]byte)},
+		loads: newLoads(),
+		queue: make(chan func(), queueSize),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ranges := m.prefetchStartup(ctx, 1, torrent)
+
+	if len(ranges) != 2 {
+		t.Fatalf("got %d ranges, want the head and moov: %+v", len(ranges), ranges)
+	}
+
+	moov := ranges[1]
+
+	want := entity.ByteRange{
+		Offset:     moovOffset,
+		Length:     500,
+		FirstPiece: int(moovOffset / pieceLength),
+		LastPiece:  int((moovOffset + 499) / pieceLength),
+	}
+
+	if moov != want {
+		t.Errorf("moov range = %+v, want %+v", moov, want)
+	}
+
+	// Only the pieces with the box headers are read before answering.
+	if len(downloaded) != 2 || downloaded[0] != 0 || downloaded[1] != want.FirstPiece {
+		t.Errorf("downloaded pieces %v, want [0 %d]", downloaded, want.FirstPiece)
+	}
+
+	if len(m.queue) != 1 {
+		t.Errorf("queued %d jobs, want the prefetch of the ranges", len(m.queue))
+	}
+}
@@ -0,0 +1,224 @@
+package movie
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	// Pieces a stream sends ahead of the piece the client is playing.
+	streamWindow = 8
+)
+
+var (
+	closedErr = e.New("Stream is closed.", e.BadInput)
+)
+
+// Stream pushes the pieces of a movie to one client. Pieces are sent in
+// order from the seek position, but never more than streamWindow ahead of
+// the playhead the client reports, so a slow client isn`t flooded. A
+// client that never reports its playhead gets the pieces as fast as it
+// reads them, the writes to a slow one block.
+type Stream struct {
+	movie   *Movie
+	movieId uint64
+	userId  uint64
+	torrent *decode.Torrent
+	session *entity.Session
+	pieces  int
+
+	mutex    sync.Mutex
+	changed  *sync.Cond
+	next     int
+	playhead int
+	reported bool
+	paused   bool
+	ended    bool
+	closed   bool
+}
+
+// OpenStream continues the session if it is still active, otherwise a new
+// one is started from the beginning.
+func (m *Movie) OpenStream(ctx context.Context, movieId uint64, sessionId string, userId uint64) (entity.Stream, *e.Error) {
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	torrent, err := m.getTorrent(ctx, movie)
+	if err != nil {
+		return nil, err
+	}
+
+	session, isFound := m.sessions.Get(sessionId)
+
+	if !isFound || session.MovieId != movieId || session.FileVersion != movie.FileVersion {
+		session = m.sessions.Start(movieId, movie.FileVersion, 0)
+	}
+
+	s := &Stream{
+		movie:    m,
+		movieId:  movieId,
+		userId:   userId,
+		torrent:  torrent,
+		session:  session,
+		pieces:   len(torrent.PieceHashes),
+		next:     session.Playhead,
+		playhead: session.Playhead,
+	}
+
+	s.changed = sync.NewCond(&s.mutex)
+
+	return s, nil
+}
+
+func (s *Stream) Session() *entity.Session {
+	return s.session
+}
+
+func (s *Stream) Pieces() int {
+	return s.pieces
+}
+
+// Next blocks until the window allows another piece and returns it. A nil
+// chunk means the last piece was sent, the stream waits for a seek then.
+// It gives up when the context is done, the download of the piece goes on.
+func (s *Stream) Next(ctx context.Context) (*entity.Chunk, *e.Error) {
+	stop := context.AfterFunc(ctx, func() {
+		s.update(func() {})
+	})
+	defer stop()
+
+	s.mutex.Lock()
+
+	for !s.closed && ctx.Err() == nil && (s.paused || s.ended || s.isAhead()) {
+		s.changed.Wait()
+	}
+
+	if s.closed || ctx.Err() != nil {
+		s.mutex.Unlock()
+		return nil, closedErr
+	}
+
+	if s.next >= s.pieces {
+		s.ended = true
+		s.mutex.Unlock()
+		return nil, nil
+	}
+
+	index := s.next
+	isPlaying := index == s.playhead
+
+	s.mutex.Unlock()
+
+	getPiece := s.movie.getPiece
+
+	if isPlaying {
+		getPiece = s.movie.getUrgentPiece
+	}
+
+	buff, err := getPiece(ctx, s.movieId, s.torrent, index)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// A seek while the piece was downloading moves the stream, the piece is
+	// still sent, the client drops it if it doesn`t need it.
+	if s.next == index {
+		s.next++
+	}
+
+	chunk := &entity.Chunk{
+		Buffer:      buff,
+		NextIndex:   index + 1,
+		FileVersion: s.session.FileVersion,
+		SessionId:   s.session.Id,
+	}
+
+	return chunk, nil
+}
+
+func (s *Stream) Seek(ctx context.Context, index int) *e.Error {
+	if index < 0 || index >= s.pieces {
+		return badReqErr
+	}
+
+	s.update(func() {
+		s.next = index
+		s.playhead = index
+		s.ended = false
+	})
+
+	s.played(ctx, index)
+
+	return nil
+}
+
+// Played moves the playhead to the piece the client is playing, it opens
+// the window for the next pieces.
+func (s *Stream) Played(ctx context.Context, index int) *e.Error {
+	if index < 0 || index >= s.pieces {
+		return badReqErr
+	}
+
+	s.update(func() {
+		s.playhead = index
+		s.reported = true
+	})
+
+	s.played(ctx, index)
+
+	return nil
+}
+
+func (s *Stream) Pause() {
+	s.update(func() {
+		s.paused = true
+	})
+}
+
+func (s *Stream) Resume() {
+	s.update(func() {
+		s.paused = false
+	})
+}
+
+func (s *Stream) Close() {
+	s.update(func() {
+		s.closed = true
+	})
+}
+
+// isAhead is true when the next piece is out of the window, the caller
+// holds the mutex.
+func (s *Stream) isAhead() bool {
+	return s.reported && s.next > s.playhead+streamWindow
+}
+
+func (s *Stream) update(f func()) {
+	s.mutex.Lock()
+
+	f()
+
+	s.mutex.Unlock()
+
+	s.changed.Broadcast()
+}
+
+func (s *Stream) played(ctx context.Context, index int) {
+	s.movie.sessions.Touch(s.session.Id, s.movieId, s.session.FileVersion, index)
+
+	movie := &entity.Movie{
+		Id:          s.movieId,
+		FileVersion: s.session.FileVersion,
+	}
+
+	s.movie.saveHistory(ctx, s.userId, movie, index)
+}
@@ -0,0 +1,116 @@
+package movie
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media/subtitle"
+)
+
+const (
+	maxSubtitleSize = 4 << 20
+)
+
+var (
+	subtitleNotFoundErr = e.New("This subtitle wasn`t found.", e.NotFound)
+	subtitleErr         = e.New("This subtitle can`t be converted.", e.BadInput)
+)
+
+func (m *Movie) GetSubtitles(ctx context.Context, movieId uint64) ([]*entity.Subtitle, *e.Error) {
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	torrent, err := m.getTorrent(ctx, movie)
+	if err != nil {
+		return nil, err
+	}
+
+	subtitles, _ := findSubtitles(torrent.Files)
+
+	return subtitles, nil
+}
+
+// GetSubtitle downloads the subtitle file and returns it as WebVTT.
+func (m *Movie) GetSubtitle(ctx context.Context, movieId uint64, id string) (string, *e.Error) {
+	movie, err := m.getMovie(ctx, movieId)
+	if err != nil {
+		return "", err
+	}
+
+	torrent, err := m.getTorrent(ctx, movie)
+	if err != nil {
+		return "", err
+	}
+
+	subtitles, files := findSubtitles(torrent.Files)
+
+	for i, item := range subtitles {
+		if item.Id != id {
+			continue
+		}
+
+		file := files[i]
+
+		if file.Length > maxSubtitleSize {
+			return "", subtitleErr
+		}
+
+		data, err := m.readRange(movieId, torrent, int64(file.Offset), file.Length)
+		if err != nil {
+			return "", err
+		}
+
+		vtt, convertErr := subtitle.ToVTT(subtitle.Format(item.Format), data)
+		if convertErr != nil {
+			return "", subtitleErr
+		}
+
+		return vtt, nil
+	}
+
+	return "", subtitleNotFoundErr
+}
+
+// findSubtitles returns the subtitles of the torrent with the files they
+// are read from. Ids are stable as long as the torrent is the same.
+func findSubtitles(files []decode.File) ([]*entity.Subtitle, []decode.File) {
+	var (
+		subtitles []*entity.Subtitle
+		sources   []decode.File
+	)
+
+	count := make(map[string]int)
+
+	for _, file := range files {
+		format, isFound := subtitle.Detect(file.Path)
+		if !isFound || file.Length == 0 {
+			continue
+		}
+
+		language := subtitle.Language(file.Path)
+
+		count[language]++
+
+		id := language
+
+		if count[language] > 1 {
+			id = fmt.Sprintf("%s-%d", language, count[language])
+		}
+
+		subtitles = append(subtitles, &entity.Subtitle{
+			Id:       id,
+			Language: language,
+			Name:     file.Path,
+			Format:   string(format),
+		})
+
+		sources = append(sources, file)
+	}
+
+	return subtitles, sources
+}
@@ -0,0 +1,273 @@
+package resume
+
+import (
+	"context"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/state"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/p2p"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/logging"
+)
+
+const (
+	saveInterval = 1 * time.Minute
+	maxPeers     = 200
+)
+
+type State interface {
+	Snapshot() []*state.Snapshot
+	Restore(id uint64, torrent *decode.Torrent, pieces []byte, expires time.Time)
+}
+
+type MovieStorage interface {
+	GetMovieById(ctx context.Context, id uint64) (*entity.Movie, *e.Error)
+}
+
+type ResumeStorage interface {
+	GetAll(ctx context.Context) ([]*entity.Resume, *e.Error)
+	ReplaceAll(ctx context.Context, resume []*entity.Resume) *e.Error
+}
+
+type SourceStorage interface {
+	GetSource(ctx context.Context, id uint64) (*entity.Source, *e.Error)
+}
+
+type BlobStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+type Resume struct {
+	state    State
+	movies   MovieStorage
+	storage  ResumeStorage
+	sources  SourceStorage
+	blobs    BlobStore
+	restored atomic.Bool
+}
+
+// New restores the torrents saved by the previous run in the background,
+// a restored torrent may announce, so it doesn`t hold the start. The
+// movies viewers open meanwhile aren`t replaced by the saved ones. Then the
+// state is saved in the background too.
+func New(state State, movies MovieStorage, storage ResumeStorage, sources SourceStorage, blobs BlobStore) *Resume {
+	r := &Resume{
+		state:   state,
+		movies:  movies,
+		storage: storage,
+		sources: sources,
+		blobs:   blobs,
+	}
+
+	go r.restoreAndSave()
+
+	return r
+}
+
+func (r *Resume) restoreAndSave() {
+	if err := r.Restore(context.Background()); err != nil {
+		logging.Default().Error("Can`t restore torrents. Error: " + err.Message)
+	}
+
+	r.restored.Store(true)
+
+	r.saveState()
+}
+
+func (r *Resume) Restore(ctx context.Context) *e.Error {
+	saved, err := r.storage.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(saved); i++ {
+		item := saved[i]
+
+		if item.Expires.Before(time.Now()) {
+			continue
+		}
+
+		torrent, ok := r.restoreTorrent(ctx, item)
+		if !ok {
+			continue
+		}
+
+		pieces := item.Pieces
+
+		if len(pieces) != (len(torrent.PieceHashes)+7)/8 {
+			pieces = make([]byte, (len(torrent.PieceHashes)+7)/8)
+		}
+
+		r.state.Restore(item.MovieId, torrent, pieces, item.Expires)
+	}
+
+	return nil
+}
+
+// Save replaces the saved data with the current state. Until the restore
+// is over the state has only a part of it, so nothing is saved then.
+func (r *Resume) Save(ctx context.Context) *e.Error {
+	if !r.restored.Load() {
+		return nil
+	}
+
+	snapshots := r.state.Snapshot()
+
+	result := make([]*entity.Resume, 0, len(snapshots))
+
+	for i := 0; i < len(snapshots); i++ {
+		snapshot := snapshots[i]
+		torrent := snapshot.Torrent
+
+		result = append(result, &entity.Resume{
+			MovieId:     snapshot.MovieId,
+			InfoHash:    hex.EncodeToString(torrent.InfoHash[:]),
+			Pieces:      snapshot.Pieces,
+			Peers:       joinPeers(knownPeers(torrent)),
+			Interval:    torrent.Interval,
+			AnnouncedAt: torrent.AnnouncedAt,
+			Expires:     snapshot.Expires,
+		})
+	}
+
+	return r.storage.ReplaceAll(ctx, result)
+}
+
+func (r *Resume) saveState() {
+	for {
+		time.Sleep(saveInterval)
+
+		if err := r.Save(context.Background()); err != nil {
+			logging.Default().Error("Can`t save resume data. Error: " + err.Message)
+		}
+	}
+}
+
+func (r *Resume) restoreTorrent(ctx context.Context, item *entity.Resume) (*decode.Torrent, bool) {
+	movie, err := r.movies.GetMovieById(ctx, item.MovieId)
+	if err != nil || movie.IsDeleted() {
+		return nil, false
+	}
+
+	source, err := r.sources.GetSource(ctx, movie.SourceId)
+	if err != nil || !source.IsUsable() {
+		return nil, false
+	}
+
+	data, openErr := r.blobs.Get(ctx, source.Path)
+	if openErr != nil {
+		return nil, false
+	}
+
+	tf, openErr := decode.Parse(data)
+	if openErr != nil {
+		return nil, false
+	}
+
+	if hex.EncodeToString(tf.InfoHash[:]) != item.InfoHash {
+		return nil, false
+	}
+
+	peers := splitPeers(item.Peers)
+
+	nextAnnounce := item.AnnouncedAt.Add(time.Duration(item.Interval) * time.Second)
+
+	if nextAnnounce.Before(time.Now()) {
+		torrent, announceErr := tf.GetTorrentFile()
+		if announceErr == nil {
+			torrent.Peers = mergePeers(peers, torrent.Peers)
+
+			return &torrent, true
+		}
+	}
+
+	torrent, resumeErr := tf.Resume(peers, item.Interval, item.AnnouncedAt)
+	if resumeErr != nil {
+		return nil, false
+	}
+
+	return &torrent, true
+}
+
+// knownPeers puts the peers that already gave us pieces first and drops
+// the banned ones.
+func knownPeers(torrent *decode.Torrent) []decode.Peer {
+	stats := p2p.Peers(torrent.InfoHash)
+
+	peers := make([]decode.Peer, 0, len(torrent.Peers))
+
+	for _, peer := range torrent.Peers {
+		peerStats := stats[peer.String()]
+
+		if peerStats.IsBanned() {
+			continue
+		}
+
+		peers = append(peers, peer)
+	}
+
+	sort.SliceStable(peers, func(i, j int) bool {
+		first := stats[peers[i].String()]
+		second := stats[peers[j].String()]
+
+		return first.Pieces > 0 && second.Pieces == 0
+	})
+
+	if len(peers) > maxPeers {
+		peers = peers[:maxPeers]
+	}
+
+	return peers
+}
+
+func mergePeers(saved []decode.Peer, announced []decode.Peer) []decode.Peer {
+	seen := make(map[string]bool, len(saved))
+
+	result := make([]decode.Peer, 0, len(saved)+len(announced))
+
+	for _, list := range [][]decode.Peer{saved, announced} {
+		for _, peer := range list {
+			addr := peer.String()
+
+			if seen[addr] {
+				continue
+			}
+
+			seen[addr] = true
+			result = append(result, peer)
+		}
+	}
+
+	return result
+}
+
+func joinPeers(peers []decode.Peer) string {
+	addrs := make([]string, 0, len(peers))
+
+	for _, peer := range peers {
+		addrs = append(addrs, peer.String())
+	}
+
+	return strings.Join(addrs, ";")
+}
+
+func splitPeers(peers string) []decode.Peer {
+	var result []decode.Peer
+
+	for _, addr := range strings.Split(peers, ";") {
+		peer, err := decode.ParsePeer(addr)
+		if err != nil {
+			continue
+		}
+
+		result = append(result, peer)
+	}
+
+	return result
+}
@@ -0,0 +1,160 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+)
+
+const (
+	idleTimeout   = 5 * time.Minute
+	clearInterval = 1 * time.Minute
+)
+
+// Sessions keeps the viewers in memory, a session without chunk requests
+// for idleTimeout is dropped.
+type Sessions struct {
+	sessions map[string]*entity.Session
+	mutex    *sync.Mutex
+}
+
+func New() *Sessions {
+	sessions := make(map[string]*entity.Session)
+
+	var mutex sync.Mutex
+
+	go clearSessions(sessions, &mutex)
+
+	return &Sessions{
+		sessions: sessions,
+		mutex:    &mutex,
+	}
+}
+
+func (s *Sessions) Start(movieId uint64, version int, playhead int) *entity.Session {
+	now := time.Now()
+
+	session := &entity.Session{
+		Id:          newId(),
+		MovieId:     movieId,
+		FileVersion: version,
+		Playhead:    playhead,
+		StartedAt:   now,
+		LastSeen:    now,
+	}
+
+	s.mutex.Lock()
+
+	s.sessions[session.Id] = session
+
+	s.mutex.Unlock()
+
+	result := *session
+
+	return &result
+}
+
+func (s *Sessions) Get(id string) (*entity.Session, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, isFound := s.sessions[id]
+
+	if !isFound || isExpired(session) {
+		return nil, false
+	}
+
+	result := *session
+
+	return &result, true
+}
+
+// Touch moves the playhead of the session. Unknown and expired sessions
+// are ignored, clients without a session can still watch.
+func (s *Sessions) Touch(id string, movieId uint64, version int, playhead int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, isFound := s.sessions[id]
+
+	if !isFound || session.MovieId != movieId {
+		return
+	}
+
+	session.FileVersion = version
+	session.Playhead = playhead
+	session.LastSeen = time.Now()
+}
+
+func (s *Sessions) Viewers(movieId uint64) []*entity.Session {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := make([]*entity.Session, 0)
+
+	for _, session := range s.sessions {
+		if session.MovieId != movieId || isExpired(session) {
+			continue
+		}
+
+		item := *session
+
+		result = append(result, &item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+
+	return result
+}
+
+// Playheads returns the playheads of the active sessions by movie.
+func (s *Sessions) Playheads() map[uint64][]int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := make(map[uint64][]int)
+
+	for _, session := range s.sessions {
+		if isExpired(session) {
+			continue
+		}
+
+		result[session.MovieId] = append(result[session.MovieId], session.Playhead)
+	}
+
+	return result
+}
+
+func isExpired(session *entity.Session) bool {
+	return session.LastSeen.Add(idleTimeout).Before(time.Now())
+}
+
+func newId() string {
+	id := make([]byte, 16)
+
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+func clearSessions(sessions map[string]*entity.Session, mutex *sync.Mutex) {
+	for {
+		mutex.Lock()
+
+		for key := range sessions {
+			if isExpired(sessions[key]) {
+				delete(sessions, key)
+			}
+		}
+
+		mutex.Unlock()
+
+		time.Sleep(clearInterval)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 
+	bt "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/BitField"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
 )
 
@@ -14,9 +15,17 @@ type State struct {
 
 type movie struct {
 	torrent *decode.Torrent
+	pieces  bt.BT
 	expires time.Time
 }
 
+type Snapshot struct {
+	MovieId uint64
+	Torrent *decode.Torrent
+	Pieces  []byte
+	Expires time.Time
+}
+
 func New() *State {
 	movies := make(map[uint64]*movie)
 
@@ -45,8 +54,11 @@ func (s *State) Get(id uint64) *decode.Torrent {
 }
 
 func (s *State) Add(id uint64, torrent *decode.Torrent, expires time.Duration) {
+	pieces := make(bt.BT, (len(torrent.PieceHashes) + 7) / 8)
+
 	new := &movie{
 		torrent: torrent,
+		pieces:  pieces,
 		expires: time.Now().Add(expires),
 	}
 
@@ -57,6 +69,88 @@ func (s *State) Add(id uint64, torrent *decode.Torrent, expires time.Duration) {
 	s.mutex.Unlock()
 }
 
+// Restore adds a torrent saved by the previous run. A movie opened since
+// the start is kept, it is newer than the saved one.
+func (s *State) Restore(id uint64, torrent *decode.Torrent, pieces []byte, expires time.Time) {
+	new := &movie{
+		torrent: torrent,
+		pieces:  pieces,
+		expires: expires,
+	}
+
+	s.mutex.Lock()
+
+	if _, isFound := s.movies[id]; !isFound {
+		s.movies[id] = new
+	}
+
+	s.mutex.Unlock()
+}
+
+func (s *State) HasPiece(id uint64, index int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	movie, isFound := s.movies[id]
+
+	if !isFound {
+		return false
+	}
+
+	return movie.pieces.Has(index)
+}
+
+func (s *State) SetPiece(id uint64, index int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	movie, isFound := s.movies[id]
+
+	if !isFound {
+		return
+	}
+
+	movie.pieces.Set(index)
+}
+
+func (s *State) Snapshot() []*Snapshot {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := make([]*Snapshot, 0, len(s.movies))
+
+	for id, movie := range s.movies {
+		pieces := make([]byte, len(movie.pieces))
+
+		copy(pieces, movie.pieces)
+
+		result = append(result, &Snapshot{
+			MovieId: id,
+			Torrent: movie.torrent,
+			Pieces:  pieces,
+			Expires: movie.expires,
+		})
+	}
+
+	return result
+}
+
+// Remove forgets the torrent of the movie, so the next request has to
+// announce it again. The removed torrent is returned, nil if there was none.
+func (s *State) Remove(id uint64) *decode.Torrent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	movie, isFound := s.movies[id]
+	if !isFound {
+		return nil
+	}
+
+	delete(s.movies, id)
+
+	return movie.torrent
+}
+
 func (s *State) ChangeExpires(id uint64, expires time.Duration) {
 	s.mutex.Lock()
 
@@ -0,0 +1,33 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+)
+
+func TestRestoreKeepsOpenedMovie(t *testing.T) {
+	s := New()
+
+	opened := &decode.Torrent{Name: "opened", PieceHashes: make([][20]byte, 8)}
+	saved := &decode.Torrent{Name: "saved", PieceHashes: make([][20]byte, 8)}
+
+	s.Add(1, opened, time.Hour)
+	s.SetPiece(1, 3)
+
+	s.Restore(1, saved, []byte{0xFF}, time.Now().Add(time.Hour))
+	s.Restore(2, saved, []byte{0xFF}, time.Now().Add(time.Hour))
+
+	if got := s.Get(1); got != opened {
+		t.Errorf("movie 1 has torrent %q, want the opened one", got.Name)
+	}
+
+	if s.HasPiece(1, 0) || !s.HasPiece(1, 3) {
+		t.Error("pieces of the opened movie were replaced")
+	}
+
+	if got := s.Get(2); got != saved {
+		t.Error("missing movie 2 wasn`t restored")
+	}
+}
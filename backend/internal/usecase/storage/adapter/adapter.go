@@ -13,8 +13,10 @@ import (
 )
 
 const (
-	adaptersTable = "adapters"
-	redisExpires  = 3 * time.Hour
+	adaptersTable  = "adapters"
+	mediaTable     = "media"
+	keyframesTable = "keyframes"
+	redisExpires   = 3 * time.Hour
 )
 
 var (
@@ -46,11 +48,15 @@ func (a *Adapter) GetAdapter(ctx context.Context, movieId uint64, version int) (
 		return &adapter, nil
 	}
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE movieId = %d AND version = %d;", adaptersTable, movieId, version)
+	query := fmt.Sprintf(
+		`SELECT a.id, a.movieId, a.version, a.length, a.pieceLength, COALESCE(m.duration, 0) FROM %s a
+		LEFT JOIN %s m ON m.movieId = a.movieId AND m.version = a.version WHERE a.movieId = %d AND a.version = %d;`,
+		adaptersTable, mediaTable, movieId, version,
+	)
 
 	row := a.postgres.QueryRow(ctx, query)
 
-	err = row.Scan(&adapter.Id, &adapter.MovieId, &adapter.Version, &adapter.Length, &adapter.PieceLength)
+	err = adapter.Scan(row)
 	
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -60,6 +66,19 @@ func (a *Adapter) GetAdapter(ctx context.Context, movieId uint64, version int) (
 		}
 	}
 
+	keyframes, keyframesErr := a.getKeyframes(ctx, movieId, version)
+	if keyframesErr != nil {
+		return nil, keyframesErr
+	}
+
+	adapter.Keyframes = keyframes
+
+	// Until the file is probed the adapter isn`t cached, so the metadata
+	// shows up as soon as it is saved.
+	if adapter.Duration == 0 {
+		return &adapter, nil
+	}
+
 	err = a.redis.Set(ctx, getRedisKey(movieId, version), &adapter, redisExpires).Err()
 	if err != nil {
 		return nil, internalErr
@@ -89,6 +108,34 @@ func (a *Adapter) CreateAdapter(ctx context.Context, adapter *entity.Adapter) *e
 	return nil
 }
 
+func (a *Adapter) getKeyframes(ctx context.Context, movieId uint64, version int) ([]*entity.Keyframe, *e.Error) {
+	query := fmt.Sprintf(
+		`SELECT k.* FROM %s k JOIN %s m ON k.mediaId = m.id
+		WHERE m.movieId = %d AND m.version = %d ORDER BY k.time;`,
+		keyframesTable, mediaTable, movieId, version,
+	)
+
+	rows, err := a.postgres.Query(ctx, query)
+	if err != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	var result []*entity.Keyframe
+
+	for rows.Next() {
+		var keyframe entity.Keyframe
+
+		if err := keyframe.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		result = append(result, &keyframe)
+	}
+
+	return result, nil
+}
+
 func getRedisKey(movieId uint64, fileId int) string {
 	return fmt.Sprintf("adapters:%d:%d", movieId, fileId)
 }
\ No newline at end of file
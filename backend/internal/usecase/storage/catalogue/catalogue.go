@@ -0,0 +1,517 @@
+package catalogue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/postgresql"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	moviesTable            = "movies"
+	genresTable            = "genres"
+	tagsTable              = "tags"
+	moviesGenresTable      = "movies_genres"
+	moviesTagsTable        = "movies_tags"
+	collectionsTable       = "collections"
+	collectionsMoviesTable = "collections_movies"
+	redisExpires           = 3 * time.Hour
+)
+
+var (
+	internalErr           = e.New("Something going wrong...", e.Internal)
+	genreNotFoundErr      = e.New("This genre wasn`t found", e.NotFound)
+	tagNotFoundErr        = e.New("This tag wasn`t found", e.NotFound)
+	collectionNotFoundErr = e.New("This collection wasn`t found", e.NotFound)
+)
+
+type Catalogue struct {
+	postgres postgresql.Client
+	redis    *goredis.Client
+}
+
+func New(pgClient postgresql.Client, redisClient *goredis.Client) *Catalogue {
+	return &Catalogue{
+		postgres: pgClient,
+		redis:    redisClient,
+	}
+}
+
+func (c *Catalogue) GetGenres(ctx context.Context) ([]*entity.Genre, *e.Error) {
+	var genres []*entity.Genre
+
+	isCached, err := c.getList(ctx, genresTable, &genres)
+	if err != nil {
+		return nil, err
+	}
+
+	if isCached {
+		return genres, nil
+	}
+
+	rows, queryErr := c.postgres.Query(ctx, labelsQuery(genresTable, moviesGenresTable, "genreId"))
+	if queryErr != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	genres = make([]*entity.Genre, 0)
+
+	for rows.Next() {
+		var genre entity.Genre
+
+		if err := genre.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		genres = append(genres, &genre)
+	}
+
+	if err := c.setList(ctx, genresTable, genres); err != nil {
+		return nil, err
+	}
+
+	return genres, nil
+}
+
+func (c *Catalogue) GetGenre(ctx context.Context, name string) (*entity.Genre, *e.Error) {
+	var genre entity.Genre
+
+	err := c.redis.Get(ctx, getLabelKey(genresTable, name)).Scan(&genre)
+	if err != nil && err != goredis.Nil {
+		return nil, internalErr
+	}
+
+	if genre.Id != 0 {
+		return &genre, nil
+	}
+
+	row := c.postgres.QueryRow(ctx, labelQuery(genresTable, moviesGenresTable, "genreId"), name)
+
+	if err := genre.Scan(row); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, genreNotFoundErr
+		} else {
+			return nil, internalErr
+		}
+	}
+
+	ids, idsErr := c.getMoviesIds(ctx, moviesGenresTable, "genreId", genre.Id)
+	if idsErr != nil {
+		return nil, idsErr
+	}
+
+	genre.MoviesIds = ids
+
+	err = c.redis.Set(ctx, getLabelKey(genresTable, name), &genre, redisExpires).Err()
+	if err != nil {
+		return nil, internalErr
+	}
+
+	return &genre, nil
+}
+
+func (c *Catalogue) GetTags(ctx context.Context) ([]*entity.Tag, *e.Error) {
+	var tags []*entity.Tag
+
+	isCached, err := c.getList(ctx, tagsTable, &tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if isCached {
+		return tags, nil
+	}
+
+	rows, queryErr := c.postgres.Query(ctx, labelsQuery(tagsTable, moviesTagsTable, "tagId"))
+	if queryErr != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	tags = make([]*entity.Tag, 0)
+
+	for rows.Next() {
+		var tag entity.Tag
+
+		if err := tag.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		tags = append(tags, &tag)
+	}
+
+	if err := c.setList(ctx, tagsTable, tags); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (c *Catalogue) GetTag(ctx context.Context, name string) (*entity.Tag, *e.Error) {
+	var tag entity.Tag
+
+	err := c.redis.Get(ctx, getLabelKey(tagsTable, name)).Scan(&tag)
+	if err != nil && err != goredis.Nil {
+		return nil, internalErr
+	}
+
+	if tag.Id != 0 {
+		return &tag, nil
+	}
+
+	row := c.postgres.QueryRow(ctx, labelQuery(tagsTable, moviesTagsTable, "tagId"), name)
+
+	if err := tag.Scan(row); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, tagNotFoundErr
+		} else {
+			return nil, internalErr
+		}
+	}
+
+	ids, idsErr := c.getMoviesIds(ctx, moviesTagsTable, "tagId", tag.Id)
+	if idsErr != nil {
+		return nil, idsErr
+	}
+
+	tag.MoviesIds = ids
+
+	err = c.redis.Set(ctx, getLabelKey(tagsTable, name), &tag, redisExpires).Err()
+	if err != nil {
+		return nil, internalErr
+	}
+
+	return &tag, nil
+}
+
+// ForgetLabels drops the cached genres and tags after the movies linked to
+// them have changed.
+func (c *Catalogue) ForgetLabels(ctx context.Context, genres []string, tags []string) *e.Error {
+	keys := []string{getListKey(genresTable), getListKey(tagsTable)}
+
+	for _, name := range genres {
+		keys = append(keys, getLabelKey(genresTable, name))
+	}
+
+	for _, name := range tags {
+		keys = append(keys, getLabelKey(tagsTable, name))
+	}
+
+	if err := c.redis.Del(ctx, keys...).Err(); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+// ForgetCollections drops the cached collections the movie is in, after it
+// was archived or restored.
+func (c *Catalogue) ForgetCollections(ctx context.Context, movieId uint64) *e.Error {
+	query := fmt.Sprintf("SELECT collectionId FROM %s WHERE movieId = %d;", collectionsMoviesTable, movieId)
+
+	ids, err := c.queryIds(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		keys = append(keys, getCollectionKey(id))
+	}
+
+	if err := c.redis.Del(ctx, keys...).Err(); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+func (c *Catalogue) GetCollections(ctx context.Context, limit int, offset int) ([]*entity.Collection, *e.Error) {
+	query := fmt.Sprintf("SELECT * FROM %s ORDER BY id LIMIT %d OFFSET %d;", collectionsTable, limit, offset)
+
+	rows, err := c.postgres.Query(ctx, query)
+	if err != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	collections := make([]*entity.Collection, 0)
+
+	for rows.Next() {
+		var collection entity.Collection
+
+		if err := collection.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		collections = append(collections, &collection)
+	}
+
+	return collections, nil
+}
+
+func (c *Catalogue) GetCollection(ctx context.Context, id uint64) (*entity.Collection, *e.Error) {
+	var collection entity.Collection
+
+	err := c.redis.Get(ctx, getCollectionKey(id)).Scan(&collection)
+	if err != nil && err != goredis.Nil {
+		return nil, internalErr
+	}
+
+	if collection.Id != 0 {
+		return &collection, nil
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = %d;", collectionsTable, id)
+
+	row := c.postgres.QueryRow(ctx, query)
+
+	if err := collection.Scan(row); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, collectionNotFoundErr
+		} else {
+			return nil, internalErr
+		}
+	}
+
+	query = fmt.Sprintf(
+		"SELECT l.movieId FROM %s l JOIN %s m ON m.id = l.movieId WHERE l.collectionId = %d AND m.deletedAt IS NULL ORDER BY l.position;",
+		collectionsMoviesTable, moviesTable, id,
+	)
+
+	ids, idsErr := c.queryIds(ctx, query)
+	if idsErr != nil {
+		return nil, idsErr
+	}
+
+	collection.MoviesIds = ids
+
+	err = c.redis.Set(ctx, getCollectionKey(id), &collection, redisExpires).Err()
+	if err != nil {
+		return nil, internalErr
+	}
+
+	return &collection, nil
+}
+
+func (c *Catalogue) CreateCollection(ctx context.Context, collection *entity.Collection) *e.Error {
+	query := fmt.Sprintf("INSERT INTO %s (title, description) VALUES ($1, $2) RETURNING id;", collectionsTable)
+
+	tx, err := c.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	row := tx.QueryRow(ctx, query, collection.Title, collection.Description)
+
+	if err = row.Scan(&collection.Id); err != nil {
+		return internalErr
+	}
+
+	if err = setCollectionMovies(ctx, tx, collection); err != nil {
+		return internalErr
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	err = c.redis.Set(ctx, getCollectionKey(collection.Id), collection, redisExpires).Err()
+	if err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+// UpdateCollection saves the title, the description and the order of the
+// movies.
+func (c *Catalogue) UpdateCollection(ctx context.Context, collection *entity.Collection) *e.Error {
+	query := fmt.Sprintf("UPDATE %s SET title = $1, description = $2 WHERE id = $3;", collectionsTable)
+
+	tx, err := c.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, collection.Title, collection.Description, collection.Id)
+	if err != nil {
+		return internalErr
+	}
+
+	if err = setCollectionMovies(ctx, tx, collection); err != nil {
+		return internalErr
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	err = c.redis.Set(ctx, getCollectionKey(collection.Id), collection, redisExpires).Err()
+	if err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+func (c *Catalogue) DeleteCollection(ctx context.Context, id uint64) *e.Error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1;", collectionsTable)
+
+	tx, err := c.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, id)
+	if err != nil {
+		return internalErr
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	err = c.redis.Del(ctx, getCollectionKey(id)).Err()
+	if err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+func setCollectionMovies(ctx context.Context, tx pgx.Tx, collection *entity.Collection) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE collectionId = $1;", collectionsMoviesTable)
+
+	if _, err := tx.Exec(ctx, query, collection.Id); err != nil {
+		return err
+	}
+
+	if len(collection.MoviesIds) == 0 {
+		return nil
+	}
+
+	rows := make([][]interface{}, 0, len(collection.MoviesIds))
+
+	for position, movieId := range collection.MoviesIds {
+		rows = append(rows, []interface{}{collection.Id, movieId, position})
+	}
+
+	_, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{collectionsMoviesTable},
+		[]string{"collectionid", "movieid", "position"},
+		pgx.CopyFromRows(rows),
+	)
+
+	return err
+}
+
+func (c *Catalogue) getMoviesIds(ctx context.Context, links string, column string, id uint64) ([]uint64, *e.Error) {
+	query := fmt.Sprintf(
+		"SELECT l.movieId FROM %s l JOIN %s m ON m.id = l.movieId WHERE l.%s = %d AND m.deletedAt IS NULL ORDER BY l.movieId DESC;",
+		links, moviesTable, column, id,
+	)
+
+	return c.queryIds(ctx, query)
+}
+
+func (c *Catalogue) queryIds(ctx context.Context, query string) ([]uint64, *e.Error) {
+	rows, err := c.postgres.Query(ctx, query)
+	if err != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	ids := make([]uint64, 0)
+
+	for rows.Next() {
+		var id uint64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, internalErr
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// getList reads a cached list, lists aren`t BinaryMarshalers so they are
+// stored as JSON.
+func (c *Catalogue) getList(ctx context.Context, name string, list interface{}) (bool, *e.Error) {
+	data, err := c.redis.Get(ctx, getListKey(name)).Bytes()
+	if err == goredis.Nil {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, internalErr
+	}
+
+	if err := json.Unmarshal(data, list); err != nil {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (c *Catalogue) setList(ctx context.Context, name string, list interface{}) *e.Error {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return internalErr
+	}
+
+	if err := c.redis.Set(ctx, getListKey(name), data, redisExpires).Err(); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+// Archived movies aren`t counted.
+func labelsQuery(table string, links string, column string) string {
+	return fmt.Sprintf(
+		`SELECT l.id, l.name, count(mv.id) FROM %s l
+		LEFT JOIN (%s m JOIN %s mv ON mv.id = m.movieId AND mv.deletedAt IS NULL) ON m.%s = l.id
+		GROUP BY l.id ORDER BY l.name;`,
+		table, links, moviesTable, column,
+	)
+}
+
+func labelQuery(table string, links string, column string) string {
+	return fmt.Sprintf(
+		`SELECT l.id, l.name, count(mv.id) FROM %s l
+		LEFT JOIN (%s m JOIN %s mv ON mv.id = m.movieId AND mv.deletedAt IS NULL) ON m.%s = l.id
+		WHERE l.name = $1 GROUP BY l.id;`,
+		table, links, moviesTable, column,
+	)
+}
+
+func getListKey(name string) string {
+	return name
+}
+
+func getLabelKey(table string, name string) string {
+	return fmt.Sprintf("%s:%s", table, name)
+}
+
+func getCollectionKey(id uint64) string {
+	return fmt.Sprintf("collections:%d", id)
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/postgresql"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	healthTable = "health"
+)
+
+var (
+	internalErr = e.New("Something going wrong...", e.Internal)
+)
+
+type Health struct {
+	postgres postgresql.Client
+}
+
+func New(postgres postgresql.Client) *Health {
+	return &Health{
+		postgres,
+	}
+}
+
+func (h *Health) GetMovieHealth(ctx context.Context, movieId uint64) ([]*entity.Health, *e.Error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE movieId = %d;", healthTable, movieId)
+
+	rows, err := h.postgres.Query(ctx, query)
+	if err != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	var result []*entity.Health
+
+	for rows.Next() {
+		var health entity.Health
+
+		if err := health.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		result = append(result, &health)
+	}
+
+	return result, nil
+}
+
+func (h *Health) SaveHealth(ctx context.Context, health *entity.Health) *e.Error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (movieId, path, seeders, leechers, completed, error, checkedAt, peers) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (movieId, path) DO UPDATE SET seeders = $3, leechers = $4, completed = $5, error = $6, checkedAt = $7, peers = $8;`,
+		healthTable,
+	)
+
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx, query, health.MovieId, health.Path, health.Seeders,
+		health.Leechers, health.Completed, health.Error, health.CheckedAt, health.Peers,
+	)
+	if err != nil {
+		return internalErr
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package history
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/postgresql"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	historyTable = "history"
+)
+
+var (
+	internalErr = e.New("Something going wrong...", e.Internal)
+)
+
+type History struct {
+	postgres postgresql.Client
+}
+
+func New(postgres postgresql.Client) *History {
+	return &History{
+		postgres,
+	}
+}
+
+func (h *History) GetHistory(ctx context.Context, userId uint64, limit int, offset int) ([]*entity.History, *e.Error) {
+	query := fmt.Sprintf(
+		"SELECT * FROM %s WHERE userId = %d ORDER BY updatedAt DESC LIMIT %d OFFSET %d;",
+		historyTable, userId, limit, offset,
+	)
+
+	rows, err := h.postgres.Query(ctx, query)
+	if err != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	var result []*entity.History
+
+	for rows.Next() {
+		var history entity.History
+
+		if err := history.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		result = append(result, &history)
+	}
+
+	return result, nil
+}
+
+// SaveHistory keeps one row per user and movie, the newest position wins.
+func (h *History) SaveHistory(ctx context.Context, history *entity.History) *e.Error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (userId, movieId, position, fileVersion, updatedAt) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (userId, movieId) DO UPDATE SET position = $3, fileVersion = $4, updatedAt = $5;`,
+		historyTable,
+	)
+
+	_, err := h.postgres.Exec(ctx, query, history.UserId, history.MovieId, history.Position, history.FileVersion, history.UpdatedAt)
+	if err != nil {
+		return internalErr
+	}
+
+	return nil
+}
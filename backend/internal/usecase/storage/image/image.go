@@ -0,0 +1,104 @@
+package image
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/postgresql"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	imagesTable = "images"
+)
+
+var (
+	internalErr = e.New("Something going wrong...", e.Internal)
+	notFoundErr = e.New("This image wasn`t found", e.NotFound)
+)
+
+type Image struct {
+	postgres postgresql.Client
+}
+
+func New(postgres postgresql.Client) *Image {
+	return &Image{
+		postgres,
+	}
+}
+
+func (i *Image) GetImage(ctx context.Context, movieId uint64, kind string, size string) (*entity.Image, *e.Error) {
+	var image entity.Image
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE movieId = $1 AND kind = $2 AND size = $3;", imagesTable)
+
+	err := image.Scan(i.postgres.QueryRow(ctx, query, movieId, kind, size))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, notFoundErr
+		} else {
+			return nil, internalErr
+		}
+	}
+
+	return &image, nil
+}
+
+func (i *Image) GetImages(ctx context.Context, movieId uint64) ([]*entity.Image, *e.Error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE movieId = $1;", imagesTable)
+
+	rows, err := i.postgres.Query(ctx, query, movieId)
+	if err != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	images := make([]*entity.Image, 0)
+
+	for rows.Next() {
+		var image entity.Image
+
+		if err := image.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		images = append(images, &image)
+	}
+
+	return images, nil
+}
+
+// SaveImages replaces the sizes of an image together, so a reader never
+// gets sizes of two different uploads.
+func (i *Image) SaveImages(ctx context.Context, images []*entity.Image) *e.Error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (movieId, kind, size, path, width, height, etag, updatedAt) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (movieId, kind, size) DO UPDATE SET path = $4, width = $5, height = $6, etag = $7, updatedAt = $8;`,
+		imagesTable,
+	)
+
+	tx, err := i.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	for _, image := range images {
+		_, err = tx.Exec(
+			ctx, query,
+			image.MovieId, image.Kind, image.Size, image.Path,
+			image.Width, image.Height, image.ETag, image.UpdatedAt,
+		)
+		if err != nil {
+			return internalErr
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
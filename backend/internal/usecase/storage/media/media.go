@@ -0,0 +1,150 @@
+package media
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/postgresql"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	mediaTable     = "media"
+	tracksTable    = "tracks"
+	keyframesTable = "keyframes"
+)
+
+var (
+	internalErr = e.New("Something going wrong...", e.Internal)
+	notFoundErr = e.New("This media wasn`t found", e.NotFound)
+)
+
+type Media struct {
+	postgres postgresql.Client
+}
+
+func New(postgres postgresql.Client) *Media {
+	return &Media{
+		postgres,
+	}
+}
+
+func (m *Media) GetMedia(ctx context.Context, movieId uint64, version int) (*entity.Media, *e.Error) {
+	var media entity.Media
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE movieId = %d AND version = %d;", mediaTable, movieId, version)
+
+	err := media.Scan(m.postgres.QueryRow(ctx, query))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, notFoundErr
+		} else {
+			return nil, internalErr
+		}
+	}
+
+	tracksQuery := fmt.Sprintf("SELECT * FROM %s WHERE mediaId = %d ORDER BY number;", tracksTable, media.Id)
+
+	rows, err := m.postgres.Query(ctx, tracksQuery)
+	if err != nil {
+		return nil, internalErr
+	}
+
+	for rows.Next() {
+		var track entity.Track
+
+		if err := track.Scan(rows); err != nil {
+			rows.Close()
+			return nil, internalErr
+		}
+
+		media.Tracks = append(media.Tracks, &track)
+	}
+
+	rows.Close()
+
+	keyframesQuery := fmt.Sprintf("SELECT * FROM %s WHERE mediaId = %d ORDER BY time;", keyframesTable, media.Id)
+
+	rows, err = m.postgres.Query(ctx, keyframesQuery)
+	if err != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var keyframe entity.Keyframe
+
+		if err := keyframe.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		media.Keyframes = append(media.Keyframes, &keyframe)
+	}
+
+	return &media, nil
+}
+
+// SaveMedia stores the media with its tracks and keyframes. If the version
+// of the movie was probed already, the saved data is kept.
+func (m *Media) SaveMedia(ctx context.Context, media *entity.Media) *e.Error {
+	mediaQuery := fmt.Sprintf(
+		"INSERT INTO %s (movieId, version, container, duration) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING RETURNING id;",
+		mediaTable,
+	)
+
+	trackQuery := fmt.Sprintf(
+		"INSERT INTO %s (mediaId, number, type, codec, language, name) VALUES ($1, $2, $3, $4, $5, $6);",
+		tracksTable,
+	)
+
+	tx, err := m.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, mediaQuery, media.MovieId, media.Version, media.Container, media.Duration).Scan(&media.Id)
+	if err == pgx.ErrNoRows {
+		return nil
+	}
+
+	if err != nil {
+		return internalErr
+	}
+
+	for i := 0; i < len(media.Tracks); i++ {
+		track := media.Tracks[i]
+		track.MediaId = media.Id
+
+		_, err = tx.Exec(ctx, trackQuery, track.MediaId, track.Number, track.Type, track.Codec, track.Language, track.Name)
+		if err != nil {
+			return internalErr
+		}
+	}
+
+	keyframes := make([][]any, 0, len(media.Keyframes))
+
+	for i := 0; i < len(media.Keyframes); i++ {
+		keyframe := media.Keyframes[i]
+		keyframe.MediaId = media.Id
+
+		keyframes = append(keyframes, []any{keyframe.MediaId, keyframe.Time, keyframe.Position})
+	}
+
+	_, err = tx.CopyFrom(
+		ctx, pgx.Identifier{keyframesTable},
+		[]string{"mediaid", "time", "position"},
+		pgx.CopyFromRows(keyframes),
+	)
+	if err != nil {
+		return internalErr
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package movie
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	sourcesTable = "movie_sources"
+)
+
+// ImportMovies creates the movies of the rows that didn`t fail with their
+// sources in one transaction, so either all of them are created or none.
+// The first source becomes the current one of the movie. The movies are
+// cached when they are read for the first time.
+func (m *Movie) ImportMovies(ctx context.Context, imports []*entity.Import) *e.Error {
+	tx, err := m.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	for _, item := range imports {
+		if item.IsFailed() {
+			continue
+		}
+
+		if err = insertMovie(ctx, tx, item.Movie); err != nil {
+			return internalErr
+		}
+
+		if err = insertSources(ctx, tx, item.Movie, item.Sources); err != nil {
+			return internalErr
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+// insertSources adds the sources after the ones the movie already has. The
+// first of them becomes the current one if the movie has none, otherwise
+// the first viewer would change the file version.
+func insertSources(ctx context.Context, tx pgx.Tx, movie *entity.Movie, sources []*entity.Source) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %[1]s (movieId, path, infoHash, addedBy, status, health, priority)
+		SELECT $1, $2, $3, NULLIF($4, 0), $5, $6, COALESCE(max(priority), 0) + 1 FROM %[1]s WHERE movieId = $1
+		RETURNING id, priority, createdAt;`,
+		sourcesTable,
+	)
+
+	current := fmt.Sprintf("UPDATE %s SET sourceId = $1 WHERE id = $2;", moviesTable)
+
+	for _, source := range sources {
+		source.MovieId = movie.Id
+		source.Status = entity.SourceActive
+		source.Health = entity.SourceUnknown
+
+		row := tx.QueryRow(
+			ctx, query,
+			source.MovieId, source.Path, source.InfoHash, int64(source.AddedBy), source.Status, source.Health,
+		)
+
+		if err := row.Scan(&source.Id, &source.Priority, &source.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if movie.SourceId != 0 || len(sources) == 0 {
+		return nil
+	}
+
+	movie.SourceId = sources[0].Id
+
+	_, err := tx.Exec(ctx, current, movie.SourceId, movie.Id)
+
+	return err
+}
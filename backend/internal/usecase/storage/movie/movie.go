@@ -2,6 +2,7 @@ package movie
 
 import (
 	"context"
+	"strings"
 	"time"
 	"fmt"
 
@@ -13,8 +14,22 @@ import (
 )
 
 const (
-	moviesTable  = "movies"
-	redisExpires = 3 * time.Hour
+	moviesTable       = "movies"
+	genresTable       = "genres"
+	tagsTable         = "tags"
+	moviesGenresTable = "movies_genres"
+	moviesTagsTable   = "movies_tags"
+	redisExpires      = 3 * time.Hour
+
+	// The search column is only used in WHERE and ORDER BY, so it isn`t read.
+	// Genres and tags are collected from the link tables.
+	movieFields = `id, name, fileVersion, sourceId, originalTitle, description, releaseYear, runtime,
+		ARRAY(SELECT g.name FROM movies_genres mg JOIN genres g ON g.id = mg.genreId WHERE mg.movieId = movies.id ORDER BY g.name),
+		ARRAY(SELECT t.name FROM movies_tags mt JOIN tags t ON t.id = mt.tagId WHERE mt.movieId = movies.id ORDER BY t.name),
+		countries, ageRating, credits, deletedAt`
+
+	// Word similarity a title needs to match a query with a typo in it.
+	similarityThreshold = 0.3
 )
 
 var (
@@ -35,7 +50,7 @@ func New(pgClient postgresql.Client, redisClient *goredis.Client) *Movie {
 }
 
 func (m *Movie) GetAllMovies(ctx context.Context, limit int, offset int) ([]*entity.Movie, *e.Error) {
-	query := fmt.Sprintf("SELECT * FROM %s LIMIT %d OFFSET %d;", moviesTable, limit, offset)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE deletedAt IS NULL LIMIT %d OFFSET %d;", movieFields, moviesTable, limit, offset)
 
 	rows, err := m.postgres.Query(ctx, query)
 	
@@ -49,9 +64,9 @@ func (m *Movie) GetAllMovies(ctx context.Context, limit int, offset int) ([]*ent
 
 	var movies []*entity.Movie
 
-	var movie entity.Movie
-
 	for rows.Next() {
+		var movie entity.Movie
+
 		err = movie.Scan(rows)
 		if err != nil {
 			return nil, internalErr
@@ -75,7 +90,7 @@ func (m *Movie) GetMovieById(ctx context.Context, id uint64) (*entity.Movie, *e.
 		return &movie, nil
 	}
 	
-	query := fmt.Sprintf("SELECT * FROM %s WHERE id = %d;", moviesTable, id)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = %d;", movieFields, moviesTable, id)
 
 	row := m.postgres.QueryRow(ctx, query)
 
@@ -96,17 +111,117 @@ func (m *Movie) GetMovieById(ctx context.Context, id uint64) (*entity.Movie, *e.
 	return &movie, nil
 }
 
-func (m *Movie) CreateMovie(ctx context.Context, movie *entity.Movie) *e.Error {
-	query := fmt.Sprintf("INSERT INTO %s (name, paths, fileVersion) VALUES ($1, $2, $3);", moviesTable)
+// SearchMovies matches the query against the tsvector of the titles and the
+// description, titles with a typo are found by trigrams. Results are ordered
+// by relevance, or by name when there is no query.
+func (m *Movie) SearchMovies(ctx context.Context, search *entity.MovieSearch, limit int, offset int) ([]*entity.Movie, *e.Error) {
+	var (
+		conditions = []string{"deletedAt IS NULL"}
+		args       []interface{}
+		order      = "name"
+	)
+
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if search.Query != "" {
+		q := arg(search.Query)
+
+		conditions = append(conditions, fmt.Sprintf(
+			"(search @@ websearch_to_tsquery('simple', %[1]s) OR %[1]s <%% name OR %[1]s <%% originalTitle)", q,
+		))
+
+		order = fmt.Sprintf(
+			"ts_rank(search, websearch_to_tsquery('simple', %[1]s)) + greatest(word_similarity(%[1]s, name), word_similarity(%[1]s, originalTitle)) DESC, name", q,
+		)
+	}
+
+	if search.Genre != "" {
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM movies_genres mg JOIN genres g ON g.id = mg.genreId WHERE mg.movieId = movies.id AND g.name = %s)",
+			arg(search.Genre),
+		))
+	}
+
+	if search.YearFrom != 0 {
+		conditions = append(conditions, fmt.Sprintf("releaseYear >= %d", search.YearFrom))
+	}
+
+	if search.YearTo != 0 {
+		conditions = append(conditions, fmt.Sprintf("releaseYear BETWEEN 1 AND %d", search.YearTo))
+	}
+
+	if search.RuntimeMin != 0 {
+		conditions = append(conditions, fmt.Sprintf("runtime >= %d", search.RuntimeMin))
+	}
+
+	if search.RuntimeMax != 0 {
+		conditions = append(conditions, fmt.Sprintf("runtime BETWEEN 1 AND %d", search.RuntimeMax))
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT %d OFFSET %d;",
+		movieFields, moviesTable, strings.Join(conditions, " AND "), order, limit, offset,
+	)
 
 	tx, err := m.postgres.Begin(ctx)
 	if err != nil {
-		return internalErr
+		return nil, internalErr
 	}
 	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(ctx, query,  movie.Name, movie.Paths, movie.FileVersion)
+	_, err = tx.Exec(ctx, fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %g;", similarityThreshold))
+	if err != nil {
+		return nil, internalErr
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
 	if err != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	movies := make([]*entity.Movie, 0)
+
+	for rows.Next() {
+		var movie entity.Movie
+
+		if err := movie.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		movies = append(movies, &movie)
+	}
+
+	if rows.Err() != nil {
+		return nil, internalErr
+	}
+
+	rows.Close()
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, internalErr
+	}
+
+	return movies, nil
+}
+
+// CreateMovie creates the movie with its sources in one transaction, the
+// first source becomes the current one.
+func (m *Movie) CreateMovie(ctx context.Context, movie *entity.Movie, sources []*entity.Source) *e.Error {
+	tx, err := m.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	if err = insertMovie(ctx, tx, movie); err != nil {
+		return internalErr
+	}
+
+	if err = insertSources(ctx, tx, movie, sources); err != nil {
 		return internalErr
 	}
 
@@ -114,7 +229,7 @@ func (m *Movie) CreateMovie(ctx context.Context, movie *entity.Movie) *e.Error {
 		return internalErr
 	}
 
-	err = m.redis.Set(ctx, getRedisKey(movie.Id), &movie, redisExpires).Err()
+	err = m.redis.Set(ctx, getRedisKey(movie.Id), movie, redisExpires).Err()
 	if err != nil {
 		return internalErr
 	}
@@ -123,7 +238,20 @@ func (m *Movie) CreateMovie(ctx context.Context, movie *entity.Movie) *e.Error {
 }
 
 func (m *Movie) UpdateMovie(ctx context.Context, movie *entity.Movie) *e.Error {
-	query := fmt.Sprintf("UPDATE %s SET fileVersion = $1, paths = $2, name = $3 WHERE id = $4;", moviesTable)
+	return m.EditMovie(ctx, movie, nil)
+}
+
+// EditMovie updates the movie and adds the sources in one transaction. The
+// update locks the row of the movie, so the priorities of the sources
+// don`t race with AddSources.
+func (m *Movie) EditMovie(ctx context.Context, movie *entity.Movie, sources []*entity.Source) *e.Error {
+	query := fmt.Sprintf(
+		`UPDATE %s SET fileVersion = $1, sourceId = $2, name = $3, originalTitle = $4, description = $5, releaseYear = $6,
+		runtime = $7, countries = $8, ageRating = $9, credits = $10 WHERE id = $11;`,
+		moviesTable,
+	)
+
+	setDefaults(movie)
 
 	tx, err := m.postgres.Begin(ctx)
 	if err != nil {
@@ -131,11 +259,24 @@ func (m *Movie) UpdateMovie(ctx context.Context, movie *entity.Movie) *e.Error {
 	}
 	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(ctx, query, movie.FileVersion, movie.Paths, movie.Name, movie.Id)
+	_, err = tx.Exec(
+		ctx, query,
+		movie.FileVersion, movie.SourceId, movie.Name,
+		movie.OriginalTitle, movie.Description, movie.ReleaseYear, movie.Runtime,
+		movie.Countries, movie.AgeRating, movie.Credits, movie.Id,
+	)
 	if err != nil {
 		return internalErr
 	}
 
+	if err = setLabels(ctx, tx, movie); err != nil {
+		return internalErr
+	}
+
+	if err = insertSources(ctx, tx, movie, sources); err != nil {
+		return internalErr
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return internalErr
 	}
@@ -145,14 +286,196 @@ func (m *Movie) UpdateMovie(ctx context.Context, movie *entity.Movie) *e.Error {
 		return internalErr
 	}
 
-	err = m.redis.Set(ctx, getRedisKey(movie.Id), &movie, redisExpires).Err()
+	err = m.redis.Set(ctx, getRedisKey(movie.Id), movie, redisExpires).Err()
+	if err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+// DeleteMovie archives the movie, the row is kept for the history.
+func (m *Movie) DeleteMovie(ctx context.Context, id uint64) *e.Error {
+	query := fmt.Sprintf("UPDATE %s SET deletedAt = now() WHERE id = $1 AND deletedAt IS NULL;", moviesTable)
+
+	return m.setDeleted(ctx, query, id)
+}
+
+func (m *Movie) RestoreMovie(ctx context.Context, id uint64) *e.Error {
+	query := fmt.Sprintf("UPDATE %s SET deletedAt = NULL WHERE id = $1 AND deletedAt IS NOT NULL;", moviesTable)
+
+	return m.setDeleted(ctx, query, id)
+}
+
+func (m *Movie) setDeleted(ctx context.Context, query string, id uint64) *e.Error {
+	tag, err := m.postgres.Exec(ctx, query, id)
+	if err != nil {
+		return internalErr
+	}
+
+	if tag.RowsAffected() == 0 {
+		return notFoundErr
+	}
+
+	if err = m.redis.Del(ctx, getRedisKey(id)).Err(); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+// PurgeMovie removes the movie, the rows that reference it are removed by
+// the foreign keys. The cached rows are found before they are gone and
+// removed from redis after the commit.
+func (m *Movie) PurgeMovie(ctx context.Context, id uint64) *e.Error {
+	tx, err := m.postgres.Begin(ctx)
 	if err != nil {
 		return internalErr
 	}
+	defer tx.Rollback(ctx)
+
+	keys := []string{getRedisKey(id)}
+
+	cached := []struct {
+		query string
+		key   string
+	}{
+		{"SELECT id FROM comments WHERE movieId = $1;", "comments:%d"},
+		{"SELECT playlistId FROM movies_playlists WHERE movieId = $1;", "playlists:%d"},
+		{"SELECT collectionId FROM collections_movies WHERE movieId = $1;", "collections:%d"},
+		{"SELECT version FROM adapters WHERE movieId = $1;", fmt.Sprintf("adapters:%d:%%d", id)},
+	}
+
+	for _, item := range cached {
+		ids, err := queryIds(ctx, tx, item.query, id)
+		if err != nil {
+			return internalErr
+		}
+
+		for _, cachedId := range ids {
+			keys = append(keys, fmt.Sprintf(item.key, cachedId))
+		}
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1;", moviesTable)
+
+	tag, err := tx.Exec(ctx, query, id)
+	if err != nil {
+		return internalErr
+	}
+
+	if tag.RowsAffected() == 0 {
+		return notFoundErr
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	if err = m.redis.Del(ctx, keys...).Err(); err != nil {
+		return internalErr
+	}
 
 	return nil
 }
 
+func queryIds(ctx context.Context, tx pgx.Tx, query string, id uint64) ([]int64, error) {
+	rows, err := tx.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// insertMovie creates the movie with its genres and tags and sets its id.
+func insertMovie(ctx context.Context, tx pgx.Tx, movie *entity.Movie) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (name, fileVersion, sourceId, originalTitle, description, releaseYear, runtime, countries, ageRating, credits)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id;`,
+		moviesTable,
+	)
+
+	setDefaults(movie)
+
+	row := tx.QueryRow(
+		ctx, query,
+		movie.Name, movie.FileVersion, movie.SourceId,
+		movie.OriginalTitle, movie.Description, movie.ReleaseYear, movie.Runtime,
+		movie.Countries, movie.AgeRating, movie.Credits,
+	)
+
+	if err := row.Scan(&movie.Id); err != nil {
+		return err
+	}
+
+	return setLabels(ctx, tx, movie)
+}
+
+// setLabels replaces the genres and the tags of the movie, the ones that
+// don`t exist yet are created.
+func setLabels(ctx context.Context, tx pgx.Tx, movie *entity.Movie) error {
+	if err := setLinks(ctx, tx, movie.Id, genresTable, moviesGenresTable, "genreId", movie.Genres); err != nil {
+		return err
+	}
+
+	return setLinks(ctx, tx, movie.Id, tagsTable, moviesTagsTable, "tagId", movie.Tags)
+}
+
+func setLinks(ctx context.Context, tx pgx.Tx, movieId uint64, table string, links string, column string, names []string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE movieId = $1;", links)
+
+	if _, err := tx.Exec(ctx, query, movieId); err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s (name) SELECT unnest($1::TEXT[]) ON CONFLICT DO NOTHING;", table)
+
+	if _, err := tx.Exec(ctx, query, names); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(
+		"INSERT INTO %s (movieId, %s) SELECT $1, id FROM %s WHERE name = ANY($2) ON CONFLICT DO NOTHING;",
+		links, column, table,
+	)
+
+	_, err := tx.Exec(ctx, query, movieId, names)
+
+	return err
+}
+
+// setDefaults replaces the nil lists, the columns are NOT NULL.
+func setDefaults(movie *entity.Movie) {
+
+	if movie.Countries == nil {
+		movie.Countries = []string{}
+	}
+
+	if movie.Credits == nil {
+		movie.Credits = []entity.Credit{}
+	}
+}
+
+// getRedisKey has a version, so movies cached before the metadata columns,
+// the tags and the sources were added aren`t read without them.
 func getRedisKey(id uint64) string {
-	return fmt.Sprintf("movies:%d", id)
+	return fmt.Sprintf("movies:v4:%d", id)
 }
\ No newline at end of file
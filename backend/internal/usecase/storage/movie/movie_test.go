@@ -0,0 +1,121 @@
+package movie
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/pressly/goose"
+)
+
+// countingRow records how many destinations a Scan asks for.
+type countingRow struct {
+	count int
+}
+
+func (r *countingRow) Scan(dst ...interface{}) error {
+	r.count = len(dst)
+	return nil
+}
+
+// columns counts the top level columns of a select list, the commas inside
+// the subqueries don`t separate columns.
+func columns(fields string) int {
+	count, depth := 1, 0
+
+	for _, char := range fields {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+func TestMovieFieldsMatchScan(t *testing.T) {
+	var (
+		movie entity.Movie
+		row   countingRow
+	)
+
+	movie.Scan(&row)
+
+	if got := columns(movieFields); got != row.count {
+		t.Fatalf("movieFields has %d columns, Movie.Scan reads %d", got, row.count)
+	}
+}
+
+// TestScanMovieRow reads a movie back from a real database. It needs an
+// empty database in TEST_POSTGRES_URL and is skipped without one.
+func TestScanMovieRow(t *testing.T) {
+	url := os.Getenv("TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("TEST_POSTGRES_URL isn`t set")
+	}
+
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+
+	if err := goose.SetDialect("postgres"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := goose.Up(db, "../../../../migrations/scheme"); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+
+	created := &entity.Movie{
+		Name:        "Scan test",
+		FileVersion: 1,
+		ReleaseYear: 2001,
+		Genres:      []string{"drama"},
+		Tags:        []string{"scan-test"},
+		Credits:     []entity.Credit{{Name: "Someone", Role: "director"}},
+	}
+
+	if err := insertMovie(ctx, tx, created); err != nil {
+		t.Fatal(err)
+	}
+
+	var movie entity.Movie
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1;", movieFields, moviesTable)
+
+	if err := movie.Scan(tx.QueryRow(ctx, query, created.Id)); err != nil {
+		t.Fatal(err)
+	}
+
+	if movie.Id != created.Id || movie.Name != created.Name || movie.IsDeleted() {
+		t.Errorf("read %+v, want %+v", movie, created)
+	}
+
+	if strings.Join(movie.Genres, ",") != "drama" || strings.Join(movie.Tags, ",") != "scan-test" {
+		t.Errorf("labels = %v %v, want [drama] [scan-test]", movie.Genres, movie.Tags)
+	}
+}
@@ -0,0 +1,91 @@
+package resume
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/postgresql"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	resumeTable = "resume"
+)
+
+var (
+	internalErr = e.New("Something going wrong...", e.Internal)
+)
+
+type Resume struct {
+	postgres postgresql.Client
+}
+
+func New(postgres postgresql.Client) *Resume {
+	return &Resume{
+		postgres,
+	}
+}
+
+func (r *Resume) GetAll(ctx context.Context) ([]*entity.Resume, *e.Error) {
+	query := fmt.Sprintf("SELECT * FROM %s;", resumeTable)
+
+	rows, err := r.postgres.Query(ctx, query)
+	if err != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	var result []*entity.Resume
+
+	for rows.Next() {
+		var resume entity.Resume
+
+		if err := resume.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		result = append(result, &resume)
+	}
+
+	return result, nil
+}
+
+// ReplaceAll swaps the saved resume data with the given one, so torrents
+// that left the state are forgotten.
+func (r *Resume) ReplaceAll(ctx context.Context, resume []*entity.Resume) *e.Error {
+	deleteQuery := fmt.Sprintf("DELETE FROM %s;", resumeTable)
+
+	insertQuery := fmt.Sprintf(
+		"INSERT INTO %s (movieId, infoHash, pieces, peers, interval, announcedAt, expires) VALUES ($1, $2, $3, $4, $5, $6, $7);",
+		resumeTable,
+	)
+
+	tx, err := r.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, deleteQuery); err != nil {
+		return internalErr
+	}
+
+	for i := 0; i < len(resume); i++ {
+		item := resume[i]
+
+		_, err = tx.Exec(
+			ctx, insertQuery, item.MovieId, item.InfoHash, item.Pieces,
+			item.Peers, item.Interval, item.AnnouncedAt, item.Expires,
+		)
+		if err != nil {
+			return internalErr
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package source
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/entity"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/postgresql"
+	e "github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/errors"
+)
+
+const (
+	sourcesTable = "movie_sources"
+	moviesTable  = "movies"
+
+	sourceFields = "id, movieId, path, infoHash, COALESCE(addedBy, 0), status, lastError, priority, health, streak, checkedAt, createdAt"
+)
+
+var (
+	internalErr = e.New("Something going wrong...", e.Internal)
+	notFoundErr = e.New("This source wasn`t found", e.NotFound)
+)
+
+type Source struct {
+	postgres postgresql.Client
+}
+
+func New(postgres postgresql.Client) *Source {
+	return &Source{
+		postgres,
+	}
+}
+
+func (s *Source) GetSources(ctx context.Context, movieId uint64) ([]*entity.Source, *e.Error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE movieId = $1 ORDER BY priority, id;", sourceFields, sourcesTable)
+
+	rows, err := s.postgres.Query(ctx, query, movieId)
+	if err != nil {
+		return nil, internalErr
+	}
+	defer rows.Close()
+
+	sources := make([]*entity.Source, 0)
+
+	for rows.Next() {
+		var source entity.Source
+
+		if err := source.Scan(rows); err != nil {
+			return nil, internalErr
+		}
+
+		sources = append(sources, &source)
+	}
+
+	return sources, nil
+}
+
+func (s *Source) GetSource(ctx context.Context, id uint64) (*entity.Source, *e.Error) {
+	var source entity.Source
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1;", sourceFields, sourcesTable)
+
+	err := source.Scan(s.postgres.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, notFoundErr
+		} else {
+			return nil, internalErr
+		}
+	}
+
+	return &source, nil
+}
+
+// AddSources puts the sources after the existing ones of the movie, in the
+// order they are given. The movie row is locked first, so sources added at
+// the same time can`t get the same priority.
+func (s *Source) AddSources(ctx context.Context, sources []*entity.Source) *e.Error {
+	lock := fmt.Sprintf("SELECT id FROM %s WHERE id = $1 FOR UPDATE;", moviesTable)
+
+	query := fmt.Sprintf(
+		`INSERT INTO %[1]s (movieId, path, infoHash, addedBy, status, health, priority)
+		SELECT $1, $2, $3, NULLIF($4, 0), $5, $6, COALESCE(max(priority), 0) + 1 FROM %[1]s WHERE movieId = $1
+		RETURNING id, priority, createdAt;`,
+		sourcesTable,
+	)
+
+	tx, err := s.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	locked := make(map[uint64]bool)
+
+	for _, source := range sources {
+		if !locked[source.MovieId] {
+			var id uint64
+
+			if err := tx.QueryRow(ctx, lock, source.MovieId).Scan(&id); err != nil {
+				if err == pgx.ErrNoRows {
+					return notFoundErr
+				}
+
+				return internalErr
+			}
+
+			locked[source.MovieId] = true
+		}
+
+		if source.Status == "" {
+			source.Status = entity.SourceActive
+		}
+
+		if source.Health == "" {
+			source.Health = entity.SourceUnknown
+		}
+
+		row := tx.QueryRow(
+			ctx, query,
+			source.MovieId, source.Path, source.InfoHash, int64(source.AddedBy), source.Status, source.Health,
+		)
+
+		if err := row.Scan(&source.Id, &source.Priority, &source.CreatedAt); err != nil {
+			return internalErr
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+func (s *Source) UpdateSource(ctx context.Context, source *entity.Source) *e.Error {
+	query := fmt.Sprintf(
+		"UPDATE %s SET infoHash = $1, status = $2, lastError = $3, health = $4, streak = $5, checkedAt = $6 WHERE id = $7;",
+		sourcesTable,
+	)
+
+	tag, err := s.postgres.Exec(
+		ctx, query, source.InfoHash, source.Status, source.LastError,
+		source.Health, source.Streak, source.CheckedAt, source.Id,
+	)
+	if err != nil {
+		return internalErr
+	}
+
+	if tag.RowsAffected() == 0 {
+		return notFoundErr
+	}
+
+	return nil
+}
+
+// ReorderSources gives the sources the priorities in the order of the ids.
+func (s *Source) ReorderSources(ctx context.Context, movieId uint64, ids []uint64) *e.Error {
+	query := fmt.Sprintf("UPDATE %s SET priority = $1 WHERE id = $2 AND movieId = $3;", sourcesTable)
+
+	tx, err := s.postgres.Begin(ctx)
+	if err != nil {
+		return internalErr
+	}
+	defer tx.Rollback(ctx)
+
+	for i, id := range ids {
+		tag, err := tx.Exec(ctx, query, i+1, id, movieId)
+		if err != nil {
+			return internalErr
+		}
+
+		if tag.RowsAffected() == 0 {
+			return notFoundErr
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return internalErr
+	}
+
+	return nil
+}
+
+func (s *Source) DeleteSource(ctx context.Context, id uint64) *e.Error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1;", sourcesTable)
+
+	tag, err := s.postgres.Exec(ctx, query, id)
+	if err != nil {
+		return internalErr
+	}
+
+	if tag.RowsAffected() == 0 {
+		return notFoundErr
+	}
+
+	return nil
+}
@@ -2,11 +2,19 @@ package storage
 
 import (
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/adapter"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/catalogue"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/comment"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/health"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/history"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/image"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/media"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/movie"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/playlist"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/resume"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/source"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/token"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage/user"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/blob"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/client/postgresql"
 	"github.com/redis/go-redis/v9"
 )
@@ -18,15 +26,31 @@ type Storage struct {
 	Playlists *playlist.Playlist
 	Tokens    *token.Token
 	Adapters  *adapter.Adapter
+	Health    *health.Health
+	Resume    *resume.Resume
+	Media     *media.Media
+	History   *history.History
+	Catalogue *catalogue.Catalogue
+	Images    *image.Image
+	Sources   *source.Source
+	Blobs     blob.Store
 }
 
-func New(postgres postgresql.Client, redis *redis.Client) *Storage {
+func New(postgres postgresql.Client, redis *redis.Client, blobs blob.Store) *Storage {
 	return &Storage{
 		Movies:    movie.New(postgres, redis),
 		Users:     user.New(postgres, redis),
 		Comments:  comment.New(postgres, redis),
 		Playlists: playlist.New(postgres, redis),
 		Tokens:    token.New(postgres),
-		Adapters:  adapter.New(postgres, redis),		
+		Adapters:  adapter.New(postgres, redis),
+		Health:    health.New(postgres),
+		Resume:    resume.New(postgres),
+		Media:     media.New(postgres),
+		History:   history.New(postgres),
+		Catalogue: catalogue.New(postgres, redis),
+		Images:    image.New(postgres),
+		Sources:   source.New(postgres),
+		Blobs:     blobs,
 	}
 }
\ No newline at end of file
@@ -4,11 +4,17 @@ import (
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/account"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/admin"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/auth"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/catalogue"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/comment"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/health"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/history"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/movie"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/playlist"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/pkg/resume"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/session"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/state"
 	"github.com/nikitaSstepanov/p2p-streaming-service/backend/internal/usecase/storage"
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/cache"
 )
 
 type UseCase struct {
@@ -18,15 +24,27 @@ type UseCase struct {
 	Auth     *auth.Auth
 	Comment  *comment.Comment
 	Playlist *playlist.Playlist
+	Health   *health.Health
+	Resume   *resume.Resume
+	History  *history.History
+	Catalogue *catalogue.Catalogue
+	Jwt      *auth.JwtUseCase
 }
 
-func New(store *storage.Storage, state *state.State, jwt *auth.JwtUseCase) *UseCase {
+func New(store *storage.Storage, state *state.State, cache *cache.Cache, jwt *auth.JwtUseCase, healthCfg *health.Config) *UseCase {
+	sessions := session.New()
+
 	return &UseCase{
-		Movies:   movie.New(store.Movies, store.Adapters, state),
+		Movies:   movie.New(store.Movies, store.Adapters, store.Media, store.Images, store.Sources, store.Blobs, state, cache, sessions, store.History),
 		Accounts: account.New(store.Users, jwt),
-		Admin:    admin.New(store.Users, store.Movies),
+		Admin:    admin.New(store.Users, store.Movies, store.Health, store.Catalogue, store.Images, store.Sources, store.Blobs, state, cache, sessions),
 		Auth:     auth.New(jwt, store.Users, store.Tokens),
 		Comment:  comment.New(store.Comments, store.Movies),
 		Playlist: playlist.New(store.Playlists, store.Movies),
+		Health:   health.New(healthCfg, store.Movies, store.Health, store.Sources, store.Blobs, state),
+		Resume:   resume.New(state, store.Movies, store.Resume, store.Sources, store.Blobs),
+		History:  history.New(store.History, store.Movies, store.Adapters),
+		Catalogue: catalogue.New(store.Catalogue, store.Movies),
+		Jwt:      jwt,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE health (
+    id SERIAL PRIMARY KEY,
+    movieId SERIAL,
+    path VARCHAR(255),
+    seeders INTEGER,
+    leechers INTEGER,
+    completed INTEGER,
+    error TEXT,
+    checkedAt TIMESTAMP,
+    UNIQUE (movieId, path),
+    FOREIGN KEY (movieId) REFERENCES movies (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE health;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE resume (
+    movieId INTEGER PRIMARY KEY,
+    infoHash VARCHAR(40),
+    pieces BYTEA,
+    peers TEXT,
+    interval INTEGER,
+    announcedAt TIMESTAMP,
+    expires TIMESTAMP,
+    FOREIGN KEY (movieId) REFERENCES movies (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE resume;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE media (
+    id SERIAL PRIMARY KEY,
+    movieId INTEGER,
+    version INTEGER,
+    container VARCHAR(255),
+    duration DOUBLE PRECISION,
+    UNIQUE (movieId, version),
+    FOREIGN KEY (movieId) REFERENCES movies (id) ON DELETE CASCADE
+);
+
+CREATE TABLE tracks (
+    id SERIAL PRIMARY KEY,
+    mediaId INTEGER,
+    number INTEGER,
+    type VARCHAR(255),
+    codec VARCHAR(255),
+    language VARCHAR(255),
+    name VARCHAR(255),
+    FOREIGN KEY (mediaId) REFERENCES media (id) ON DELETE CASCADE
+);
+
+CREATE TABLE keyframes (
+    mediaId INTEGER,
+    time DOUBLE PRECISION,
+    position BIGINT,
+    FOREIGN KEY (mediaId) REFERENCES media (id) ON DELETE CASCADE
+);
+
+CREATE INDEX keyframes_media_idx ON keyframes (mediaId, time);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE keyframes;
+DROP TABLE tracks;
+DROP TABLE media;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE history (
+    id SERIAL PRIMARY KEY,
+    userId INTEGER,
+    movieId INTEGER,
+    position INTEGER,
+    fileVersion INTEGER,
+    updatedAt TIMESTAMP,
+    UNIQUE (userId, movieId),
+    FOREIGN KEY (userId) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (movieId) REFERENCES movies (id) ON DELETE CASCADE
+);
+
+CREATE INDEX history_user_idx ON history (userId, updatedAt DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE history;
+-- +goose StatementEnd
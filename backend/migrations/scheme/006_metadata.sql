@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE movies
+    ADD COLUMN originalTitle VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN releaseYear INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN runtime INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN genres TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN countries TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN ageRating VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN credits JSONB NOT NULL DEFAULT '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE movies
+    DROP COLUMN originalTitle,
+    DROP COLUMN description,
+    DROP COLUMN releaseYear,
+    DROP COLUMN runtime,
+    DROP COLUMN genres,
+    DROP COLUMN countries,
+    DROP COLUMN ageRating,
+    DROP COLUMN credits;
+-- +goose StatementEnd
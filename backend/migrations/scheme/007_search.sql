@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE movies ADD COLUMN search TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', originalTitle), 'A') ||
+    setweight(to_tsvector('simple', description), 'B')
+) STORED;
+
+CREATE INDEX movies_search_idx ON movies USING GIN (search);
+CREATE INDEX movies_name_trgm_idx ON movies USING GIN (name gin_trgm_ops);
+CREATE INDEX movies_original_title_trgm_idx ON movies USING GIN (originalTitle gin_trgm_ops);
+CREATE INDEX movies_genres_idx ON movies USING GIN (genres);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX movies_genres_idx;
+DROP INDEX movies_original_title_trgm_idx;
+DROP INDEX movies_name_trgm_idx;
+DROP INDEX movies_search_idx;
+ALTER TABLE movies DROP COLUMN search;
+-- +goose StatementEnd
@@ -0,0 +1,74 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE genres (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(128) UNIQUE
+);
+
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(128) UNIQUE
+);
+
+CREATE TABLE movies_genres (
+    movieId INTEGER,
+    genreId INTEGER,
+    PRIMARY KEY (movieId, genreId),
+    FOREIGN KEY (movieId) REFERENCES movies (id) ON DELETE CASCADE,
+    FOREIGN KEY (genreId) REFERENCES genres (id) ON DELETE CASCADE
+);
+
+CREATE TABLE movies_tags (
+    movieId INTEGER,
+    tagId INTEGER,
+    PRIMARY KEY (movieId, tagId),
+    FOREIGN KEY (movieId) REFERENCES movies (id) ON DELETE CASCADE,
+    FOREIGN KEY (tagId) REFERENCES tags (id) ON DELETE CASCADE
+);
+
+CREATE INDEX movies_genres_genre_idx ON movies_genres (genreId);
+CREATE INDEX movies_tags_tag_idx ON movies_tags (tagId);
+
+CREATE TABLE collections (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255),
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE collections_movies (
+    collectionId INTEGER,
+    movieId INTEGER,
+    position INTEGER,
+    PRIMARY KEY (collectionId, movieId),
+    FOREIGN KEY (collectionId) REFERENCES collections (id) ON DELETE CASCADE,
+    FOREIGN KEY (movieId) REFERENCES movies (id) ON DELETE CASCADE
+);
+
+CREATE INDEX collections_movies_position_idx ON collections_movies (collectionId, position);
+
+INSERT INTO genres (name) SELECT DISTINCT unnest(genres) FROM movies;
+
+INSERT INTO movies_genres (movieId, genreId)
+SELECT m.id, g.id FROM movies m JOIN genres g ON g.name = ANY(m.genres);
+
+DROP INDEX movies_genres_idx;
+ALTER TABLE movies DROP COLUMN genres;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE movies ADD COLUMN genres TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE movies m SET genres = ARRAY(
+    SELECT g.name FROM movies_genres mg JOIN genres g ON g.id = mg.genreId WHERE mg.movieId = m.id ORDER BY g.name
+);
+
+CREATE INDEX movies_genres_idx ON movies USING GIN (genres);
+
+DROP TABLE collections_movies;
+DROP TABLE collections;
+DROP TABLE movies_tags;
+DROP TABLE movies_genres;
+DROP TABLE tags;
+DROP TABLE genres;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE images (
+    id SERIAL PRIMARY KEY,
+    movieId INTEGER,
+    kind VARCHAR(16),
+    size VARCHAR(16),
+    path VARCHAR(255),
+    width INTEGER,
+    height INTEGER,
+    etag VARCHAR(64),
+    updatedAt TIMESTAMP,
+    UNIQUE (movieId, kind, size),
+    FOREIGN KEY (movieId) REFERENCES movies (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE images;
+-- +goose StatementEnd
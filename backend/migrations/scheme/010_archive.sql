@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE movies ADD COLUMN deletedAt TIMESTAMP;
+
+CREATE INDEX movies_deleted_at_idx ON movies (deletedAt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX movies_deleted_at_idx;
+ALTER TABLE movies DROP COLUMN deletedAt;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE movie_sources (
+    id SERIAL PRIMARY KEY,
+    movieId INTEGER,
+    path VARCHAR(255),
+    infoHash VARCHAR(40) NOT NULL DEFAULT '',
+    addedBy INTEGER,
+    status VARCHAR(16) NOT NULL DEFAULT 'ACTIVE',
+    lastError TEXT NOT NULL DEFAULT '',
+    priority INTEGER,
+    createdAt TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (movieId, path),
+    FOREIGN KEY (movieId) REFERENCES movies (id) ON DELETE CASCADE,
+    FOREIGN KEY (addedBy) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX movie_sources_priority_idx ON movie_sources (movieId, priority);
+
+INSERT INTO movie_sources (movieId, path, priority)
+SELECT m.id, p.path, p.n FROM movies m, unnest(string_to_array(m.paths, ';')) WITH ORDINALITY AS p(path, n)
+WHERE p.path <> '';
+
+-- The source the current file version was made from, the version changes
+-- with it.
+ALTER TABLE movies ADD COLUMN sourceId INTEGER NOT NULL DEFAULT 0;
+
+UPDATE movies SET sourceId = s.id FROM movie_sources s WHERE s.movieId = movies.id AND s.priority = 1;
+
+ALTER TABLE movies DROP COLUMN paths;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE movies ADD COLUMN paths VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE movies SET paths = s.paths FROM (
+    SELECT movieId, string_agg(path, ';' ORDER BY priority) AS paths FROM movie_sources GROUP BY movieId
+) s WHERE s.movieId = movies.id;
+
+ALTER TABLE movies DROP COLUMN sourceId;
+DROP TABLE movie_sources;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE movie_sources ADD COLUMN health VARCHAR(16) NOT NULL DEFAULT 'UNKNOWN';
+ALTER TABLE movie_sources ADD COLUMN streak INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movie_sources ADD COLUMN checkedAt TIMESTAMP;
+
+-- A source that couldn`t be opened is checked again instead of being
+-- failed at once.
+UPDATE movie_sources SET status = 'ACTIVE', health = 'DEGRADED', streak = -1 WHERE status = 'FAILED';
+
+ALTER TABLE health ADD COLUMN peers INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE health DROP COLUMN peers;
+
+UPDATE movie_sources SET status = 'FAILED' WHERE status = 'ACTIVE' AND health = 'DEAD';
+
+ALTER TABLE movie_sources DROP COLUMN checkedAt;
+ALTER TABLE movie_sources DROP COLUMN streak;
+ALTER TABLE movie_sources DROP COLUMN health;
+-- +goose StatementEnd
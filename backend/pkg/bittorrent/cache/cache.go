@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/blob"
+)
+
+const (
+	cachePrefix = "cache"
+)
+
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	DeletePrefix(ctx context.Context, prefix string) error
+	List(ctx context.Context, prefix string) ([]blob.Info, error)
+}
+
+// Config holds the size of the cache in bytes, zero means unlimited.
+type Config struct {
+	MaxSize int64 `yaml:"maxSize" env-default:"10737418240"`
+}
+
+type piece struct {
+	infoHash [20]byte
+	index    int
+}
+
+type entry struct {
+	piece piece
+	size  int64
+}
+
+// Cache keeps downloaded pieces in the blob store, one blob per piece. It
+// doesn`t verify anything, callers only read pieces they know were checked.
+// When the pieces take more than cfg.MaxSize the least recently used ones
+// are deleted. The pieces stored by a previous run are counted on New as
+// the least recently used ones.
+type Cache struct {
+	store   Store
+	maxSize int64
+
+	mutex sync.Mutex
+	size  int64
+	order *list.List
+	items map[piece]*list.Element
+}
+
+// New returns the cache together with the error when the stored pieces
+// can`t be listed, the cache works then but doesn`t count them.
+func New(store Store, cfg *Config) (*Cache, error) {
+	c := &Cache{
+		store:   store,
+		maxSize: cfg.MaxSize,
+		order:   list.New(),
+		items:   make(map[piece]*list.Element),
+	}
+
+	return c, c.load()
+}
+
+// load indexes the pieces left in the store and deletes the ones over the
+// size. Blobs with keys that aren`t pieces are left alone.
+func (c *Cache) load() error {
+	stored, err := c.store.List(context.Background(), cachePrefix)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+
+	for _, item := range stored {
+		p, isValid := parseKey(item.Key)
+
+		if !isValid {
+			continue
+		}
+
+		if _, isFound := c.items[p]; isFound {
+			continue
+		}
+
+		c.items[p] = c.order.PushBack(&entry{p, item.Size})
+		c.size += item.Size
+	}
+
+	evicted := c.evict()
+
+	c.mutex.Unlock()
+
+	c.delete(evicted)
+
+	return nil
+}
+
+func (c *Cache) Get(infoHash [20]byte, index int) ([]byte, bool) {
+	data, err := c.store.Get(context.Background(), pieceKey(infoHash, index))
+	if err != nil {
+		return nil, false
+	}
+
+	c.use(piece{infoHash, index}, len(data))
+
+	return data, true
+}
+
+func (c *Cache) Put(infoHash [20]byte, index int, data []byte) error {
+	if err := c.store.Put(context.Background(), pieceKey(infoHash, index), data); err != nil {
+		return err
+	}
+
+	c.use(piece{infoHash, index}, len(data))
+
+	return nil
+}
+
+func (c *Cache) Remove(infoHash [20]byte) error {
+	c.mutex.Lock()
+
+	for p, item := range c.items {
+		if p.infoHash == infoHash {
+			c.size -= item.Value.(*entry).size
+			c.order.Remove(item)
+			delete(c.items, p)
+		}
+	}
+
+	c.mutex.Unlock()
+
+	return c.store.DeletePrefix(context.Background(), torrentKey(infoHash))
+}
+
+// use marks the piece as the most recently used one and deletes the
+// oldest pieces while the cache is over its size. The piece itself is
+// never deleted here.
+func (c *Cache) use(p piece, size int) {
+	c.mutex.Lock()
+
+	if item, isFound := c.items[p]; isFound {
+		current := item.Value.(*entry)
+
+		c.size += int64(size) - current.size
+		current.size = int64(size)
+
+		c.order.MoveToFront(item)
+	} else {
+		c.items[p] = c.order.PushFront(&entry{p, int64(size)})
+		c.size += int64(size)
+	}
+
+	evicted := c.evict()
+
+	c.mutex.Unlock()
+
+	c.delete(evicted)
+}
+
+// evict drops the oldest pieces from the index while the cache is over its
+// size, the most recent one is always kept. The caller holds the mutex and
+// deletes the returned pieces from the store.
+func (c *Cache) evict() []piece {
+	var evicted []piece
+
+	for c.maxSize > 0 && c.size > c.maxSize && c.order.Len() > 1 {
+		oldest := c.order.Back()
+		current := oldest.Value.(*entry)
+
+		c.size -= current.size
+		c.order.Remove(oldest)
+		delete(c.items, current.piece)
+
+		evicted = append(evicted, current.piece)
+	}
+
+	return evicted
+}
+
+func (c *Cache) delete(pieces []piece) {
+	for _, old := range pieces {
+		c.store.Delete(context.Background(), pieceKey(old.infoHash, old.index))
+	}
+}
+
+func torrentKey(infoHash [20]byte) string {
+	return cachePrefix + "/" + hex.EncodeToString(infoHash[:])
+}
+
+func pieceKey(infoHash [20]byte, index int) string {
+	return torrentKey(infoHash) + "/" + strconv.Itoa(index)
+}
+
+// parseKey is the reverse of pieceKey.
+func parseKey(key string) (piece, bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 || parts[0] != cachePrefix {
+		return piece{}, false
+	}
+
+	var p piece
+
+	hash, err := hex.DecodeString(parts[1])
+	if err != nil || len(hash) != len(p.infoHash) {
+		return piece{}, false
+	}
+
+	copy(p.infoHash[:], hash)
+
+	p.index, err = strconv.Atoi(parts[2])
+	if err != nil || p.index < 0 {
+		return piece{}, false
+	}
+
+	return p, true
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/blob"
+)
+
+type memoryStore struct {
+	mutex sync.Mutex
+	items map[string][]byte
+}
+
+func (m *memoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	data, isFound := m.items[key]
+	if !isFound {
+		return nil, errors.New("not found")
+	}
+
+	return data, nil
+}
+
+func (m *memoryStore) Put(ctx context.Context, key string, data []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.items[key] = data
+
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.items, key)
+
+	return nil
+}
+
+func (m *memoryStore) DeletePrefix(ctx context.Context, prefix string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for key := range m.items {
+		if strings.HasPrefix(key, prefix+"/") {
+			delete(m.items, key)
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryStore) List(ctx context.Context, prefix string) ([]blob.Info, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	result := make([]blob.Info, 0)
+
+	for key, data := range m.items {
+		if strings.HasPrefix(key, prefix+"/") {
+			result = append(result, blob.Info{Key: key, Size: int64(len(data))})
+		}
+	}
+
+	return result, nil
+}
+
+func TestCacheIndexesStoredPieces(t *testing.T) {
+	store := &memoryStore{items: make(map[string][]byte)}
+
+	infoHash := [20]byte{1}
+
+	// Pieces and a blob that isn`t a piece left by a previous run.
+	for i := 0; i < 4; i++ {
+		store.items[pieceKey(infoHash, i)] = make([]byte, 10)
+	}
+
+	store.items["cache/notes.txt"] = make([]byte, 100)
+
+	cache, err := New(store, &Config{MaxSize: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cache.size != 20 || len(cache.items) != 2 {
+		t.Errorf("indexed %d pieces of %d bytes, want 2 of 20", len(cache.items), cache.size)
+	}
+
+	if len(store.items) != 3 {
+		t.Errorf("store has %d blobs, want 2 pieces and the other blob", len(store.items))
+	}
+
+	// A new piece pushes out one of the old ones.
+	cache.Put(infoHash, 10, make([]byte, 10))
+
+	if cache.size != 20 || len(store.items) != 3 {
+		t.Errorf("size = %d with %d blobs, want 20 with 3", cache.size, len(store.items))
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	store := &memoryStore{items: make(map[string][]byte)}
+	cache, _ := New(store, &Config{MaxSize: 30})
+
+	var infoHash [20]byte
+
+	for i := 0; i < 3; i++ {
+		if err := cache.Put(infoHash, i, make([]byte, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Piece 0 is read, so piece 1 is the oldest one now.
+	if _, isFound := cache.Get(infoHash, 0); !isFound {
+		t.Fatal("piece 0 isn`t cached")
+	}
+
+	cache.Put(infoHash, 3, make([]byte, 10))
+
+	for index, want := range []bool{true, false, true, true} {
+		if _, isFound := cache.Get(infoHash, index); isFound != want {
+			t.Errorf("piece %d cached = %v, want %v", index, isFound, want)
+		}
+	}
+
+	if cache.size != 30 {
+		t.Errorf("size = %d, want 30", cache.size)
+	}
+}
+
+func TestCacheKeepsPieceOverSize(t *testing.T) {
+	store := &memoryStore{items: make(map[string][]byte)}
+	cache, _ := New(store, &Config{MaxSize: 5})
+
+	var infoHash [20]byte
+
+	cache.Put(infoHash, 0, make([]byte, 10))
+
+	if _, isFound := cache.Get(infoHash, 0); !isFound {
+		t.Error("the only piece was evicted")
+	}
+}
+
+func TestCacheRemove(t *testing.T) {
+	store := &memoryStore{items: make(map[string][]byte)}
+	cache, _ := New(store, &Config{})
+
+	first, second := [20]byte{1}, [20]byte{2}
+
+	cache.Put(first, 0, make([]byte, 10))
+	cache.Put(second, 0, make([]byte, 10))
+
+	if err := cache.Remove(first); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, isFound := cache.Get(first, 0); isFound {
+		t.Error("removed piece is still cached")
+	}
+
+	if cache.size != 10 {
+		t.Errorf("size = %d, want 10", cache.size)
+	}
+}
@@ -2,14 +2,18 @@ package decode;
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/sha1"
 	"fmt"
 	"os"
+	"io"
+	"strings"
 	"net"
 	"net/url"
 	"net/http"
 	"strconv"
+	"time"
 	"encoding/binary"
 	// "io/ioutil"
 	// "bit_tor/peers"
@@ -28,18 +32,40 @@ type TorrentFile struct {
 	PieceLength int
 	Length      int
 	Name        string
+	Files       []File
+	// Trackers are the announce url and the ones of the announce list
+	// without repeats, peers are only requested from the first one.
+	Trackers    []string
+	WebSeeds    []string
+	Private     bool
+}
+
+// File is a file inside the torrent. Offset is the position of its first
+// byte in the concatenated data of all files.
+type File struct {
+	Path   string
+	Length int
+	Offset int
+}
+
+type bencodeFile struct {
+	Length int      `bencode:"length"`
+	Path   []string `bencode:"path"`
 }
 
 type bencodeInfo struct {
-	Pieces      string `bencode:"pieces"`
-	PieceLength int    `bencode:"piece length"`
-	Length      int    `bencode:"length"`
-	Name        string `bencode:"name"`
+	Pieces      string        `bencode:"pieces"`
+	PieceLength int           `bencode:"piece length"`
+	Length      int           `bencode:"length"`
+	Name        string        `bencode:"name"`
+	Files       []bencodeFile `bencode:"files"`
+	Private     int           `bencode:"private"`
 }
 
 type bencodeTorrent struct {
-	Announce string      `bencode:"announce"`
-	Info     bencodeInfo `bencode:"info"`
+	Announce     string      `bencode:"announce"`
+	AnnounceList [][]string  `bencode:"announce-list"`
+	Info         bencodeInfo `bencode:"info"`
 }
 
 type Peer struct {
@@ -55,6 +81,9 @@ type Torrent struct {
 	PieceLength int 
 	Length 		int
 	Name 		string
+	Interval 	int
+	AnnouncedAt time.Time
+	Files 		[]File
 }
 
 func (p *Peer) String() string {
@@ -65,6 +94,28 @@ func (p *Peer) String() string {
 	return res;
 }
 
+func ParsePeer(addr string) (Peer, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return Peer{}, err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return Peer{}, fmt.Errorf("invalid peer ip %q", host)
+	}
+
+	number, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return Peer{}, err
+	}
+
+	return Peer{
+		Ip:   ip,
+		Port: uint16(number),
+	}, nil
+}
+
 func Reverse(s string) string {
     runes := []rune(s)
     for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
@@ -128,22 +179,23 @@ func (t *TorrentFile) UnmarshalPeers(Peers []byte) ([]Peer, error) {
 	return peers, nil;
 }
 
-func (t *TorrentFile) requestPeers(peerID [20]byte) ([]Peer, error) {
+func (t *TorrentFile) requestPeers(ctx context.Context, peerID [20]byte) ([]Peer, int, error) {
 	request, err := t.buildTrackerUrl(peerID);
 	if err != 	nil {
-		return []Peer{}, err;
+		return []Peer{}, 0, err;
 	}
 
-	req, err := http.NewRequest(http.MethodGet, request, nil);
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, request, nil);
 
 	if err != nil {
-		return []Peer{}, err;
+		return []Peer{}, 0, err;
 	}
 	res, err := http.DefaultClient.Do(req);
 	
 	if err != nil {
-		return []Peer{}, err;
+		return []Peer{}, 0, err;
 	}
+	defer res.Body.Close();
 	// body, err := ioutil.ReadAll(res.Body);
 	// if err != nil {					
 	// 	return []string{" "}, err;
@@ -152,24 +204,30 @@ func (t *TorrentFile) requestPeers(peerID [20]byte) ([]Peer, error) {
 	trackerResp := BencodeTrackerResponse{};
 	err = bencode.Unmarshal(res.Body, &trackerResp);
 	if err != nil {
-		return []Peer{}, err;
+		return []Peer{}, 0, err;
 	}
 	peers, err := t.UnmarshalPeers([]byte(trackerResp.Peers));
 	if err != nil {
-		return []Peer{}, err;
+		return []Peer{}, 0, err;
 	}
 	// fmt.Println(peers);
-	return peers, nil;
+	return peers, trackerResp.Interval, nil;
 
 }
 
 func (t *TorrentFile) GetTorrentFile() (Torrent, error) {
+	return t.GetTorrentFileContext(context.Background())
+}
+
+// GetTorrentFileContext announces to the tracker until the context is
+// done, the request is cancelled with it.
+func (t *TorrentFile) GetTorrentFileContext(ctx context.Context) (Torrent, error) {
 	var peerID [20]byte;
 	_, err := rand.Read(peerID[:]);
 	if err != nil {
 		return Torrent{}, err;
 	}
-	peers, err := t.requestPeers(peerID);
+	peers, interval, err := t.requestPeers(ctx, peerID);
 	if err != nil {
 		fmt.Println(err);
 		return Torrent{}, err
@@ -182,9 +240,34 @@ func (t *TorrentFile) GetTorrentFile() (Torrent, error) {
 		PieceLength: t.PieceLength,
 		Length: t.Length,
 		Name: t.Name,
+		Interval: interval,
+		AnnouncedAt: time.Now(),
+		Files: t.Files,
 	}, nil;
 }
 
+// Resume builds the torrent from peers and tracker state saved earlier
+// instead of announcing again.
+func (t *TorrentFile) Resume(peers []Peer, interval int, announcedAt time.Time) (Torrent, error) {
+	var peerID [20]byte
+	_, err := rand.Read(peerID[:])
+	if err != nil {
+		return Torrent{}, err
+	}
+	return Torrent{
+		Peers:       peers,
+		PeerID:      peerID,
+		InfoHash:    t.InfoHash,
+		PieceHashes: t.PieceHashes,
+		PieceLength: t.PieceLength,
+		Length:      t.Length,
+		Name:        t.Name,
+		Interval:    interval,
+		AnnouncedAt: announcedAt,
+		Files:       t.Files,
+	}, nil
+}
+
 func Open(path string) (TorrentFile, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -192,22 +275,71 @@ func Open(path string) (TorrentFile, error) {
 	}
 	defer file.Close()
 
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return TorrentFile{}, err
+	}
+
+	return Parse(data)
+}
+
+// Parse reads a torrent file that is already in memory.
+func Parse(data []byte) (TorrentFile, error) {
 	bto := bencodeTorrent{}
-	err = bencode.Unmarshal(file, &bto)
+	err := bencode.Unmarshal(bytes.NewReader(data), &bto)
 	if err != nil {
 		return TorrentFile{}, err
 	}
-	return bto.toTorrentFile()
+	decoded, err := bencode.Decode(bytes.NewReader(data))
+	if err != nil {
+		return TorrentFile{}, err
+	}
+	torrent, ok := decoded.(map[string]interface{})
+	if !ok {
+		return TorrentFile{}, fmt.Errorf("received malformed torrent")
+	}
+	infoHash, err := hashInfo(torrent)
+	if err != nil {
+		return TorrentFile{}, err
+	}
+	t, err := bto.toTorrentFile(infoHash)
+	if err != nil {
+		return TorrentFile{}, err
+	}
+	t.WebSeeds = webSeeds(torrent)
+	return t, nil
 }
 
-func (i *bencodeInfo) hash() ([20]byte, error) {
+// hashInfo hashes the info dictionary as it is in the file. Marshaling it
+// back from the struct would lose the keys the struct doesn`t know about.
+func hashInfo(torrent map[string]interface{}) ([20]byte, error) {
+	info, ok := torrent["info"].(map[string]interface{})
+	if !ok {
+		return [20]byte{}, fmt.Errorf("torrent has no info dictionary")
+	}
 	var buf bytes.Buffer
-	err := bencode.Marshal(&buf, *i)
+	err := bencode.Marshal(&buf, info)
 	if err != nil {
 		return [20]byte{}, err
 	}
-	h := sha1.Sum(buf.Bytes())
-	return h, nil
+	return sha1.Sum(buf.Bytes()), nil
+}
+
+func (i *bencodeInfo) files() ([]File, int) {
+	if len(i.Files) == 0 {
+		return []File{{Path: i.Name, Length: i.Length}}, i.Length
+	}
+	files := make([]File, 0, len(i.Files))
+	offset := 0
+	for _, file := range i.Files {
+		files = append(files, File{
+			Path:   strings.Join(file.Path, "/"),
+			Length: file.Length,
+			Offset: offset,
+		})
+		offset += file.Length
+	}
+	return files, offset
 }
 
 func (i *bencodeInfo) splitPieceHashes() ([][20]byte, error) {
@@ -225,24 +357,61 @@ func (i *bencodeInfo) splitPieceHashes() ([][20]byte, error) {
 	return hashes, nil
 }
 
-func (bto *bencodeTorrent) toTorrentFile() (TorrentFile, error) {
-	infoHash, err := bto.Info.hash()
-	if err != nil {
-		return TorrentFile{}, err
-	}
+func (bto *bencodeTorrent) toTorrentFile(infoHash [20]byte) (TorrentFile, error) {
 	pieceHashes, err := bto.Info.splitPieceHashes()
 	if err != nil {
 		return TorrentFile{}, err
 	}
+	files, length := bto.Info.files()
 	t := TorrentFile{
 		Announce:    bto.Announce,
 		InfoHash:    infoHash,
 		PieceHashes: pieceHashes,
 		PieceLength: bto.Info.PieceLength,
-		Length:      bto.Info.Length,
+		Length:      length,
 		Name:        bto.Info.Name,
+		Files:       files,
+		Trackers:    bto.trackers(),
+		Private:     bto.Info.Private == 1,
 	}
 	return t, nil
 }
 
+func (bto *bencodeTorrent) trackers() []string {
+	trackers := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(tracker string) {
+		if tracker = strings.TrimSpace(tracker); tracker != "" && !seen[tracker] {
+			seen[tracker] = true
+			trackers = append(trackers, tracker)
+		}
+	}
+	add(bto.Announce)
+	for _, tier := range bto.AnnounceList {
+		for _, tracker := range tier {
+			add(tracker)
+		}
+	}
+	return trackers
+}
+
+// webSeeds reads the url-list, it is one url or a list of them, so it
+// can`t be a field of bencodeTorrent.
+func webSeeds(torrent map[string]interface{}) []string {
+	webSeeds := make([]string, 0)
+	switch urls := torrent["url-list"].(type) {
+	case string:
+		if urls != "" {
+			webSeeds = append(webSeeds, urls)
+		}
+	case []interface{}:
+		for _, item := range urls {
+			if url, ok := item.(string); ok && url != "" {
+				webSeeds = append(webSeeds, url)
+			}
+		}
+	}
+	return webSeeds
+}
+
 
@@ -0,0 +1,98 @@
+package decode
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const btihPrefix = "urn:btih:"
+
+// Magnet is what a magnet link tells about a torrent. The pieces and the
+// files are only in the metadata, which the link doesn`t have.
+type Magnet struct {
+	InfoHash [20]byte
+	Name     string
+	Length   int
+	Trackers []string
+	WebSeeds []string
+}
+
+// ParseMagnet reads a magnet link with a BitTorrent info hash, in hex or
+// in base32.
+func ParseMagnet(link string) (Magnet, error) {
+	uri, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return Magnet{}, err
+	}
+
+	if uri.Scheme != "magnet" {
+		return Magnet{}, fmt.Errorf("link isn`t a magnet")
+	}
+
+	params, err := url.ParseQuery(uri.RawQuery)
+	if err != nil {
+		return Magnet{}, err
+	}
+
+	magnet := Magnet{
+		Name:     params.Get("dn"),
+		Trackers: params["tr"],
+		WebSeeds: params["ws"],
+	}
+
+	isFound := false
+
+	for _, xt := range params["xt"] {
+		if !strings.HasPrefix(strings.ToLower(xt), btihPrefix) {
+			continue
+		}
+
+		if magnet.InfoHash, err = parseInfoHash(xt[len(btihPrefix):]); err != nil {
+			return Magnet{}, err
+		}
+
+		isFound = true
+		break
+	}
+
+	if !isFound {
+		return Magnet{}, fmt.Errorf("magnet has no btih info hash")
+	}
+
+	if xl := params.Get("xl"); xl != "" {
+		if magnet.Length, err = strconv.Atoi(xl); err != nil {
+			return Magnet{}, fmt.Errorf("invalid magnet length %q", xl)
+		}
+	}
+
+	return magnet, nil
+}
+
+func parseInfoHash(value string) ([20]byte, error) {
+	var (
+		infoHash [20]byte
+		decoded  []byte
+		err      error
+	)
+
+	switch len(value) {
+	case 40:
+		decoded, err = hex.DecodeString(value)
+	case 32:
+		decoded, err = base32.StdEncoding.DecodeString(strings.ToUpper(value))
+	default:
+		err = fmt.Errorf("invalid info hash %q", value)
+	}
+
+	if err != nil {
+		return infoHash, err
+	}
+
+	copy(infoHash[:], decoded)
+
+	return infoHash, nil
+}
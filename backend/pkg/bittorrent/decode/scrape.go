@@ -0,0 +1,191 @@
+package decode
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackpal/bencode-go"
+)
+
+const (
+	scrapeTimeout = 15 * time.Second
+
+	udpProtocolId   = 0x41727101980
+	udpActionConn   = 0
+	udpActionScrape = 2
+	udpActionError  = 3
+)
+
+type Scrape struct {
+	Seeders   int
+	Leechers  int
+	Completed int
+}
+
+func (t *TorrentFile) Scrape() (Scrape, error) {
+	base, err := url.Parse(t.Announce)
+	if err != nil {
+		return Scrape{}, err
+	}
+
+	switch base.Scheme {
+
+	case "http", "https":
+		return t.scrapeHTTP(base)
+
+	case "udp":
+		return t.scrapeUDP(base)
+
+	default:
+		return Scrape{}, fmt.Errorf("unsupported announce scheme %q", base.Scheme)
+
+	}
+}
+
+func (t *TorrentFile) scrapeHTTP(base *url.URL) (Scrape, error) {
+	index := strings.LastIndex(base.Path, "/")
+	if index < 0 || !strings.HasPrefix(base.Path[index+1:], "announce") {
+		return Scrape{}, fmt.Errorf("tracker doesn`t support scrape")
+	}
+
+	base.Path = base.Path[:index+1] + "scrape" + strings.TrimPrefix(base.Path[index+1:], "announce")
+
+	params := base.Query()
+	params.Set("info_hash", string(t.InfoHash[:]))
+	base.RawQuery = params.Encode()
+
+	client := http.Client{Timeout: scrapeTimeout}
+
+	res, err := client.Get(base.String())
+	if err != nil {
+		return Scrape{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Scrape{}, fmt.Errorf("tracker responded with status %d", res.StatusCode)
+	}
+
+	decoded, err := bencode.Decode(res.Body)
+	if err != nil {
+		return Scrape{}, err
+	}
+
+	body, ok := decoded.(map[string]interface{})
+	if !ok {
+		return Scrape{}, fmt.Errorf("received malformed scrape response")
+	}
+
+	if reason, ok := body["failure reason"].(string); ok {
+		return Scrape{}, fmt.Errorf("tracker failure: %s", reason)
+	}
+
+	files, ok := body["files"].(map[string]interface{})
+	if !ok {
+		return Scrape{}, fmt.Errorf("received malformed scrape response")
+	}
+
+	stats, ok := files[string(t.InfoHash[:])].(map[string]interface{})
+	if !ok {
+		return Scrape{}, fmt.Errorf("tracker doesn`t know this torrent")
+	}
+
+	return Scrape{
+		Seeders:   bencodeInt(stats["complete"]),
+		Leechers:  bencodeInt(stats["incomplete"]),
+		Completed: bencodeInt(stats["downloaded"]),
+	}, nil
+}
+
+func (t *TorrentFile) scrapeUDP(base *url.URL) (Scrape, error) {
+	conn, err := net.DialTimeout("udp", base.Host, scrapeTimeout)
+	if err != nil {
+		return Scrape{}, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(scrapeTimeout))
+
+	connReq := make([]byte, 16)
+	binary.BigEndian.PutUint64(connReq[0:8], udpProtocolId)
+	binary.BigEndian.PutUint32(connReq[8:12], udpActionConn)
+
+	connRes, err := udpRoundTrip(conn, connReq, udpActionConn, 16)
+	if err != nil {
+		return Scrape{}, err
+	}
+
+	scrapeReq := make([]byte, 36)
+	copy(scrapeReq[0:8], connRes[8:16])
+	binary.BigEndian.PutUint32(scrapeReq[8:12], udpActionScrape)
+	copy(scrapeReq[16:], t.InfoHash[:])
+
+	scrapeRes, err := udpRoundTrip(conn, scrapeReq, udpActionScrape, 20)
+	if err != nil {
+		return Scrape{}, err
+	}
+
+	return Scrape{
+		Seeders:   int(binary.BigEndian.Uint32(scrapeRes[8:12])),
+		Completed: int(binary.BigEndian.Uint32(scrapeRes[12:16])),
+		Leechers:  int(binary.BigEndian.Uint32(scrapeRes[16:20])),
+	}, nil
+}
+
+func udpRoundTrip(conn net.Conn, req []byte, action uint32, minSize int) ([]byte, error) {
+	transactionId := make([]byte, 4)
+	if _, err := rand.Read(transactionId); err != nil {
+		return nil, err
+	}
+
+	copy(req[12:16], transactionId)
+
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	res := make([]byte, 2048)
+
+	n, err := conn.Read(res)
+	if err != nil {
+		return nil, err
+	}
+
+	res = res[:n]
+
+	if n < 8 || !bytes.Equal(res[4:8], transactionId) {
+		return nil, fmt.Errorf("received malformed udp tracker response")
+	}
+
+	if binary.BigEndian.Uint32(res[0:4]) == udpActionError {
+		return nil, fmt.Errorf("tracker failure: %s", string(res[8:]))
+	}
+
+	if binary.BigEndian.Uint32(res[0:4]) != action || n < minSize {
+		return nil, fmt.Errorf("received malformed udp tracker response")
+	}
+
+	return res, nil
+}
+
+func bencodeInt(value interface{}) int {
+	switch number := value.(type) {
+
+	case int64:
+		return int(number)
+
+	case uint64:
+		return int(number)
+
+	default:
+		return 0
+
+	}
+}
@@ -0,0 +1,92 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket measured in bytes per second. A zero rate means
+// that the limiter lets everything through.
+type Limiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func New(rate int) *Limiter {
+	return &Limiter{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+func (l *Limiter) Rate() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return int(l.rate)
+}
+
+func (l *Limiter) SetRate(rate int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.refill(time.Now())
+
+	l.rate = float64(rate)
+
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+}
+
+// WaitN takes n tokens from the bucket and blocks until the debt is paid off.
+func (l *Limiter) WaitN(n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.mutex.Lock()
+
+	if l.rate <= 0 {
+		l.mutex.Unlock()
+		return
+	}
+
+	now := time.Now()
+
+	l.refill(now)
+
+	l.tokens -= float64(n)
+
+	var wait time.Duration
+
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+
+	l.mutex.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+
+	l.last = now
+
+	if l.rate <= 0 {
+		l.tokens = 0
+		return
+	}
+
+	l.tokens += elapsed * l.rate
+
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+}
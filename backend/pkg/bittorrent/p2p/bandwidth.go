@@ -0,0 +1,237 @@
+package p2p
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/limiter"
+)
+
+// Limits caps the whole service, every torrent and every peer connection.
+// Peer is applied to each connection separately, so a peer that is dialed
+// twice gets the rate twice.
+type Limits struct {
+	Global  int `yaml:"global" json:"global"`
+	Torrent int `yaml:"torrent" json:"torrent"`
+	Peer    int `yaml:"peer" json:"peer"`
+}
+
+// BandwidthConfig holds rates in bytes per second, zero means unlimited.
+type BandwidthConfig struct {
+	Download Limits `yaml:"download"`
+	Upload   Limits `yaml:"upload"`
+}
+
+type limiters struct {
+	down *limiter.Limiter
+	up   *limiter.Limiter
+}
+
+// torrentLimiters is shared by the open connections of a torrent and is
+// dropped when the last of them is closed.
+type torrentLimiters struct {
+	limiters
+	conns int
+}
+
+type bandwidthState struct {
+	mutex    sync.Mutex
+	config   BandwidthConfig
+	global   limiters
+	torrents map[[20]byte]*torrentLimiters
+	conns    map[*limiters]struct{}
+}
+
+var bandwidth = &bandwidthState{
+	global: limiters{
+		down: limiter.New(0),
+		up:   limiter.New(0),
+	},
+	torrents: make(map[[20]byte]*torrentLimiters),
+	conns:    make(map[*limiters]struct{}),
+}
+
+func SetBandwidth(cfg BandwidthConfig) {
+	bandwidth.mutex.Lock()
+	defer bandwidth.mutex.Unlock()
+
+	bandwidth.config = cfg
+
+	bandwidth.global.down.SetRate(cfg.Download.Global)
+	bandwidth.global.up.SetRate(cfg.Upload.Global)
+
+	for _, torrent := range bandwidth.torrents {
+		torrent.down.SetRate(cfg.Download.Torrent)
+		torrent.up.SetRate(cfg.Upload.Torrent)
+	}
+
+	for conn := range bandwidth.conns {
+		conn.down.SetRate(cfg.Download.Peer)
+		conn.up.SetRate(cfg.Upload.Peer)
+	}
+}
+
+func GetBandwidth() BandwidthConfig {
+	bandwidth.mutex.Lock()
+	defer bandwidth.mutex.Unlock()
+
+	return bandwidth.config
+}
+
+// limitedConn throttles a peer connection. The time it sleeps for the
+// limiters doesn`t count against the deadlines set by the caller, they are
+// moved forward by the same amount.
+type limitedConn struct {
+	net.Conn
+	infoHash [20]byte
+	levels   []*limiters
+	own      *limiters
+	closing  sync.Once
+
+	mutex         sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+	waited        time.Duration
+}
+
+func limitConn(conn net.Conn, infoHash [20]byte) *limitedConn {
+	bandwidth.mutex.Lock()
+	defer bandwidth.mutex.Unlock()
+
+	torrent, isFound := bandwidth.torrents[infoHash]
+
+	if !isFound {
+		torrent = &torrentLimiters{
+			limiters: limiters{
+				down: limiter.New(bandwidth.config.Download.Torrent),
+				up:   limiter.New(bandwidth.config.Upload.Torrent),
+			},
+		}
+
+		bandwidth.torrents[infoHash] = torrent
+	}
+
+	torrent.conns++
+
+	own := &limiters{
+		down: limiter.New(bandwidth.config.Download.Peer),
+		up:   limiter.New(bandwidth.config.Upload.Peer),
+	}
+
+	bandwidth.conns[own] = struct{}{}
+
+	return &limitedConn{
+		Conn:     conn,
+		infoHash: infoHash,
+		levels:   []*limiters{&bandwidth.global, &torrent.limiters, own},
+		own:      own,
+	}
+}
+
+func (c *limitedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+
+	start := time.Now()
+
+	for _, level := range c.levels {
+		level.down.WaitN(n)
+	}
+
+	waited := time.Since(start)
+
+	c.mutex.Lock()
+	c.waited += waited
+	c.readDeadline = c.extend(c.readDeadline, waited, c.Conn.SetReadDeadline)
+	c.mutex.Unlock()
+
+	return n, err
+}
+
+func (c *limitedConn) Write(b []byte) (int, error) {
+	start := time.Now()
+
+	for _, level := range c.levels {
+		level.up.WaitN(len(b))
+	}
+
+	waited := time.Since(start)
+
+	c.mutex.Lock()
+	c.waited += waited
+	c.writeDeadline = c.extend(c.writeDeadline, waited, c.Conn.SetWriteDeadline)
+	c.mutex.Unlock()
+
+	return c.Conn.Write(b)
+}
+
+func (c *limitedConn) SetDeadline(t time.Time) error {
+	c.mutex.Lock()
+	c.readDeadline = t
+	c.writeDeadline = t
+	c.mutex.Unlock()
+
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *limitedConn) SetReadDeadline(t time.Time) error {
+	c.mutex.Lock()
+	c.readDeadline = t
+	c.mutex.Unlock()
+
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *limitedConn) SetWriteDeadline(t time.Time) error {
+	c.mutex.Lock()
+	c.writeDeadline = t
+	c.mutex.Unlock()
+
+	return c.Conn.SetWriteDeadline(t)
+}
+
+// Waited is the whole time the connection has slept for the limiters, the
+// callers that keep their own timers move them by it.
+func (c *limitedConn) Waited() time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.waited
+}
+
+// extend moves an armed deadline forward by the time spent waiting for the
+// limiters.
+func (c *limitedConn) extend(deadline time.Time, waited time.Duration, set func(time.Time) error) time.Time {
+	if deadline.IsZero() || waited <= time.Millisecond {
+		return deadline
+	}
+
+	deadline = deadline.Add(waited)
+
+	set(deadline)
+
+	return deadline
+}
+
+func (c *limitedConn) Close() error {
+	c.closing.Do(func() {
+		bandwidth.mutex.Lock()
+		defer bandwidth.mutex.Unlock()
+
+		delete(bandwidth.conns, c.own)
+
+		torrent, isFound := bandwidth.torrents[c.infoHash]
+
+		if !isFound {
+			return
+		}
+
+		torrent.conns--
+
+		if torrent.conns <= 0 {
+			delete(bandwidth.torrents, c.infoHash)
+		}
+	})
+
+	return c.Conn.Close()
+}
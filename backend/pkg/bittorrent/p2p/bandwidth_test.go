@@ -0,0 +1,45 @@
+package p2p
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLimitedConnCountsWaits(t *testing.T) {
+	SetBandwidth(BandwidthConfig{Download: Limits{Peer: 1000}})
+	defer SetBandwidth(BandwidthConfig{})
+
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	conn := limitConn(local, [20]byte{1})
+	defer conn.Close()
+
+	go remote.Write(make([]byte, 1300))
+
+	deadline := time.Now().Add(time.Second)
+
+	conn.SetReadDeadline(deadline)
+
+	// The bucket starts with a second of tokens, the rest is paid off in
+	// about 300ms.
+	if _, err := io.ReadFull(conn, make([]byte, 1300)); err != nil {
+		t.Fatal(err)
+	}
+
+	waited := conn.Waited()
+
+	if waited < 200*time.Millisecond {
+		t.Fatalf("waited %v, want about 300ms", waited)
+	}
+
+	conn.mutex.Lock()
+	moved := conn.readDeadline.Sub(deadline)
+	conn.mutex.Unlock()
+
+	if moved < 200*time.Millisecond {
+		t.Errorf("read deadline moved by %v, want the %v spent waiting", moved, waited)
+	}
+}
@@ -0,0 +1,520 @@
+package p2p
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+)
+
+const (
+	endgamePeers    = 4
+	endgameDeadline = 20 * time.Second
+	endgameBlock    = 4096
+
+	// Requests a peer has in flight at once.
+	endgameBacklog = 16
+
+	// Once this few blocks are missing they are requested from every
+	// peer, the first copy wins.
+	endgameTail = 8
+)
+
+// endgame splits the blocks of a piece between several peers, every block
+// is requested from one peer only. The last endgameTail blocks are
+// requested from all of them, the first copy of a block wins and the other
+// peers get a Cancel for it.
+type endgame struct {
+	mutex      sync.Mutex
+	infoHash   [20]byte
+	piece      Piece
+	buff       []byte
+	received   []bool
+	requested  []int
+	senders    []decode.Peer
+	left       int
+	downloaded int
+	clients    []*Client
+	inflight   map[*Client]map[int]bool
+	done       chan struct{}
+}
+
+// DownloadUrgent is used for the piece the viewer is waiting on right now,
+// so a single slow peer can`t hold the playback for the whole deadline.
+// Other pieces should use Download, it keeps to one peer.
+func DownloadUrgent(t decode.Torrent, index int) (Piece, error) {
+	if index < 0 || index >= len(t.PieceHashes) {
+		return Piece{}, fmt.Errorf("index must be in range [0..%d)", len(t.PieceHashes))
+	}
+
+	pic := Piece{
+		begin: index * t.PieceLength,
+		end:   index*t.PieceLength + t.PieceLength,
+		hash:  t.PieceHashes[index],
+		index: index,
+	}
+
+	if pic.end > t.Length {
+		pic.end = t.Length
+	}
+
+	size := pic.end - pic.begin
+	blocks := (size + endgameBlock - 1) / endgameBlock
+
+	g := &endgame{
+		infoHash:  t.InfoHash,
+		piece:     pic,
+		buff:      make([]byte, size),
+		received:  make([]bool, blocks),
+		requested: make([]int, blocks),
+		senders:   make([]decode.Peer, blocks),
+		left:      blocks,
+		inflight:  make(map[*Client]map[int]bool),
+		done:      make(chan struct{}),
+	}
+
+	swarm := getSwarm(t.InfoHash)
+
+	clients := g.connect(t, swarm)
+	if len(clients) == 0 {
+		g.emit(PieceFailEvent, 0, "")
+		return Piece{}, fmt.Errorf("cannot download piece number %d because no one peer has it", index+1)
+	}
+
+	started := time.Now()
+
+	var workers sync.WaitGroup
+
+	failed := make(chan struct{}, len(clients))
+
+	for _, c := range clients {
+		workers.Add(1)
+
+		go func(c *Client) {
+			defer workers.Done()
+
+			if err := g.run(c); err != nil {
+				failed <- struct{}{}
+			}
+		}(c)
+	}
+
+	timer := time.NewTimer(endgameDeadline)
+	defer timer.Stop()
+
+	failures := 0
+
+wait:
+	for {
+		select {
+
+		case <-g.done:
+			break wait
+
+		case <-failed:
+			failures++
+
+			if failures == len(clients) {
+				break wait
+			}
+
+		case <-timer.C:
+			break wait
+
+		}
+	}
+
+	for _, c := range clients {
+		c.Close()
+	}
+
+	workers.Wait()
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.left != 0 {
+		for _, c := range clients {
+			swarm.recordFailure(c.Peer)
+		}
+
+		g.emit(PieceFailEvent, 0, "")
+
+		return Piece{}, fmt.Errorf("cannot download piece number %d in time", index+1)
+	}
+
+	contributed := make(map[string]int)
+
+	for i, peer := range g.senders {
+		contributed[peer.String()] += blockLength(size, i)
+	}
+
+	if sha1.Sum(g.buff) != pic.hash {
+		// Only a peer that sent the whole piece is surely the bad one.
+		suspects := make([]decode.Peer, 0, len(clients))
+
+		for _, c := range clients {
+			if contributed[c.Peer.String()] > 0 {
+				suspects = append(suspects, c.Peer)
+			}
+		}
+
+		if len(suspects) == 1 {
+			swarm.recordHashFailure(suspects[0])
+		} else {
+			swarm.recordSuspects(suspects)
+		}
+
+		g.emit(PieceFailEvent, 0, "")
+
+		return Piece{}, fmt.Errorf("piece number %d failed hash check", index+1)
+	}
+
+	took := time.Since(started)
+
+	for _, c := range clients {
+		if bytes := contributed[c.Peer.String()]; bytes > 0 {
+			swarm.recordPiece(c.Peer, bytes, took)
+		}
+	}
+
+	g.emit(PieceEvent, 0, "")
+
+	return Piece{
+		Buff:  g.buff,
+		index: pic.index,
+	}, nil
+}
+
+// connect dials the best ranked peers in parallel and keeps the ones that
+// have the piece.
+func (g *endgame) connect(t decode.Torrent, swarm *swarm) []*Client {
+	ranked := swarm.rank(t.Peers)
+
+	if len(ranked) > endgamePeers*2 {
+		ranked = ranked[:endgamePeers*2]
+	}
+
+	var (
+		mutex   sync.Mutex
+		dialers sync.WaitGroup
+		clients []*Client
+	)
+
+	for _, peer := range ranked {
+		dialers.Add(1)
+
+		go func(peer decode.Peer) {
+			defer dialers.Done()
+
+			dialed := time.Now()
+
+			c, err := NewClient(t.InfoHash, t.PeerID, peer)
+			if err != nil {
+				swarm.recordFailure(peer)
+				return
+			}
+
+			swarm.recordLatency(peer, time.Since(dialed))
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if !c.bt_field.Has(g.piece.index) || len(clients) >= endgamePeers {
+				c.Close()
+				return
+			}
+
+			clients = append(clients, c)
+		}(peer)
+	}
+
+	dialers.Wait()
+
+	g.clients = clients
+
+	return clients
+}
+
+func (g *endgame) run(c *Client) error {
+	defer g.release(c)
+
+	c.conn.SetDeadline(time.Now().Add(endgameDeadline))
+
+	if err := c.SendUnchoke(); err != nil {
+		return err
+	}
+
+	if err := c.SendInterested(); err != nil {
+		return err
+	}
+
+	if !c.Choked {
+		if err := g.activate(c); err != nil {
+			return err
+		}
+	}
+
+	for {
+		msg, err := c.Read()
+		if err != nil {
+			return err
+		}
+
+		switch msg.ID {
+
+		case Choke:
+			c.Choked = true
+			g.release(c)
+
+		case Unchoke:
+			c.Choked = false
+
+			if err := g.activate(c); err != nil {
+				return err
+			}
+
+		case Have:
+			index, err := ParseHave(msg)
+			if err != nil {
+				return err
+			}
+
+			c.bt_field.Set(index)
+
+		case Pic:
+			if err := g.receive(c, msg); err != nil {
+				return err
+			}
+
+		}
+	}
+}
+
+// activate lets the peer take blocks, it is called once the peer unchoked
+// us.
+func (g *endgame) activate(c *Client) error {
+	g.mutex.Lock()
+
+	if g.inflight[c] == nil {
+		g.inflight[c] = make(map[int]bool)
+	}
+
+	g.mutex.Unlock()
+
+	return g.fill(c)
+}
+
+// release gives the blocks in flight on the peer back to the others, it is
+// called when the peer chokes us or goes away.
+func (g *endgame) release(c *Client) {
+	g.mutex.Lock()
+
+	blocks, isActive := g.inflight[c]
+
+	for block := range blocks {
+		g.requested[block]--
+	}
+
+	delete(g.inflight, c)
+
+	others := g.active()
+
+	g.mutex.Unlock()
+
+	if !isActive || len(blocks) == 0 {
+		return
+	}
+
+	for _, other := range others {
+		g.fill(other)
+	}
+}
+
+// fill tops up the requests in flight on the peer.
+func (g *endgame) fill(c *Client) error {
+	g.mutex.Lock()
+
+	blocks := g.next(c)
+
+	g.mutex.Unlock()
+
+	size := len(g.buff)
+
+	for _, block := range blocks {
+		err := c.sendRequest(g.piece.index, block*endgameBlock, blockLength(size, block))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// next picks the blocks to request from the peer and marks them in flight.
+// A block nobody was asked for goes first, the missing blocks of the tail
+// are duplicated. The caller holds the mutex.
+func (g *endgame) next(c *Client) []int {
+	inflight, isActive := g.inflight[c]
+	if !isActive {
+		return nil
+	}
+
+	blocks := make([]int, 0)
+
+	take := func(block int) {
+		inflight[block] = true
+		g.requested[block]++
+		blocks = append(blocks, block)
+	}
+
+	for block, isReceived := range g.received {
+		if len(inflight) >= endgameBacklog {
+			break
+		}
+
+		if !isReceived && g.requested[block] == 0 {
+			take(block)
+		}
+	}
+
+	if g.left > endgameTail {
+		return blocks
+	}
+
+	for block, isReceived := range g.received {
+		if !isReceived && !inflight[block] {
+			take(block)
+		}
+	}
+
+	return blocks
+}
+
+// active returns the peers that can take blocks, the caller holds the
+// mutex.
+func (g *endgame) active() []*Client {
+	clients := make([]*Client, 0, len(g.inflight))
+
+	for c := range g.inflight {
+		clients = append(clients, c)
+	}
+
+	return clients
+}
+
+func (g *endgame) receive(c *Client, msg MSG) error {
+	if len(msg.Payload) < 8 {
+		return fmt.Errorf("msg payload too short")
+	}
+
+	index := int(binary.BigEndian.Uint32(msg.Payload[:4]))
+	begin := int(binary.BigEndian.Uint32(msg.Payload[4:8]))
+	data := msg.Payload[8:]
+
+	if index != g.piece.index {
+		return nil
+	}
+
+	block := begin / endgameBlock
+
+	if begin%endgameBlock != 0 || block >= len(g.received) || len(data) != blockLength(len(g.buff), block) {
+		return fmt.Errorf("received malformed block")
+	}
+
+	g.mutex.Lock()
+
+	if g.received[block] {
+		g.mutex.Unlock()
+		return nil
+	}
+
+	copy(g.buff[begin:], data)
+
+	g.received[block] = true
+	g.senders[block] = c.Peer
+	g.left--
+	g.downloaded += len(data)
+
+	g.emit(BlockEvent, len(data), c.Peer.String())
+
+	if g.left == 0 {
+		close(g.done)
+	}
+
+	// Only the tail is requested from several peers, the others get a
+	// Cancel for the copy they won`t have to send.
+	duplicates := make([]*Client, 0)
+
+	for other, inflight := range g.inflight {
+		if !inflight[block] {
+			continue
+		}
+
+		delete(inflight, block)
+		g.requested[block]--
+
+		if other != c {
+			duplicates = append(duplicates, other)
+		}
+	}
+
+	var refill []*Client
+
+	switch {
+
+	case g.left == 0:
+
+	case g.left <= endgameTail:
+		refill = g.active()
+
+	default:
+		refill = []*Client{c}
+
+	}
+
+	g.mutex.Unlock()
+
+	for _, other := range duplicates {
+		other.SendCancel(index, begin, len(data))
+	}
+
+	for _, other := range refill {
+		if other == c {
+			if err := g.fill(c); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		g.fill(other)
+	}
+
+	return nil
+}
+
+// emit reports the state of the piece, the caller holds the mutex or is the
+// only one left using the endgame.
+func (g *endgame) emit(eventType EventType, bytes int, peer string) {
+	emit(Event{
+		Type:       eventType,
+		InfoHash:   g.infoHash,
+		Piece:      g.piece.index,
+		Bytes:      bytes,
+		Downloaded: g.downloaded,
+		Size:       len(g.buff),
+		Peer:       peer,
+	})
+}
+
+func blockLength(size int, block int) int {
+	if (block+1)*endgameBlock > size {
+		return size - block*endgameBlock
+	}
+
+	return endgameBlock
+}
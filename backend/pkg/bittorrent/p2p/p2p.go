@@ -9,6 +9,9 @@ import (
 	"io"
 	"fmt"
 	"bytes"
+	"errors"
+	"crypto/sha1"
+	"sync"
 	// "github.com/schollz/progressbar/v3"
 	// "os/exec"
 	// "os"
@@ -26,6 +29,10 @@ const (
 	Cancel
 )
 
+const KeepAlive = -1;
+
+var errSnubbed = errors.New("peer stopped sending data");
+
 type Piece struct {
 	begin 	int
 	end 	int
@@ -57,13 +64,15 @@ type Client struct {
 	Peer 		decode.Peer
 	bt_field	bt.BT
 	Choked		bool
+	closing		sync.Once
 }
 
 func NewClient(infoHash, PeerID [20]byte, Peer decode.Peer) (*Client, error) {
-	conn, err := net.DialTimeout("tcp", Peer.String(), 3 * time.Second);
+	raw, err := net.DialTimeout("tcp", Peer.String(), 3 * time.Second);
 	if err != nil {
 		return nil, err;
 	}
+	conn := limitConn(raw, infoHash);
 	_, err = HandShake(infoHash, PeerID, conn);
 	if err != nil {
 		conn.Close();
@@ -74,6 +83,11 @@ func NewClient(infoHash, PeerID [20]byte, Peer decode.Peer) (*Client, error) {
 		conn.Close();
 		return nil, err;
 	}
+	emit(Event {
+		Type: ConnectEvent,
+		InfoHash: infoHash,
+		Peer: Peer.String(),
+	});
 	return &Client {
 		Choked: true,
 		InfoHash: infoHash,
@@ -84,6 +98,17 @@ func NewClient(infoHash, PeerID [20]byte, Peer decode.Peer) (*Client, error) {
 	}, nil;
 }
 
+func (c *Client) Close() error {
+	c.closing.Do(func() {
+		emit(Event {
+			Type: DisconnectEvent,
+			InfoHash: c.InfoHash,
+			Peer: c.Peer.String(),
+		});
+	});
+	return c.conn.Close();
+}
+
 func RecvBT(conn net.Conn) (bt.BT, error) {
 	conn.SetDeadline(time.Now().Add(5 * time.Second));
 	defer conn.SetDeadline(time.Time{});
@@ -154,7 +179,9 @@ func ReadMSG(r io.Reader) (MSG, error) {
 	}
 	sz := binary.BigEndian.Uint32(buff_len[:]);
 	if sz == 0 {
-		return MSG{}, fmt.Errorf("received 0 bytes");
+		return MSG {
+			ID: KeepAlive,
+		}, nil;
 	}
 	buff := make([]byte, sz);
 	_, err = io.ReadFull(r, buff);
@@ -212,10 +239,10 @@ func (c *Client) SendInterested() error {
 }
 
 func ParseHave(msg MSG) (int, error) {
-	if len(msg.Payload) <= 0 {
-		return -1, fmt.Errorf("msg size == 0");
+	if len(msg.Payload) < 4 {
+		return -1, fmt.Errorf("msg payload too short");
 	}
-	index := binary.BigEndian.Uint32(msg.Payload[1:]);
+	index := binary.BigEndian.Uint32(msg.Payload[:4]);
 	if index < 0 {
 		return -1, fmt.Errorf("received index < 0");
 	}
@@ -237,11 +264,9 @@ func (p *ProgressInfo) ParsePiece(msg MSG, index int) (int, error) {
 		return -1, fmt.Errorf("begin >= size");
 	}
 	if len(data) + int(begin) > p.size {
-		fmt.Println(int(begin) + len(data), " (<>) ", p.size);
 		return -1, fmt.Errorf("len(data) + begin > size");
 	}
 	_ = copy(p.buff[begin:], data[:]);
-	// fmt.Println("here!!!");
 	return len(data), nil;
 }
 
@@ -275,13 +300,20 @@ func (p *ProgressInfo) Read() error {
 		}
 		p.client.bt_field.Set(index);
 	} else if msg.ID == Pic {
-		_, err := p.ParsePiece(msg, p.index);
+		n, err := p.ParsePiece(msg, p.index);
 		if err != nil {
 			return err;
 		}
 		p.downloaded += p.block_size;
-		fmt.Println("downloaded ", p.downloaded, " of ", p.size);
-		// p.update_progress_bar();
+		emit(Event {
+			Type: BlockEvent,
+			InfoHash: p.client.InfoHash,
+			Piece: p.index,
+			Bytes: n,
+			Downloaded: p.downloaded,
+			Size: p.size,
+			Peer: p.client.Peer.String(),
+		});
 	}
 	return nil;
 }
@@ -316,6 +348,22 @@ func (c *Client) sendRequest(index, begin, size int) error {
 	return nil;
 }
 
+func (c *Client) SendCancel(index, begin, size int) error {
+	payload := make([]byte, 12);
+	binary.BigEndian.PutUint32(payload[:4], uint32(index));
+	binary.BigEndian.PutUint32(payload[4:8], uint32(begin));
+	binary.BigEndian.PutUint32(payload[8:], uint32(size));
+	msg := MSG {
+		ID: Cancel,
+		Payload: payload,
+	};
+	_, err := c.conn.Write(msg.Serialize());
+	if err != nil {
+		return err;
+	}
+	return nil;
+}
+
 func (c *Client) DownloadPiece(pic Piece) ([]byte, error) {
 	var (
 		size = pic.end - pic.begin
@@ -331,8 +379,20 @@ func (c *Client) DownloadPiece(pic Piece) ([]byte, error) {
 		buff: make([]byte, size),
 		// bar: progressbar.Default(int64(size)),
 	};
-	c.conn.SetDeadline(time.Now().Add(20 * time.Second));
+	deadline := time.Now().Add(20 * time.Second);
+	c.conn.SetDeadline(deadline);
 	defer c.conn.SetDeadline(time.Time{});
+	lastData := time.Now();
+	// The time spent in the limiters isn`t the peer`s fault, the timers
+	// are moved by it like the deadlines of the connection.
+	waited := c.waited();
+	skipWaits := func() {
+		if now := c.waited(); now > waited {
+			deadline = deadline.Add(now - waited);
+			lastData = lastData.Add(now - waited);
+			waited = now;
+		}
+	};
 	for ; p.downloaded < size; {
 		if !c.Choked {
 			if p.size - p.requested < block_size {
@@ -344,22 +404,44 @@ func (c *Client) DownloadPiece(pic Piece) ([]byte, error) {
 			}
 			p.requested += p.block_size;
 		}
+		skipWaits();
+		snubAt := lastData.Add(snubTimeout);
+		if snubAt.Before(deadline) {
+			c.conn.SetReadDeadline(snubAt);
+		} else {
+			c.conn.SetReadDeadline(deadline);
+		}
+		downloaded := p.downloaded;
 		err := p.Read();
+		skipWaits();
 		if err != nil {
 			// cmd := exec.Command("clear");
 			// cmd.Stdout = os.Stdout;
 			// cmd.Run();
+			var netErr net.Error;
+			if errors.As(err, &netErr) && netErr.Timeout() && time.Now().Before(deadline) {
+				return nil, errSnubbed;
+			}
 			return nil, err;
 		}
-		
+		if p.downloaded > downloaded {
+			lastData = time.Now();
+		}
 	}
 	
 	return p.buff, nil;
 }
 
+func (c *Client) waited() time.Duration {
+	if conn, isLimited := c.conn.(*limitedConn); isLimited {
+		return conn.Waited();
+	}
+	return 0;
+}
+
 func Download(t decode.Torrent, index int) (Piece, error) {
-	if index < 0 || index > len(t.PieceHashes) {
-		return Piece{}, fmt.Errorf("index must be in range [0..%d]", len(t.PieceHashes));
+	if index < 0 || index >= len(t.PieceHashes) {
+		return Piece{}, fmt.Errorf("index must be in range [0..%d)", len(t.PieceHashes));
 	}
 	pic := Piece {
 		begin: index * t.PieceLength,
@@ -367,31 +449,61 @@ func Download(t decode.Torrent, index int) (Piece, error) {
 		hash: t.PieceHashes[index],
 		index: index,
 	};
-	// fmt.Println(len(t.PieceHashes));
-	for _, peer := range t.Peers {
+	if pic.end > t.Length {
+		pic.end = t.Length;
+	}
+	swarm := getSwarm(t.InfoHash);
+	for _, peer := range swarm.rank(t.Peers) {
+		dialed := time.Now();
 		c, err := NewClient(t.InfoHash, t.PeerID, peer);
 		if err != nil {
-			// return nil, err;
-			fmt.Println(err);
+			swarm.recordFailure(peer);
 			continue;
 		}
-		defer c.conn.Close();
+		swarm.recordLatency(peer, time.Since(dialed));
 		if !c.bt_field.Has(index) {
-			// fmt.Println("continue...");
+			c.Close();
 			continue;
 		}
 		c.SendUnchoke();
 		c.SendInterested();
+		started := time.Now();
 		buff, err := c.DownloadPiece(pic);
 		if err != nil {
-			fmt.Println(err);
+			c.Close();
+			if err == errSnubbed {
+				swarm.recordSnub(peer);
+			} else {
+				swarm.recordFailure(peer);
+			}
+			continue;
+		}
+		if sha1.Sum(buff) != pic.hash {
+			c.Close();
+			swarm.recordHashFailure(peer);
 			continue;
 		}
+		swarm.recordPiece(peer, len(buff), time.Since(started));
 		c.SendHave(pic.index);
+		c.Close();
+		emit(Event {
+			Type: PieceEvent,
+			InfoHash: t.InfoHash,
+			Piece: index,
+			Downloaded: len(buff),
+			Size: len(buff),
+			Peer: peer.String(),
+		});
 		return Piece {
 			Buff: buff,
 			index: pic.index,
 		}, nil;
 	}
+	emit(Event {
+		Type: PieceFailEvent,
+		InfoHash: t.InfoHash,
+		Piece: index,
+		Size: pic.end - pic.begin,
+	});
 	return Piece{}, fmt.Errorf("cannot download piece number %d because no one peer has it", index + 1);
 }
@@ -0,0 +1,268 @@
+package p2p
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+)
+
+const (
+	backoffBase = 5 * time.Second
+	backoffMax  = 30 * time.Minute
+	banDuration = 6 * time.Hour
+	snubTimeout = 8 * time.Second
+
+	// Weight of the newest sample in the moving averages.
+	smoothing = 0.3
+)
+
+type PeerStats struct {
+	Latency      time.Duration
+	Throughput   float64
+	Pieces       int
+	Failures     int
+	HashFailures int
+	Snubbed      bool
+	NextDial     time.Time
+	BannedUntil  time.Time
+}
+
+func (s *PeerStats) IsBanned() bool {
+	return s.BannedUntil.After(time.Now())
+}
+
+func (s *PeerStats) CanDial() bool {
+	return !s.IsBanned() && !s.NextDial.After(time.Now())
+}
+
+// Score is the bytes per second we expect from the peer, lowered by its
+// latency and by failures it had recently.
+func (s *PeerStats) Score() float64 {
+	score := s.Throughput
+
+	if s.Pieces == 0 {
+		score = 1
+	}
+
+	score /= 1 + s.Latency.Seconds()
+	score /= float64(1 + s.Failures)
+
+	if s.Snubbed {
+		score /= 2
+	}
+
+	return score
+}
+
+type swarm struct {
+	mutex    sync.Mutex
+	peers    map[string]*PeerStats
+	suspects map[string]bool
+}
+
+var swarms = struct {
+	mutex  sync.Mutex
+	byHash map[[20]byte]*swarm
+}{
+	byHash: make(map[[20]byte]*swarm),
+}
+
+func getSwarm(infoHash [20]byte) *swarm {
+	swarms.mutex.Lock()
+	defer swarms.mutex.Unlock()
+
+	s, isFound := swarms.byHash[infoHash]
+
+	if !isFound {
+		s = &swarm{
+			peers: make(map[string]*PeerStats),
+		}
+
+		swarms.byHash[infoHash] = s
+	}
+
+	return s
+}
+
+// Peers returns a snapshot of the statistics collected for the torrent.
+func Peers(infoHash [20]byte) map[string]PeerStats {
+	s := getSwarm(infoHash)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := make(map[string]PeerStats, len(s.peers))
+
+	for addr, stats := range s.peers {
+		result[addr] = *stats
+	}
+
+	return result
+}
+
+func (s *swarm) update(peer decode.Peer, f func(stats *PeerStats)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	addr := peer.String()
+
+	stats, isFound := s.peers[addr]
+
+	if !isFound {
+		stats = &PeerStats{}
+		s.peers[addr] = stats
+	}
+
+	f(stats)
+}
+
+func (s *swarm) recordLatency(peer decode.Peer, latency time.Duration) {
+	s.update(peer, func(stats *PeerStats) {
+		if stats.Latency == 0 {
+			stats.Latency = latency
+		} else {
+			stats.Latency = time.Duration(smoothing*float64(latency) + (1-smoothing)*float64(stats.Latency))
+		}
+	})
+}
+
+func (s *swarm) recordPiece(peer decode.Peer, size int, took time.Duration) {
+	s.update(peer, func(stats *PeerStats) {
+		rate := float64(size) / took.Seconds()
+
+		if stats.Pieces == 0 {
+			stats.Throughput = rate
+		} else {
+			stats.Throughput = smoothing*rate + (1-smoothing)*stats.Throughput
+		}
+
+		stats.Pieces++
+		stats.Failures = 0
+		stats.Snubbed = false
+		stats.NextDial = time.Time{}
+	})
+}
+
+func (s *swarm) recordFailure(peer decode.Peer) {
+	s.update(peer, func(stats *PeerStats) {
+		stats.Failures++
+		stats.NextDial = time.Now().Add(backoff(stats.Failures))
+	})
+}
+
+func (s *swarm) recordSnub(peer decode.Peer) {
+	s.update(peer, func(stats *PeerStats) {
+		stats.Snubbed = true
+		stats.Failures++
+		stats.NextDial = time.Now().Add(backoff(stats.Failures))
+	})
+}
+
+func (s *swarm) recordHashFailure(peer decode.Peer) {
+	s.update(peer, func(stats *PeerStats) {
+		stats.HashFailures++
+		stats.BannedUntil = time.Now().Add(banDuration * time.Duration(stats.HashFailures))
+	})
+}
+
+// recordSuspects is called for a piece that failed the hash check with
+// blocks from several peers. Any of them could have sent the bad block, so
+// nobody is banned right away. The suspects of the last such piece are
+// narrowed down by the next one and a peer is banned once it is the only
+// one left.
+func (s *swarm) recordSuspects(peers []decode.Peer) {
+	s.mutex.Lock()
+
+	common := make([]decode.Peer, 0, len(peers))
+
+	for _, peer := range peers {
+		if s.suspects[peer.String()] {
+			common = append(common, peer)
+		}
+	}
+
+	if len(common) == 0 {
+		common = peers
+	}
+
+	s.suspects = make(map[string]bool, len(common))
+
+	if len(common) > 1 {
+		for _, peer := range common {
+			s.suspects[peer.String()] = true
+		}
+	}
+
+	s.mutex.Unlock()
+
+	if len(common) == 1 {
+		s.recordHashFailure(common[0])
+	}
+}
+
+// rank drops banned peers and peers that are still backing off, the rest
+// are ordered from the best score to the worst. When every peer that isn`t
+// banned is backing off, they are all returned, the ones that can be
+// dialed soonest first, so a download isn`t failed just for the backoff.
+func (s *swarm) rank(peers []decode.Peer) []decode.Peer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := make([]decode.Peer, 0, len(peers))
+	waiting := make([]decode.Peer, 0)
+	scores := make(map[string]float64, len(peers))
+	nextDial := make(map[string]time.Time)
+
+	for _, peer := range peers {
+		addr := peer.String()
+
+		stats, isFound := s.peers[addr]
+
+		if !isFound {
+			stats = &PeerStats{}
+		}
+
+		if stats.IsBanned() {
+			continue
+		}
+
+		if !stats.CanDial() {
+			nextDial[addr] = stats.NextDial
+			waiting = append(waiting, peer)
+			continue
+		}
+
+		scores[addr] = stats.Score()
+		result = append(result, peer)
+	}
+
+	if len(result) == 0 {
+		sort.SliceStable(waiting, func(i, j int) bool {
+			return nextDial[waiting[i].String()].Before(nextDial[waiting[j].String()])
+		})
+
+		return waiting
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return scores[result[i].String()] > scores[result[j].String()]
+	})
+
+	return result
+}
+
+func backoff(failures int) time.Duration {
+	wait := backoffBase
+
+	for i := 1; i < failures && wait < backoffMax; i++ {
+		wait *= 2
+	}
+
+	if wait > backoffMax {
+		wait = backoffMax
+	}
+
+	return wait
+}
@@ -0,0 +1,49 @@
+package p2p
+
+import (
+	"net"
+	"testing"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+)
+
+func TestRecordSuspects(t *testing.T) {
+	peer := func(last byte) decode.Peer {
+		return decode.Peer{Ip: net.IPv4(10, 0, 0, last), Port: 6881}
+	}
+
+	bad, first, second, third := peer(1), peer(2), peer(3), peer(4)
+
+	s := &swarm{peers: make(map[string]*PeerStats)}
+
+	isBanned := func(p decode.Peer) bool {
+		stats, isFound := s.peers[p.String()]
+		return isFound && stats.IsBanned()
+	}
+
+	steps := []struct {
+		name   string
+		peers  []decode.Peer
+		banned []decode.Peer
+	}{
+		{"one failure bans nobody", []decode.Peer{bad, first, second}, nil},
+		{"two common peers ban nobody", []decode.Peer{bad, first, third}, nil},
+		{"the only common peer is banned", []decode.Peer{bad, third}, []decode.Peer{bad}},
+	}
+
+	for _, step := range steps {
+		s.recordSuspects(step.peers)
+
+		for _, p := range []decode.Peer{bad, first, second, third} {
+			want := false
+
+			for _, banned := range step.banned {
+				want = want || banned.String() == p.String()
+			}
+
+			if got := isBanned(p); got != want {
+				t.Errorf("%s: %s banned = %v, want %v", step.name, p.String(), got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	BlockEvent      EventType = "block"
+	PieceEvent      EventType = "piece"
+	PieceFailEvent  EventType = "failed"
+	ConnectEvent    EventType = "connect"
+	DisconnectEvent EventType = "disconnect"
+
+	// Events a subscriber can fall behind by, newer ones are dropped then.
+	subscriberBuffer = 256
+)
+
+// Event reports the progress of the downloads of a torrent. Bytes is what
+// the event itself brought, Downloaded is the total of the piece so far.
+type Event struct {
+	Type       EventType
+	InfoHash   [20]byte
+	Piece      int
+	Bytes      int
+	Downloaded int
+	Size       int
+	Peer       string
+	Time       time.Time
+}
+
+var progress = struct {
+	mutex       sync.Mutex
+	subscribers map[[20]byte]map[chan Event]struct{}
+	connected   map[[20]byte]int
+}{
+	subscribers: make(map[[20]byte]map[chan Event]struct{}),
+	connected:   make(map[[20]byte]int),
+}
+
+// Subscribe returns the events of the torrent and the function that stops
+// them. A subscriber that doesn`t keep up loses events instead of slowing
+// the downloads down.
+func Subscribe(infoHash [20]byte) (<-chan Event, func()) {
+	events := make(chan Event, subscriberBuffer)
+
+	progress.mutex.Lock()
+
+	if progress.subscribers[infoHash] == nil {
+		progress.subscribers[infoHash] = make(map[chan Event]struct{})
+	}
+
+	progress.subscribers[infoHash][events] = struct{}{}
+
+	progress.mutex.Unlock()
+
+	var once sync.Once
+
+	unsubscribe := func() {
+		once.Do(func() {
+			progress.mutex.Lock()
+			defer progress.mutex.Unlock()
+
+			delete(progress.subscribers[infoHash], events)
+
+			if len(progress.subscribers[infoHash]) == 0 {
+				delete(progress.subscribers, infoHash)
+			}
+
+			close(events)
+		})
+	}
+
+	return events, unsubscribe
+}
+
+// Connected is the number of peers the torrent has open connections to.
+func Connected(infoHash [20]byte) int {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	return progress.connected[infoHash]
+}
+
+func emit(event Event) {
+	event.Time = time.Now()
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	switch event.Type {
+
+	case ConnectEvent:
+		progress.connected[event.InfoHash]++
+
+	case DisconnectEvent:
+		progress.connected[event.InfoHash]--
+
+		if progress.connected[event.InfoHash] <= 0 {
+			delete(progress.connected, event.InfoHash)
+		}
+
+	}
+
+	for events := range progress.subscribers[event.InfoHash] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps files by their keys. Keys are slash separated paths like
+// "images/1/poster-thumb.jpg", they are the same for every driver.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	DeletePrefix(ctx context.Context, prefix string) error
+	List(ctx context.Context, prefix string) ([]Info, error)
+}
+
+// Info is a blob found by List.
+type Info struct {
+	Key  string
+	Size int64
+}
+
+type Config struct {
+	Driver string   `yaml:"driver" env-default:"file"`
+	Dir    string   `yaml:"dir" env-default:"files"`
+	S3     S3Config `yaml:"s3"`
+}
+
+func New(cfg *Config) (Store, error) {
+	switch cfg.Driver {
+	case "file":
+		store, err := NewFileStore(cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+
+		return store, nil
+	case "s3":
+		store, err := NewS3Store(&cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown blob driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,141 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileStore keeps the blobs in a local directory, a key is a path inside
+// of it.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{
+		dir: dir,
+	}, nil
+}
+
+func (f *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	name, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+// Put writes to a temporary file first, so readers never see a part of
+// the blob.
+func (f *FileStore) Put(ctx context.Context, key string, data []byte) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+		return err
+	}
+
+	tmp := name + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, name)
+}
+
+func (f *FileStore) Delete(ctx context.Context, key string) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// DeletePrefix removes a directory of blobs, the prefix must be a whole
+// directory like "cache/<infohash>".
+func (f *FileStore) DeletePrefix(ctx context.Context, prefix string) error {
+	name, err := f.path(prefix)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(name)
+}
+
+// List walks the directory of the prefix, the prefix must be a whole
+// directory like DeletePrefix. Unfinished writes aren`t listed.
+func (f *FileStore) List(ctx context.Context, prefix string) ([]Info, error) {
+	root, err := f.path(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Info, 0)
+
+	err = filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if entry.IsDir() || strings.HasSuffix(name, ".tmp") {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(f.dir, name)
+		if err != nil {
+			return err
+		}
+
+		result = append(result, Info{
+			Key:  filepath.ToSlash(rel),
+			Size: info.Size(),
+		})
+
+		return nil
+	})
+
+	return result, err
+}
+
+func (f *FileStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+
+	if clean == "/" || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("bad blob key %q", key)
+	}
+
+	return filepath.Join(f.dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,58 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFileList(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	for _, key := range []string{"cache/ab/1", "cache/ab/10", "cache/cd/2", "images/1/poster.jpg"} {
+		if err := store.Put(ctx, key, []byte(key)); err != nil {
+			t.Fatalf("Put %s: %v", key, err)
+		}
+	}
+
+	// A write that was never finished.
+	if err := os.WriteFile(filepath.Join(dir, "cache", "cd", "3.tmp"), []byte("part"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := store.List(ctx, "cache")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Key < items[j].Key
+	})
+
+	got := make([]string, 0, len(items))
+
+	for _, item := range items {
+		got = append(got, fmt.Sprintf("%s:%d", item.Key, item.Size))
+	}
+
+	want := "cache/ab/1:10,cache/ab/10:11,cache/cd/2:10"
+
+	if strings.Join(got, ",") != want {
+		t.Errorf("List = %v, want %s", got, want)
+	}
+
+	if items, err := store.List(ctx, "missing"); err != nil || len(items) != 0 {
+		t.Errorf("List of a missing prefix = %v, %v, want nothing", items, err)
+	}
+}
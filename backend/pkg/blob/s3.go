@@ -0,0 +1,437 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Timeout = time.Minute
+
+	// Multi object delete takes at most that many keys in one request.
+	maxDeleteKeys = 1000
+)
+
+// S3Config is for AWS S3 and for the compatible servers like MinIO. Local
+// servers usually need path style URLs: <endpoint>/<bucket>/<key>.
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region" env-default:"us-east-1"`
+	Bucket    string `yaml:"bucket"`
+	PathStyle bool   `yaml:"pathStyle"`
+	AccessKey string `env:"S3_ACCESS_KEY"`
+	SecretKey string `env:"S3_SECRET_KEY"`
+}
+
+// S3Store keeps the blobs in a bucket. Requests are signed with AWS
+// Signature Version 4.
+type S3Store struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	pathStyle bool
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+type listResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+type deleteRequest struct {
+	XMLName xml.Name `xml:"Delete"`
+	Quiet   bool     `xml:"Quiet"`
+	Objects []struct {
+		Key string `xml:"Key"`
+	} `xml:"Object"`
+}
+
+type deleteResult struct {
+	Errors []s3Error `xml:"Error"`
+}
+
+func NewS3Store(cfg *S3Config) (*S3Store, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint.Scheme == "" || endpoint.Host == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 store needs an endpoint and a bucket")
+	}
+
+	return &S3Store{
+		endpoint:  endpoint,
+		region:    cfg.Region,
+		bucket:    cfg.Bucket,
+		pathStyle: cfg.PathStyle,
+		accessKey: cfg.AccessKey,
+		secretKey: cfg.SecretKey,
+		client: &http.Client{
+			Timeout: s3Timeout,
+		},
+	}, nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, nil, data)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// Delete succeeds for a key that doesn`t exist, like S3 itself.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// DeletePrefix lists the keys under the prefix and removes them with multi
+// object deletes.
+func (s *S3Store) DeletePrefix(ctx context.Context, prefix string) error {
+	return s.list(ctx, prefix, func(items []Info) error {
+		keys := make([]string, 0, len(items))
+
+		for _, item := range items {
+			keys = append(keys, item.Key)
+		}
+
+		for len(keys) != 0 {
+			n := min(len(keys), maxDeleteKeys)
+
+			if err := s.deleteKeys(ctx, keys[:n]); err != nil {
+				return err
+			}
+
+			keys = keys[n:]
+		}
+
+		return nil
+	})
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string) ([]Info, error) {
+	result := make([]Info, 0)
+
+	err := s.list(ctx, prefix, func(items []Info) error {
+		result = append(result, items...)
+		return nil
+	})
+
+	return result, err
+}
+
+// list hands the keys under the prefix to page, one listing page at a
+// time.
+func (s *S3Store) list(ctx context.Context, prefix string, page func(items []Info) error) error {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+
+	token := ""
+
+	for {
+		query := url.Values{
+			"list-type": {"2"},
+			"prefix":    {prefix},
+		}
+
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		var list listResult
+
+		if err := s.doXML(ctx, http.MethodGet, "", query, nil, nil, &list); err != nil {
+			return err
+		}
+
+		items := make([]Info, 0, len(list.Contents))
+
+		for _, item := range list.Contents {
+			items = append(items, Info{
+				Key:  item.Key,
+				Size: item.Size,
+			})
+		}
+
+		if err := page(items); err != nil {
+			return err
+		}
+
+		if !list.IsTruncated {
+			return nil
+		}
+
+		token = list.NextContinuationToken
+	}
+}
+
+func (s *S3Store) deleteKeys(ctx context.Context, keys []string) error {
+	req := deleteRequest{
+		Quiet: true,
+	}
+
+	for _, key := range keys {
+		req.Objects = append(req.Objects, struct {
+			Key string `xml:"Key"`
+		}{key})
+	}
+
+	body, err := xml.Marshal(&req)
+	if err != nil {
+		return err
+	}
+
+	sum := md5.Sum(body)
+
+	header := http.Header{
+		"Content-Md5": {base64.StdEncoding.EncodeToString(sum[:])},
+	}
+
+	var result deleteResult
+
+	if err := s.doXML(ctx, http.MethodPost, "", url.Values{"delete": {""}}, header, body, &result); err != nil {
+		return err
+	}
+
+	if len(result.Errors) != 0 {
+		return fmt.Errorf("s3: can`t delete %d keys: %s", len(result.Errors), result.Errors[0].Code)
+	}
+
+	return nil
+}
+
+func (s *S3Store) doXML(ctx context.Context, method string, key string, query url.Values, header http.Header, body []byte, result interface{}) error {
+	resp, err := s.do(ctx, method, key, query, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return xml.NewDecoder(resp.Body).Decode(result)
+}
+
+// do sends a signed request. Responses with an error status are closed and
+// returned as errors, a missing key is ErrNotFound.
+func (s *S3Store) do(ctx context.Context, method string, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	target := s.url(key, query)
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.URL = target
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	s.sign(req, "s3", hashHex(body), time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && key != "" {
+		return nil, ErrNotFound
+	}
+
+	var s3Err s3Error
+
+	xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&s3Err)
+
+	return nil, fmt.Errorf("s3: %s %s: %d %s %s", method, key, resp.StatusCode, s3Err.Code, s3Err.Message)
+}
+
+func (s *S3Store) url(key string, query url.Values) *url.URL {
+	target := *s.endpoint
+
+	path := "/" + key
+
+	if s.pathStyle {
+		path = "/" + s.bucket
+
+		if key != "" {
+			path += "/" + key
+		}
+	} else {
+		target.Host = s.bucket + "." + target.Host
+	}
+
+	target.Path = strings.TrimSuffix(s.endpoint.Path, "/") + path
+	target.RawPath = escape(strings.TrimSuffix(s.endpoint.Path, "/")+path, false)
+	target.RawQuery = canonicalQuery(query)
+
+	return &target
+}
+
+// sign adds the X-Amz-Date and the Authorization headers. The host and all
+// the headers already set are signed.
+func (s *S3Store) sign(req *http.Request, service string, payloadHash string, now time.Time) {
+	now = now.UTC()
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	if service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(strings.Fields(strings.Join(values, ",")), " ")
+	}
+
+	names := make([]string, 0, len(headers))
+
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.region, service, "aws4_request"}, "/")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := []byte("AWS4" + s.secretKey)
+
+	for _, part := range []string{date, s.region, service, "aws4_request"} {
+		key = hmacSum(key, part)
+	}
+
+	signature := hex.EncodeToString(hmacSum(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+// canonicalQuery is sent as it is signed: sorted and escaped.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var parts []string
+
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, escape(key, true)+"="+escape(value, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// escape is the URI encoding of SigV4, only the unreserved characters of
+// RFC 3986 stay as they are.
+func escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSum(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
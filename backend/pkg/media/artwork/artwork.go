@@ -0,0 +1,117 @@
+package artwork
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// Images larger than this are refused before they are decoded, a small
+	// file can still unpack into gigabytes of pixels.
+	maxPixels = 40_000_000
+
+	quality = 85
+)
+
+var formats = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Size is one of the renditions of an image, Width is the target width, the
+// height keeps the aspect ratio.
+type Size struct {
+	Name  string
+	Width int
+}
+
+type Rendition struct {
+	Size   string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Sniff detects the type from the content, the name and the type the client
+// sent aren`t trusted.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+
+	if !formats[contentType] {
+		return "", fmt.Errorf("unsupported image type %q", contentType)
+	}
+
+	return contentType, nil
+}
+
+// Render decodes the image and encodes it as JPEG in every size. Images are
+// never upscaled, a size wider than the original gets the original width.
+func Render(data []byte, sizes []Size) ([]Rendition, error) {
+	if _, err := Sniff(data); err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("image is %dx%d, it is empty or too large", config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Rendition, 0, len(sizes))
+
+	for _, size := range sizes {
+		img := resize(src, size.Width)
+
+		var buff bytes.Buffer
+
+		if err := jpeg.Encode(&buff, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+
+		result = append(result, Rendition{
+			Size:   size.Name,
+			Width:  img.Bounds().Dx(),
+			Height: img.Bounds().Dy(),
+			Data:   buff.Bytes(),
+		})
+	}
+
+	return result, nil
+}
+
+func resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+
+	if width >= bounds.Dx() {
+		width = bounds.Dx()
+	}
+
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// JPEG has no alpha, transparent parts become white instead of black.
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	return dst
+}
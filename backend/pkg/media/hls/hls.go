@@ -0,0 +1,82 @@
+package hls
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type Segment struct {
+	Duration float64
+	URI      string
+
+	// Offset and Length are written as EXT-X-BYTERANGE when Length isn`t 0.
+	Offset int64
+	Length int64
+}
+
+type Media struct {
+	Init     *Segment
+	Segments []Segment
+}
+
+type Variant struct {
+	Bandwidth int
+	URI       string
+}
+
+func Master(variants []Variant) string {
+	var b strings.Builder
+
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:3\n")
+
+	for _, variant := range variants {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d\n", variant.Bandwidth)
+		b.WriteString(variant.URI + "\n")
+	}
+
+	return b.String()
+}
+
+func (m *Media) String() string {
+	var b strings.Builder
+
+	version := 3
+
+	if m.Init != nil {
+		version = 7
+	} else if len(m.Segments) != 0 && m.Segments[0].Length != 0 {
+		version = 4
+	}
+
+	target := 0.0
+
+	for _, segment := range m.Segments {
+		target = math.Max(target, segment.Duration)
+	}
+
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#EXT-X-VERSION:%d\n", version)
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(target)))
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n")
+	b.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n")
+
+	if m.Init != nil {
+		fmt.Fprintf(&b, "#EXT-X-MAP:URI=%q,BYTERANGE=\"%d@%d\"\n", m.Init.URI, m.Init.Length, m.Init.Offset)
+	}
+
+	for _, segment := range m.Segments {
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n", segment.Duration)
+
+		if segment.Length != 0 {
+			fmt.Fprintf(&b, "#EXT-X-BYTERANGE:%d@%d\n", segment.Length, segment.Offset)
+		}
+
+		b.WriteString(segment.URI + "\n")
+	}
+
+	b.WriteString("#EXT-X-ENDLIST\n")
+
+	return b.String()
+}
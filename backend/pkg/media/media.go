@@ -0,0 +1,75 @@
+package media
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/bittorrent/decode"
+)
+
+type Container string
+
+const (
+	Unknown  Container = ""
+	MPEGTS   Container = "mpegts"
+	MP4      Container = "mp4"
+	Matroska Container = "matroska"
+)
+
+// ReadAt returns length bytes of the media starting at offset. Parsers use
+// it to reach data outside of the pieces they already have.
+type ReadAt func(offset int64, length int) ([]byte, error)
+
+var videoExtensions = []string{".mp4", ".m4v", ".mov", ".mkv", ".webm", ".ts", ".m2ts", ".avi"}
+
+func Detect(head []byte) Container {
+	switch {
+
+	case len(head) > 188 && head[0] == 0x47 && head[188] == 0x47:
+		return MPEGTS
+
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return MP4
+
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return Matroska
+
+	default:
+		return Unknown
+
+	}
+}
+
+func IsVideo(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+
+	for _, videoExt := range videoExtensions {
+		if ext == videoExt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MainVideo picks the biggest video file of the torrent.
+func MainVideo(files []decode.File) (decode.File, bool) {
+	var (
+		main    decode.File
+		isFound bool
+	)
+
+	for _, file := range files {
+		if !IsVideo(file.Path) {
+			continue
+		}
+
+		if !isFound || file.Length > main.Length {
+			main = file
+			isFound = true
+		}
+	}
+
+	return main, isFound
+}
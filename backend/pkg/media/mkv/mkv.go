@@ -0,0 +1,600 @@
+package mkv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media"
+)
+
+const (
+	ebmlID        = 0x1A45DFA3
+	segmentID     = 0x18538067
+	seekHeadID    = 0x114D9B74
+	seekID        = 0x4DBB
+	seekIDID      = 0x53AB
+	seekPosID     = 0x53AC
+	infoID        = 0x1549A966
+	timescaleID   = 0x2AD7B1
+	durationID    = 0x4489
+	tracksID      = 0x1654AE6B
+	trackEntryID  = 0xAE
+	trackNumID    = 0xD7
+	trackTypeID   = 0x83
+	codecID       = 0x86
+	languageID    = 0x22B59C
+	languageBCPID = 0x22B59D
+	nameID        = 0x536E
+	cuesID        = 0x1C53BB6B
+	cuePointID    = 0xBB
+	cueTimeID     = 0xB3
+	cuePosID      = 0xB7
+	cueTrackID    = 0xF7
+	cueClusterID  = 0xF1
+	clusterID     = 0x1F43B675
+
+	// The biggest element header is a 4 byte ID with an 8 byte size.
+	maxHeaderSize = 12
+
+	defaultTimescale = 1000000
+	defaultLanguage  = "eng"
+
+	maxTopLevelElements = 1024
+	maxElementSize      = 1 << 20
+	maxCuesSize         = 16 << 20
+
+	unknownSize = -1
+)
+
+type TrackType string
+
+const (
+	Video    TrackType = "video"
+	Audio    TrackType = "audio"
+	Subtitle TrackType = "subtitle"
+	Other    TrackType = "other"
+)
+
+type Track struct {
+	Number   int
+	Type     TrackType
+	Codec    string
+	Language string
+	Name     string
+}
+
+// Cue is a point playback can start from. Offset is the position of the
+// cluster in the file.
+type Cue struct {
+	Time   float64
+	Offset int64
+}
+
+type Info struct {
+	Duration float64
+	Tracks   []Track
+	Cues     []Cue
+}
+
+type element struct {
+	ID     uint32
+	Offset int64
+	Header int64
+	Size   int64
+}
+
+func (el element) DataOffset() int64 {
+	return el.Offset + el.Header
+}
+
+func (el element) End() int64 {
+	return el.DataOffset() + el.Size
+}
+
+// Probe reads the segment metadata of a Matroska or WebM file. Only the
+// elements before the first cluster and the ones the SeekHead points to are
+// downloaded.
+func Probe(read media.ReadAt, fileSize int64) (*Info, error) {
+	header, err := readElement(read, 0, fileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.ID != ebmlID || header.Size == unknownSize {
+		return nil, fmt.Errorf("file isn`t an EBML document")
+	}
+
+	segment, err := readElement(read, header.End(), fileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if segment.ID != segmentID {
+		return nil, fmt.Errorf("file has no segment")
+	}
+
+	segmentEnd := fileSize
+
+	if segment.Size != unknownSize && segment.End() < fileSize {
+		segmentEnd = segment.End()
+	}
+
+	p := &prober{
+		read:      read,
+		fileSize:  fileSize,
+		segment:   segment.DataOffset(),
+		timescale: defaultTimescale,
+		seeks:     make(map[uint32]int64),
+		parsed:    make(map[uint32]bool),
+	}
+
+	pos := segment.DataOffset()
+
+	for i := 0; i < maxTopLevelElements && pos < segmentEnd; i++ {
+		el, err := readElement(read, pos, fileSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if el.ID == clusterID || el.Size == unknownSize {
+			break
+		}
+
+		if err := p.parse(el); err != nil {
+			return nil, err
+		}
+
+		pos = el.End()
+	}
+
+	for _, id := range []uint32{infoID, tracksID, cuesID} {
+		position, isFound := p.seeks[id]
+
+		if p.parsed[id] || !isFound {
+			continue
+		}
+
+		el, err := readElement(read, p.segment+position, fileSize)
+		if err != nil || el.ID != id {
+			continue
+		}
+
+		if err := p.parse(el); err != nil {
+			return nil, err
+		}
+	}
+
+	if !p.parsed[infoID] {
+		return nil, fmt.Errorf("file has no segment info")
+	}
+
+	p.info.Duration = p.duration * float64(p.timescale) / 1e9
+	p.info.Cues = p.cues()
+
+	return &p.info, nil
+}
+
+type rawCue struct {
+	time     uint64
+	track    int
+	position int64
+}
+
+type prober struct {
+	read      media.ReadAt
+	fileSize  int64
+	segment   int64
+	timescale uint64
+	duration  float64
+	seeks     map[uint32]int64
+	parsed    map[uint32]bool
+	rawCues   []rawCue
+	info      Info
+}
+
+func (p *prober) parse(el element) error {
+	limit := int64(maxElementSize)
+
+	switch el.ID {
+
+	case seekHeadID, infoID, tracksID:
+
+	case cuesID:
+		limit = maxCuesSize
+
+	default:
+		return nil
+
+	}
+
+	if el.Size > limit || el.End() > p.fileSize {
+		return fmt.Errorf("element %X is too big", el.ID)
+	}
+
+	data, err := p.read(el.DataOffset(), int(el.Size))
+	if err != nil {
+		return err
+	}
+
+	p.parsed[el.ID] = true
+
+	switch el.ID {
+
+	case seekHeadID:
+		return children(data, p.parseSeek)
+
+	case infoID:
+		return children(data, p.parseInfo)
+
+	case tracksID:
+		return children(data, p.parseTrack)
+
+	case cuesID:
+		return children(data, p.parseCue)
+
+	}
+
+	return nil
+}
+
+func (p *prober) parseSeek(id uint32, data []byte) error {
+	if id != seekID {
+		return nil
+	}
+
+	var (
+		target   uint32
+		position int64 = -1
+	)
+
+	err := children(data, func(id uint32, data []byte) error {
+		switch id {
+
+		case seekIDID:
+			target = uint32(readUint(data))
+
+		case seekPosID:
+			position = int64(readUint(data))
+
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if position >= 0 {
+		p.seeks[target] = position
+	}
+
+	return nil
+}
+
+func (p *prober) parseInfo(id uint32, data []byte) error {
+	switch id {
+
+	case timescaleID:
+		if scale := readUint(data); scale != 0 {
+			p.timescale = scale
+		}
+
+	case durationID:
+		duration, err := readFloat(data)
+		if err != nil {
+			return err
+		}
+
+		p.duration = duration
+
+	}
+
+	return nil
+}
+
+func (p *prober) parseTrack(id uint32, data []byte) error {
+	if id != trackEntryID {
+		return nil
+	}
+
+	track := Track{
+		Type:     Other,
+		Language: defaultLanguage,
+	}
+
+	hasBCP47 := false
+
+	err := children(data, func(id uint32, data []byte) error {
+		switch id {
+
+		case trackNumID:
+			track.Number = int(readUint(data))
+
+		case trackTypeID:
+			track.Type = trackType(readUint(data))
+
+		case codecID:
+			track.Codec = readString(data)
+
+		case languageID:
+			if !hasBCP47 {
+				track.Language = readString(data)
+			}
+
+		case languageBCPID:
+			track.Language = readString(data)
+			hasBCP47 = true
+
+		case nameID:
+			track.Name = readString(data)
+
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.info.Tracks = append(p.info.Tracks, track)
+
+	return nil
+}
+
+func (p *prober) parseCue(id uint32, data []byte) error {
+	if id != cuePointID {
+		return nil
+	}
+
+	var time uint64
+
+	var positions []rawCue
+
+	err := children(data, func(id uint32, data []byte) error {
+		switch id {
+
+		case cueTimeID:
+			time = readUint(data)
+
+		case cuePosID:
+			cue := rawCue{position: -1}
+
+			err := children(data, func(id uint32, data []byte) error {
+				switch id {
+
+				case cueTrackID:
+					cue.track = int(readUint(data))
+
+				case cueClusterID:
+					cue.position = int64(readUint(data))
+
+				}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if cue.position >= 0 {
+				positions = append(positions, cue)
+			}
+
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, cue := range positions {
+		cue.time = time
+		p.rawCues = append(p.rawCues, cue)
+	}
+
+	return nil
+}
+
+// cues keeps the points of the first video track, players seek by it.
+func (p *prober) cues() []Cue {
+	videoTrack := 0
+
+	for _, track := range p.info.Tracks {
+		if track.Type == Video {
+			videoTrack = track.Number
+			break
+		}
+	}
+
+	seen := make(map[int64]bool)
+
+	var result []Cue
+
+	for _, raw := range p.rawCues {
+		if videoTrack != 0 && raw.track != videoTrack {
+			continue
+		}
+
+		if seen[raw.position] {
+			continue
+		}
+
+		seen[raw.position] = true
+
+		result = append(result, Cue{
+			Time:   float64(raw.time) * float64(p.timescale) / 1e9,
+			Offset: p.segment + raw.position,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time < result[j].Time
+	})
+
+	return result
+}
+
+func readElement(read media.ReadAt, offset int64, fileSize int64) (element, error) {
+	length := int64(maxHeaderSize)
+
+	if fileSize-offset < length {
+		length = fileSize - offset
+	}
+
+	if length <= 0 {
+		return element{}, fmt.Errorf("element is out of the file")
+	}
+
+	data, err := read(offset, int(length))
+	if err != nil {
+		return element{}, err
+	}
+
+	el, err := parseElement(data)
+	if err != nil {
+		return element{}, err
+	}
+
+	el.Offset = offset
+
+	return el, nil
+}
+
+func parseElement(data []byte) (element, error) {
+	id, idLength, err := readVint(data, true)
+	if err != nil {
+		return element{}, err
+	}
+
+	if idLength > 4 {
+		return element{}, fmt.Errorf("element ID is too long")
+	}
+
+	size, sizeLength, err := readVint(data[idLength:], false)
+	if err != nil {
+		return element{}, err
+	}
+
+	el := element{
+		ID:     uint32(id),
+		Header: int64(idLength + sizeLength),
+		Size:   int64(size),
+	}
+
+	if size == 1<<(7*sizeLength)-1 {
+		el.Size = unknownSize
+	} else if size > math.MaxInt64 {
+		return element{}, fmt.Errorf("element is too big")
+	}
+
+	return el, nil
+}
+
+// children calls visit for every element in data, which is the payload of
+// a master element.
+func children(data []byte, visit func(id uint32, data []byte) error) error {
+	for pos := 0; pos < len(data); {
+		el, err := parseElement(data[pos:])
+		if err != nil {
+			return err
+		}
+
+		start := int64(pos) + el.Header
+
+		if el.Size == unknownSize || start+el.Size > int64(len(data)) {
+			return fmt.Errorf("element %X overflows its parent", el.ID)
+		}
+
+		if err := visit(el.ID, data[start:start+el.Size]); err != nil {
+			return err
+		}
+
+		pos = int(start + el.Size)
+	}
+
+	return nil
+}
+
+// readVint reads a variable length integer. IDs keep their length marker,
+// sizes don`t.
+func readVint(data []byte, keepMarker bool) (uint64, int, error) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, fmt.Errorf("invalid variable length integer")
+	}
+
+	length := 1
+
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+
+	if len(data) < length {
+		return 0, 0, fmt.Errorf("variable length integer is too short")
+	}
+
+	value := uint64(data[0])
+
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+
+	for i := 1; i < length; i++ {
+		value = value<<8 | uint64(data[i])
+	}
+
+	return value, length, nil
+}
+
+func readUint(data []byte) uint64 {
+	var value uint64
+
+	for i := 0; i < len(data) && i < 8; i++ {
+		value = value<<8 | uint64(data[i])
+	}
+
+	return value
+}
+
+func readFloat(data []byte) (float64, error) {
+	switch len(data) {
+
+	case 0:
+		return 0, nil
+
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+
+	default:
+		return 0, fmt.Errorf("float has invalid size %d", len(data))
+
+	}
+}
+
+func readString(data []byte) string {
+	return strings.TrimRight(string(data), "\x00")
+}
+
+func trackType(value uint64) TrackType {
+	switch value {
+
+	case 1:
+		return Video
+
+	case 2:
+		return Audio
+
+	case 0x11:
+		return Subtitle
+
+	default:
+		return Other
+
+	}
+}
@@ -0,0 +1,406 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Keyframe is a sync sample of a track. Offset is the position of the
+// sample in the file.
+type Keyframe struct {
+	Time   float64
+	Offset int64
+}
+
+type Track struct {
+	Handler   string
+	Codec     string
+	Language  string
+	Keyframes []Keyframe
+}
+
+func (t Track) IsVideo() bool {
+	return t.Handler == "vide"
+}
+
+type Movie struct {
+	Duration float64
+	Tracks   []Track
+}
+
+// sampleTable holds the boxes of stbl that are needed to find where the
+// sync samples are.
+type sampleTable struct {
+	timescale    uint32
+	deltas       []sttsEntry
+	syncSamples  []uint32
+	hasSync      bool
+	chunks       []stscEntry
+	sampleSize   uint32
+	sampleSizes  []uint32
+	sampleCount  uint32
+	chunkOffsets []int64
+}
+
+type sttsEntry struct {
+	count uint32
+	delta uint32
+}
+
+type stscEntry struct {
+	firstChunk uint32
+	samples    uint32
+}
+
+// ParseMoov reads the movie header and the sample tables of every track.
+// Keyframes are only collected for tracks with a sync sample table, in the
+// other ones every sample is a sync sample.
+func ParseMoov(payload []byte) (*Movie, error) {
+	mvhd, isFound := child(payload, "mvhd")
+	if !isFound {
+		return nil, fmt.Errorf("moov has no mvhd box")
+	}
+
+	timescale, duration, err := parseDuration(mvhd)
+	if err != nil {
+		return nil, err
+	}
+
+	movie := &Movie{}
+
+	if timescale != 0 {
+		movie.Duration = float64(duration) / float64(timescale)
+	}
+
+	for _, trak := range childrenOf(payload, "trak") {
+		track, err := parseTrack(trak)
+		if err != nil {
+			return nil, err
+		}
+
+		movie.Tracks = append(movie.Tracks, track)
+	}
+
+	return movie, nil
+}
+
+func parseTrack(trak []byte) (Track, error) {
+	track := Track{
+		Language: "und",
+	}
+
+	mdia, isFound := child(trak, "mdia")
+	if !isFound {
+		return track, nil
+	}
+
+	table := &sampleTable{}
+
+	if mdhd, isFound := child(mdia, "mdhd"); isFound {
+		timescale, _, err := parseDuration(mdhd)
+		if err != nil {
+			return Track{}, err
+		}
+
+		table.timescale = timescale
+		track.Language = parseLanguage(mdhd)
+	}
+
+	if hdlr, isFound := child(mdia, "hdlr"); isFound && len(hdlr) >= 12 {
+		track.Handler = string(hdlr[8:12])
+	}
+
+	stbl, isFound := find(mdia, "minf", "stbl")
+	if !isFound {
+		return track, nil
+	}
+
+	if stsd, isFound := child(stbl, "stsd"); isFound && len(stsd) >= 16 {
+		track.Codec = string(stsd[12:16])
+	}
+
+	if err := table.parse(stbl); err != nil {
+		return Track{}, err
+	}
+
+	if table.hasSync && table.timescale != 0 {
+		track.Keyframes = table.keyframes()
+	}
+
+	return track, nil
+}
+
+func (t *sampleTable) parse(stbl []byte) error {
+	if stts, isFound := child(stbl, "stts"); isFound {
+		entries, err := fullBoxEntries(stts, 8)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			t.deltas = append(t.deltas, sttsEntry{
+				count: binary.BigEndian.Uint32(entry[0:4]),
+				delta: binary.BigEndian.Uint32(entry[4:8]),
+			})
+		}
+	}
+
+	if stss, isFound := child(stbl, "stss"); isFound {
+		entries, err := fullBoxEntries(stss, 4)
+		if err != nil {
+			return err
+		}
+
+		t.hasSync = true
+
+		for _, entry := range entries {
+			t.syncSamples = append(t.syncSamples, binary.BigEndian.Uint32(entry))
+		}
+	}
+
+	if stsc, isFound := child(stbl, "stsc"); isFound {
+		entries, err := fullBoxEntries(stsc, 12)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			t.chunks = append(t.chunks, stscEntry{
+				firstChunk: binary.BigEndian.Uint32(entry[0:4]),
+				samples:    binary.BigEndian.Uint32(entry[4:8]),
+			})
+		}
+	}
+
+	if stsz, isFound := child(stbl, "stsz"); isFound {
+		if len(stsz) < 12 {
+			return fmt.Errorf("stsz box is too short")
+		}
+
+		t.sampleSize = binary.BigEndian.Uint32(stsz[4:8])
+		t.sampleCount = binary.BigEndian.Uint32(stsz[8:12])
+
+		if t.sampleSize == 0 {
+			if uint64(len(stsz)-12) < uint64(t.sampleCount)*4 {
+				return fmt.Errorf("stsz box is too short")
+			}
+
+			for i := uint32(0); i < t.sampleCount; i++ {
+				t.sampleSizes = append(t.sampleSizes, binary.BigEndian.Uint32(stsz[12+i*4:]))
+			}
+		}
+	}
+
+	if stco, isFound := child(stbl, "stco"); isFound {
+		entries, err := fullBoxEntries(stco, 4)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			t.chunkOffsets = append(t.chunkOffsets, int64(binary.BigEndian.Uint32(entry)))
+		}
+	} else if co64, isFound := child(stbl, "co64"); isFound {
+		entries, err := fullBoxEntries(co64, 8)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			t.chunkOffsets = append(t.chunkOffsets, int64(binary.BigEndian.Uint64(entry)))
+		}
+	}
+
+	return nil
+}
+
+// keyframes walks the samples chunk by chunk, keeping the time and the file
+// offset of every sync sample.
+func (t *sampleTable) keyframes() []Keyframe {
+	var result []Keyframe
+
+	sync := 0
+	sample := uint32(1)
+
+	delta := 0
+	deltaLeft := uint32(0)
+
+	if len(t.deltas) > 0 {
+		deltaLeft = t.deltas[0].count
+	}
+
+	time := uint64(0)
+
+	for chunk := 0; chunk < len(t.chunkOffsets) && sync < len(t.syncSamples); chunk++ {
+		offset := t.chunkOffsets[chunk]
+
+		for i := uint32(0); i < t.samplesInChunk(uint32(chunk+1)); i++ {
+			if sample > t.sampleCount || sync >= len(t.syncSamples) {
+				return result
+			}
+
+			if t.syncSamples[sync] == sample {
+				result = append(result, Keyframe{
+					Time:   float64(time) / float64(t.timescale),
+					Offset: offset,
+				})
+
+				sync++
+			}
+
+			offset += int64(t.size(sample))
+
+			for deltaLeft == 0 && delta+1 < len(t.deltas) {
+				delta++
+				deltaLeft = t.deltas[delta].count
+			}
+
+			if deltaLeft > 0 {
+				time += uint64(t.deltas[delta].delta)
+				deltaLeft--
+			}
+
+			sample++
+		}
+	}
+
+	return result
+}
+
+func (t *sampleTable) samplesInChunk(chunk uint32) uint32 {
+	var samples uint32
+
+	for _, entry := range t.chunks {
+		if entry.firstChunk > chunk {
+			break
+		}
+
+		samples = entry.samples
+	}
+
+	return samples
+}
+
+func (t *sampleTable) size(sample uint32) uint32 {
+	if t.sampleSize != 0 {
+		return t.sampleSize
+	}
+
+	if int(sample) > len(t.sampleSizes) {
+		return 0
+	}
+
+	return t.sampleSizes[sample-1]
+}
+
+// parseDuration reads the timescale and the duration of mvhd and mdhd,
+// they share the layout.
+func parseDuration(payload []byte) (uint32, uint64, error) {
+	if len(payload) < 4 {
+		return 0, 0, fmt.Errorf("header box is too short")
+	}
+
+	if payload[0] == 1 {
+		if len(payload) < 32 {
+			return 0, 0, fmt.Errorf("header box is too short")
+		}
+
+		return binary.BigEndian.Uint32(payload[20:24]), binary.BigEndian.Uint64(payload[24:32]), nil
+	}
+
+	if len(payload) < 20 {
+		return 0, 0, fmt.Errorf("header box is too short")
+	}
+
+	return binary.BigEndian.Uint32(payload[12:16]), uint64(binary.BigEndian.Uint32(payload[16:20])), nil
+}
+
+// parseLanguage unpacks the ISO-639-2 code of mdhd, it is stored as three
+// 5 bit letters.
+func parseLanguage(mdhd []byte) string {
+	pos := 20
+
+	if mdhd[0] == 1 {
+		pos = 32
+	}
+
+	if len(mdhd) < pos+2 {
+		return "und"
+	}
+
+	packed := binary.BigEndian.Uint16(mdhd[pos : pos+2])
+
+	if packed == 0 {
+		return "und"
+	}
+
+	return string([]byte{
+		byte(packed>>10&0x1F) + 0x60,
+		byte(packed>>5&0x1F) + 0x60,
+		byte(packed&0x1F) + 0x60,
+	})
+}
+
+// fullBoxEntries returns the entries of a table box: version and flags, the
+// entry count and entries of the same size.
+func fullBoxEntries(payload []byte, size int) ([][]byte, error) {
+	if len(payload) < 8 {
+		return nil, fmt.Errorf("table box is too short")
+	}
+
+	count := binary.BigEndian.Uint32(payload[4:8])
+
+	if uint64(len(payload)-8) < uint64(count)*uint64(size) {
+		return nil, fmt.Errorf("table box is too short")
+	}
+
+	entries := make([][]byte, 0, count)
+
+	for i := 0; i < int(count); i++ {
+		entries = append(entries, payload[8+i*size:8+(i+1)*size])
+	}
+
+	return entries, nil
+}
+
+func find(payload []byte, path ...string) ([]byte, bool) {
+	for _, boxType := range path {
+		data, isFound := child(payload, boxType)
+		if !isFound {
+			return nil, false
+		}
+
+		payload = data
+	}
+
+	return payload, true
+}
+
+func child(payload []byte, boxType string) ([]byte, bool) {
+	all := childrenOf(payload, boxType)
+
+	if len(all) == 0 {
+		return nil, false
+	}
+
+	return all[0], true
+}
+
+func childrenOf(payload []byte, boxType string) [][]byte {
+	boxes, err := Children(payload, 0)
+	if err != nil {
+		return nil
+	}
+
+	var result [][]byte
+
+	for _, box := range boxes {
+		if box.Type != boxType || box.End() > int64(len(payload)) {
+			continue
+		}
+
+		result = append(result, payload[box.PayloadOffset():box.End()])
+	}
+
+	return result
+}
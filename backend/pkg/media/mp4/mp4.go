@@ -0,0 +1,236 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/nikitaSstepanov/p2p-streaming-service/backend/pkg/media"
+)
+
+const (
+	headerSize      = 8
+	largeHeaderSize = 16
+
+	// Files are expected to have only a few top level boxes, this protects
+	// the walk from garbage in broken files.
+	maxTopLevelBoxes = 1024
+)
+
+type Box struct {
+	Type   string
+	Offset int64
+	Size   int64
+	Header int64
+}
+
+func (b Box) End() int64 {
+	return b.Offset + b.Size
+}
+
+func (b Box) PayloadOffset() int64 {
+	return b.Offset + b.Header
+}
+
+func (b Box) PayloadSize() int64 {
+	return b.Size - b.Header
+}
+
+// ParseHeader reads the box header at the beginning of data. The box is at
+// offset in a file of fileSize bytes.
+func ParseHeader(data []byte, offset int64, fileSize int64) (Box, error) {
+	if len(data) < headerSize {
+		return Box{}, fmt.Errorf("box header is too short")
+	}
+
+	box := Box{
+		Type:   string(data[4:8]),
+		Offset: offset,
+		Size:   int64(binary.BigEndian.Uint32(data[0:4])),
+		Header: headerSize,
+	}
+
+	switch box.Size {
+
+	case 0:
+		box.Size = fileSize - offset
+
+	case 1:
+		if len(data) < largeHeaderSize {
+			return Box{}, fmt.Errorf("box header is too short")
+		}
+
+		box.Size = int64(binary.BigEndian.Uint64(data[8:16]))
+		box.Header = largeHeaderSize
+
+	}
+
+	if box.Size < box.Header {
+		return Box{}, fmt.Errorf("box %q has invalid size %d", box.Type, box.Size)
+	}
+
+	return box, nil
+}
+
+// Children parses the boxes inside the payload of a container box.
+func Children(payload []byte, offset int64) ([]Box, error) {
+	var boxes []Box
+
+	for pos := int64(0); pos+headerSize <= int64(len(payload)); {
+		box, err := ParseHeader(payload[pos:], offset+pos, offset+int64(len(payload)))
+		if err != nil {
+			return nil, err
+		}
+
+		boxes = append(boxes, box)
+
+		pos += box.Size
+	}
+
+	return boxes, nil
+}
+
+// Walk visits top level boxes of the file until visit returns false. Only
+// the box headers are read, so the payloads don`t have to be downloaded.
+func Walk(read media.ReadAt, fileSize int64, visit func(Box) bool) error {
+	offset := int64(0)
+
+	for i := 0; i < maxTopLevelBoxes && offset+headerSize <= fileSize; i++ {
+		length := largeHeaderSize
+
+		if fileSize-offset < largeHeaderSize {
+			length = int(fileSize - offset)
+		}
+
+		data, err := read(offset, length)
+		if err != nil {
+			return err
+		}
+
+		box, err := ParseHeader(data, offset, fileSize)
+		if err != nil {
+			return err
+		}
+
+		if !visit(box) {
+			return nil
+		}
+
+		offset = box.End()
+	}
+
+	return nil
+}
+
+type Reference struct {
+	Size     int64
+	Duration uint32
+}
+
+// Sidx is the segment index box, fragmented files use it to describe
+// byte ranges and durations of their fragments.
+type Sidx struct {
+	Timescale   uint32
+	FirstOffset int64
+	References  []Reference
+}
+
+func ParseSidx(payload []byte) (*Sidx, error) {
+	if len(payload) < 12 {
+		return nil, fmt.Errorf("sidx box is too short")
+	}
+
+	version := payload[0]
+
+	sidx := &Sidx{
+		Timescale: binary.BigEndian.Uint32(payload[8:12]),
+	}
+
+	pos := 12
+
+	if version == 0 {
+		if len(payload) < pos+8 {
+			return nil, fmt.Errorf("sidx box is too short")
+		}
+
+		sidx.FirstOffset = int64(binary.BigEndian.Uint32(payload[pos+4 : pos+8]))
+		pos += 8
+	} else {
+		if len(payload) < pos+16 {
+			return nil, fmt.Errorf("sidx box is too short")
+		}
+
+		sidx.FirstOffset = int64(binary.BigEndian.Uint64(payload[pos+8 : pos+16]))
+		pos += 16
+	}
+
+	if len(payload) < pos+4 {
+		return nil, fmt.Errorf("sidx box is too short")
+	}
+
+	count := int(binary.BigEndian.Uint16(payload[pos+2 : pos+4]))
+	pos += 4
+
+	if len(payload) < pos+count*12 {
+		return nil, fmt.Errorf("sidx box is too short")
+	}
+
+	for i := 0; i < count; i++ {
+		entry := payload[pos+i*12:]
+
+		sidx.References = append(sidx.References, Reference{
+			Size:     int64(binary.BigEndian.Uint32(entry[0:4]) & 0x7FFFFFFF),
+			Duration: binary.BigEndian.Uint32(entry[4:8]),
+		})
+	}
+
+	return sidx, nil
+}
+
+// Layout is where the movie header and the first media data are in the file.
+type Layout struct {
+	Moov Box
+	Mdat Box
+}
+
+// MoovAtEnd reports files written without "fast start", the player needs
+// the tail of such a file before it can play anything.
+func (l Layout) MoovAtEnd() bool {
+	return l.Moov.Offset > l.Mdat.Offset
+}
+
+// Locate walks the top level boxes until it finds both moov and the first
+// mdat. Fragmented files keep their media in moof+mdat pairs, so the first
+// mdat is the first fragment.
+func Locate(read media.ReadAt, fileSize int64) (Layout, error) {
+	var (
+		layout           Layout
+		hasMoov, hasMdat bool
+	)
+
+	err := Walk(read, fileSize, func(box Box) bool {
+		switch box.Type {
+
+		case "moov":
+			layout.Moov = box
+			hasMoov = true
+
+		case "mdat":
+			if !hasMdat {
+				layout.Mdat = box
+				hasMdat = true
+			}
+
+		}
+
+		return !hasMoov || !hasMdat
+	})
+	if err != nil {
+		return Layout{}, err
+	}
+
+	if !hasMoov || !hasMdat {
+		return Layout{}, fmt.Errorf("file has no moov or mdat box")
+	}
+
+	return layout, nil
+}
@@ -0,0 +1,104 @@
+package mpegts
+
+const (
+	PacketSize = 188
+	Clock      = 90000
+
+	syncByte = 0x47
+)
+
+// Duration estimates the duration of the stream in seconds from the first
+// PTS in head and the last PTS in tail.
+func Duration(head []byte, tail []byte) (float64, bool) {
+	first, isFound := FirstPTS(head)
+	if !isFound {
+		return 0, false
+	}
+
+	last, isFound := LastPTS(tail)
+	if !isFound || last <= first {
+		return 0, false
+	}
+
+	return float64(last-first) / Clock, true
+}
+
+func FirstPTS(data []byte) (int64, bool) {
+	for offset := align(data); offset+PacketSize <= len(data); offset += PacketSize {
+		if pts, isFound := packetPTS(data[offset : offset+PacketSize]); isFound {
+			return pts, true
+		}
+	}
+
+	return 0, false
+}
+
+func LastPTS(data []byte) (int64, bool) {
+	start := align(data)
+
+	if start >= len(data) {
+		return 0, false
+	}
+
+	last := start + (len(data)-start)/PacketSize*PacketSize - PacketSize
+
+	for offset := last; offset >= start; offset -= PacketSize {
+		if pts, isFound := packetPTS(data[offset : offset+PacketSize]); isFound {
+			return pts, true
+		}
+	}
+
+	return 0, false
+}
+
+// align finds the first packet in data that can start in the middle of a
+// packet, when it was cut at a piece boundary.
+func align(data []byte) int {
+	for offset := 0; offset < PacketSize && offset+PacketSize < len(data); offset++ {
+		if data[offset] == syncByte && data[offset+PacketSize] == syncByte {
+			return offset
+		}
+	}
+
+	return len(data)
+}
+
+func packetPTS(packet []byte) (int64, bool) {
+	if packet[0] != syncByte || packet[1]&0x40 == 0 {
+		return 0, false
+	}
+
+	payload := 4
+
+	adaptation := (packet[3] >> 4) & 0x3
+
+	if adaptation == 0 || adaptation == 2 {
+		return 0, false
+	}
+
+	if adaptation == 3 {
+		payload += 1 + int(packet[4])
+	}
+
+	if payload+14 > PacketSize {
+		return 0, false
+	}
+
+	pes := packet[payload:]
+
+	if pes[0] != 0 || pes[1] != 0 || pes[2] != 1 {
+		return 0, false
+	}
+
+	if pes[7]&0x80 == 0 {
+		return 0, false
+	}
+
+	pts := int64(pes[9]>>1&0x07)<<30 |
+		int64(pes[10])<<22 |
+		int64(pes[11]>>1)<<15 |
+		int64(pes[12])<<7 |
+		int64(pes[13]>>1)
+
+	return pts, true
+}
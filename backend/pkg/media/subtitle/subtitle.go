@@ -0,0 +1,361 @@
+package subtitle
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type Format string
+
+const (
+	SRT    Format = "srt"
+	ASS    Format = "ass"
+	WebVTT Format = "vtt"
+
+	Undefined = "und"
+)
+
+var formats = map[string]Format{
+	".srt": SRT,
+	".ass": ASS,
+	".ssa": ASS,
+	".vtt": WebVTT,
+}
+
+// languages maps the codes and names seen in file names to ISO 639-1 codes.
+var languages = map[string]string{
+	"en": "en", "eng": "en", "english": "en",
+	"ru": "ru", "rus": "ru", "russian": "ru",
+	"uk": "uk", "ukr": "uk", "ukrainian": "uk",
+	"de": "de", "ger": "de", "deu": "de", "german": "de",
+	"fr": "fr", "fre": "fr", "fra": "fr", "french": "fr",
+	"es": "es", "spa": "es", "spanish": "es",
+	"it": "it", "ita": "it", "italian": "it",
+	"pt": "pt", "por": "pt", "portuguese": "pt",
+	"pl": "pl", "pol": "pl", "polish": "pl",
+	"nl": "nl", "dut": "nl", "nld": "nl", "dutch": "nl",
+	"sv": "sv", "swe": "sv", "swedish": "sv",
+	"fi": "fi", "fin": "fi", "finnish": "fi",
+	"no": "no", "nor": "no", "norwegian": "no",
+	"da": "da", "dan": "da", "danish": "da",
+	"cs": "cs", "cze": "cs", "ces": "cs", "czech": "cs",
+	"tr": "tr", "tur": "tr", "turkish": "tr",
+	"el": "el", "gre": "el", "ell": "el", "greek": "el",
+	"he": "he", "heb": "he", "hebrew": "he",
+	"ar": "ar", "ara": "ar", "arabic": "ar",
+	"ja": "ja", "jpn": "ja", "japanese": "ja",
+	"ko": "ko", "kor": "ko", "korean": "ko",
+	"zh": "zh", "chi": "zh", "zho": "zh", "chinese": "zh",
+}
+
+var (
+	srtTiming = regexp.MustCompile(`^(\d+):(\d{2}):(\d{2})[,.](\d{1,3})\s*-->\s*(\d+):(\d{2}):(\d{2})[,.](\d{1,3})`)
+	fontTag   = regexp.MustCompile(`(?i)</?font[^>]*>`)
+	assTags   = regexp.MustCompile(`\{[^}]*\}`)
+	styleTag  = regexp.MustCompile(`(?i)&lt;(/?)([ibu])&gt;`)
+)
+
+func Detect(name string) (Format, bool) {
+	format, isFound := formats[strings.ToLower(path.Ext(name))]
+
+	return format, isFound
+}
+
+// Language guesses the language from the words of the file name, the last
+// known one wins: "Movie.2019.en.forced.srt" gives "en". The first word is
+// the title unless it is the only one, so "It.2017.srt" isn`t Italian.
+func Language(name string) string {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+
+	words := strings.FieldsFunc(strings.ToLower(base), func(r rune) bool {
+		return strings.ContainsRune(" ._-[]()", r)
+	})
+
+	first := 1
+
+	if len(words) == 1 {
+		first = 0
+	}
+
+	for i := len(words) - 1; i >= first; i-- {
+		if language, isFound := languages[words[i]]; isFound {
+			return language
+		}
+	}
+
+	return Undefined
+}
+
+// ToVTT converts the subtitles to WebVTT. Only the text and timings are
+// kept, styles of ASS are dropped.
+func ToVTT(format Format, data []byte) (string, error) {
+	text := decode(data)
+
+	switch format {
+
+	case SRT:
+		return fromSRT(text)
+
+	case ASS:
+		return fromASS(text)
+
+	case WebVTT:
+		if !strings.HasPrefix(text, "WEBVTT") {
+			return "", fmt.Errorf("file has no WEBVTT header")
+		}
+
+		return text, nil
+
+	default:
+		return "", fmt.Errorf("unknown subtitle format %q", format)
+
+	}
+}
+
+type cue struct {
+	start int
+	end   int
+	text  string
+}
+
+func fromSRT(text string) (string, error) {
+	var cues []cue
+
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+
+		for len(lines) > 0 && !srtTiming.MatchString(lines[0]) {
+			lines = lines[1:]
+		}
+
+		if len(lines) == 0 {
+			continue
+		}
+
+		match := srtTiming.FindStringSubmatch(lines[0])
+
+		cues = append(cues, cue{
+			start: milliseconds(match[1], match[2], match[3], match[4]),
+			end:   milliseconds(match[5], match[6], match[7], match[8]),
+			text:  srtText(strings.Join(lines[1:], "\n")),
+		})
+	}
+
+	if len(cues) == 0 {
+		return "", fmt.Errorf("file has no cues")
+	}
+
+	return render(cues), nil
+}
+
+// srtText escapes the cue text the same way the ASS text is. Only the
+// <i>, <b> and <u> tags are kept, WebVTT supports them too.
+func srtText(text string) string {
+	text = escape(fontTag.ReplaceAllString(text, ""))
+
+	return styleTag.ReplaceAllStringFunc(text, func(tag string) string {
+		match := styleTag.FindStringSubmatch(tag)
+
+		return "<" + match[1] + strings.ToLower(match[2]) + ">"
+	})
+}
+
+// fromASS reads the Dialogue lines of the Events section. The columns are
+// taken from its Format line, Text is always the last one and can contain
+// commas.
+func fromASS(text string) (string, error) {
+	var (
+		cues     []cue
+		isEvents bool
+		columns  []string
+	)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "[") {
+			isEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+
+		if !isEvents {
+			continue
+		}
+
+		key, value, isFound := strings.Cut(line, ":")
+		if !isFound {
+			continue
+		}
+
+		switch strings.TrimSpace(key) {
+
+		case "Format":
+			columns = strings.Split(value, ",")
+
+			for i := range columns {
+				columns[i] = strings.TrimSpace(columns[i])
+			}
+
+		case "Dialogue":
+			if len(columns) == 0 {
+				continue
+			}
+
+			fields := strings.SplitN(value, ",", len(columns))
+
+			if len(fields) != len(columns) {
+				continue
+			}
+
+			c, isValid := assCue(columns, fields)
+
+			if isValid {
+				cues = append(cues, c)
+			}
+
+		}
+	}
+
+	if len(cues) == 0 {
+		return "", fmt.Errorf("file has no dialogue lines")
+	}
+
+	sort.SliceStable(cues, func(i, j int) bool {
+		return cues[i].start < cues[j].start
+	})
+
+	return render(cues), nil
+}
+
+func assCue(columns []string, fields []string) (cue, bool) {
+	var (
+		c                cue
+		hasStart, hasEnd bool
+	)
+
+	for i, column := range columns {
+		field := strings.TrimSpace(fields[i])
+
+		switch column {
+
+		case "Start":
+			c.start, hasStart = assTime(field)
+
+		case "End":
+			c.end, hasEnd = assTime(field)
+
+		case "Text":
+			field = assTags.ReplaceAllString(field, "")
+			field = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(field)
+
+			c.text = escape(field)
+
+		}
+	}
+
+	return c, hasStart && hasEnd && c.text != ""
+}
+
+// assTime parses "H:MM:SS.cc".
+func assTime(value string) (int, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+
+	seconds, fraction, _ := strings.Cut(parts[2], ".")
+
+	return milliseconds(parts[0], parts[1], seconds, fraction), true
+}
+
+func render(cues []cue) string {
+	var result strings.Builder
+
+	result.WriteString("WEBVTT\n\n")
+
+	for _, c := range cues {
+		text := strings.TrimSpace(c.text)
+
+		if text == "" {
+			continue
+		}
+
+		// A blank line would end the cue.
+		for strings.Contains(text, "\n\n") {
+			text = strings.ReplaceAll(text, "\n\n", "\n")
+		}
+
+		fmt.Fprintf(&result, "%s --> %s\n%s\n\n", timestamp(c.start), timestamp(c.end), text)
+	}
+
+	return result.String()
+}
+
+func timestamp(ms int) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// milliseconds sums up the parts of a timing, the fraction can have one to
+// three digits.
+func milliseconds(hours, minutes, seconds, fraction string) int {
+	h, _ := strconv.Atoi(hours)
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.Atoi(seconds)
+
+	fraction = (fraction + "000")[:3]
+
+	f, _ := strconv.Atoi(fraction)
+
+	return ((h*60+m)*60+s)*1000 + f
+}
+
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// decode strips the BOM and normalizes the line breaks. Files that aren`t
+// UTF-8 are read as Windows-1251, it is the most common legacy encoding of
+// the subtitles we get.
+func decode(data []byte) string {
+	data = []byte(strings.TrimPrefix(string(data), "\uFEFF"))
+
+	text := string(data)
+
+	if !utf8.Valid(data) {
+		text = fromWindows1251(data)
+	}
+
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+func fromWindows1251(data []byte) string {
+	var result strings.Builder
+
+	for _, b := range data {
+		switch {
+
+		case b < 0x80:
+			result.WriteByte(b)
+
+		case b >= 0xC0:
+			result.WriteRune(rune(0x0410 + int(b) - 0xC0))
+
+		case b == 0xA8:
+			result.WriteRune('Ё')
+
+		case b == 0xB8:
+			result.WriteRune('ё')
+
+		default:
+			result.WriteRune('\uFFFD')
+
+		}
+	}
+
+	return result.String()
+}